@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+type listUsersQuery struct {
+	Limit  int    `json:"limit" validate:"gte=1"`
+	Offset int    `json:"offset" validate:"gte=0"`
+	Sort   string `json:"sort" validate:"oneof=created_at -created_at username -username"`
+}
+
+type listUsersResponse struct {
+	Data  []store.User `json:"data"`
+	Total int          `json:"total"`
+}
+
+func (app *application) adminListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := app.parsePagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	q := listUsersQuery{Limit: limit, Offset: offset, Sort: "-created_at"}
+	if sort := r.URL.Query().Get("sort"); sort != "" {
+		q.Sort = sort
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	users, total, err := app.store.Users.List(r.Context(), q.Limit, q.Offset, q.Sort)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, listUsersResponse{Data: users, Total: total}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestAdminListUsersHandler(t *testing.T) {
+	admin := &store.User{ID: 1, Username: "root", Role: store.RoleAdmin, IsActive: true}
+	member := &store.User{ID: 2, Username: "alice", Role: store.RoleUser, IsActive: true, Password: "$2a$hash"}
+
+	type call struct {
+		limit, offset int
+		sort          string
+	}
+
+	tests := []struct {
+		name       string
+		as         *store.User
+		query      string
+		wantStatus int
+		want       call
+	}{
+		{name: "defaults", as: admin, wantStatus: http.StatusOK, want: call{20, 0, "-created_at"}},
+		{name: "page", as: admin, query: "?limit=5&offset=10&sort=username", wantStatus: http.StatusOK, want: call{5, 10, "username"}},
+		{name: "limit clamped", as: admin, query: "?limit=1000", wantStatus: http.StatusOK, want: call{100, 0, "-created_at"}},
+		{name: "limit zero", as: admin, query: "?limit=0", wantStatus: http.StatusBadRequest},
+		{name: "negative offset", as: admin, query: "?offset=-1", wantStatus: http.StatusBadRequest},
+		{name: "unknown sort", as: admin, query: "?sort=email", wantStatus: http.StatusUnprocessableEntity},
+		{name: "non-admin", as: member, wantStatus: http.StatusForbidden},
+		{name: "anonymous", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *call
+			app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{
+				getByID: usersByID(admin, member),
+				list: func(_ context.Context, limit, offset int, sort string) ([]store.User, int, error) {
+					got = &call{limit, offset, sort}
+					return []store.User{*admin, *member}, 42, nil
+				},
+			}})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/admin/users"+tt.query, nil)
+			if tt.as != nil {
+				authorize(t, app, req, tt.as.ID)
+			}
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if got != nil {
+					t.Errorf("List() was called with %+v", *got)
+				}
+				return
+			}
+			if got == nil || *got != tt.want {
+				t.Errorf("List() called with %+v, want %+v", got, tt.want)
+			}
+
+			var body struct {
+				Data  []map[string]any `json:"data"`
+				Total int              `json:"total"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Total != 42 || len(body.Data) != 2 {
+				t.Errorf("body = %+v, want two users and a total of 42", body)
+			}
+			for _, u := range body.Data {
+				if _, ok := u["password"]; ok {
+					t.Errorf("user %v exposes the password", u["username"])
+				}
+			}
+		})
+	}
+}
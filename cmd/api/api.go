@@ -1,61 +1,423 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rissabekov-wes/social/internal/auth"
+	"github.com/rissabekov-wes/social/internal/blobstore"
+	"github.com/rissabekov-wes/social/internal/buildinfo"
+	"github.com/rissabekov-wes/social/internal/cache"
+	appconfig "github.com/rissabekov-wes/social/internal/config"
+	"github.com/rissabekov-wes/social/internal/db"
+	"github.com/rissabekov-wes/social/internal/mailer"
+	"github.com/rissabekov-wes/social/internal/moderation"
 	"github.com/rissabekov-wes/social/internal/store"
+	"github.com/rissabekov-wes/social/internal/webhook"
+	"github.com/rissabekov-wes/social/internal/worker"
 )
 
+// apiVersionPrefix is the path prefix for every versioned route. Operational
+// endpoints such as /healthz and /metrics stay at the root.
+const (
+	apiVersionPrefix = "/v1"
+)
+
+// defaultJWTSecret is the JWT_SECRET fallback for local runs. Anyone who has
+// read this file can sign tokens with it, so it is refused elsewhere.
+const defaultJWTSecret = "example"
+
 type application struct {
-	config config
-	store  store.Storage
+	config        config
+	store         store.Storage
+	logger        *slog.Logger
+	authenticator auth.Authenticator
+	metrics       *metrics
+	mailer        mailer.Client
+	moderator     moderation.Filter
+	blobs         blobstore.Store
+	workers       *worker.Pool
+	cache         cache.Store
+	feeds         *feedCache
+	webhooks      *webhook.Dispatcher
+
+	// dummyPasswordHash is built by newDummyPasswordHash.
+	dummyPasswordHash string
+
+	// webhookRetries holds failed deliveries until their next attempt.
+	webhookRetries webhookRetries
+
+	// startedAt is when the process came up, for the uptime in /healthz.
+	startedAt time.Time
+
+	// draining is set once shutdown begins so /readyz can fail fast.
+	draining atomic.Bool
 }
 
 type config struct {
-	addr string
-	db   dbConfig
-	env  string
+	addr              string
+	db                dbConfig
+	env               appconfig.Environment
+	auth              authConfig
+	log               logConfig
+	rateLimiter       rateLimiterConfig
+	cors              corsConfig
+	tracing           tracingConfig
+	mail              mailConfig
+	blob              blobConfig
+	workers           workerConfig
+	webhooks          webhookConfig
+	cache             cacheConfig
+	tags              tagsConfig
+	pagination        paginationConfig
+	bannedWords       []string
+	trustedProxies    []net.IPNet
+	deleteMode        store.DeleteMode
+	maxCommentDepth   int
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	shutdownTimeout   time.Duration
+	drainDelay        time.Duration
+	idempotencyTTL    time.Duration
+	maxRequestBytes   int64
+	compressMinBytes  int
+	requestTimeout    time.Duration
+	exportTimeout     time.Duration
 }
 
 type dbConfig struct {
-	addr         string
-	maxOpenConns int
-	maxIdleConns int
-	maxIdleTime  string
+	addr          string
+	replicaAddr   string
+	maxOpenConns  int
+	maxIdleConns  int
+	maxIdleTime   time.Duration
+	queryTimeout  time.Duration
+	slowQuery     time.Duration
+	autoMigrate   bool
+	statsInterval time.Duration
+}
+
+// webhookConfig tunes outbound webhook delivery; timeout bounds each
+// attempt.
+type webhookConfig struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	timeout    time.Duration
+}
+
+type workerConfig struct {
+	size      int
+	queueSize int
+}
+
+type cacheConfig struct {
+	kind      string
+	redisAddr string
+	feedTTL   time.Duration
+}
+
+type tagsConfig struct {
+	trendingWindow time.Duration
+	cacheTTL       time.Duration
+}
+
+type blobConfig struct {
+	kind           string
+	dir            string
+	baseURL        string
+	maxAvatarBytes int64
+}
+
+type mailConfig struct {
+	kind        string
+	frontendURL string
+	sendTimeout time.Duration
+}
+
+type authConfig struct {
+	bcryptCost       int
+	token            tokenConfig
+	invitationTTL    time.Duration
+	resendInterval   time.Duration
+	passwordResetTTL time.Duration
+}
+
+type tokenConfig struct {
+	secret     string
+	ttl        time.Duration
+	refreshTTL time.Duration
+	iss        string
+}
+
+// isDevelopment reports whether the app runs on a developer machine, the
+// only place insecure defaults are accepted and internal error details are
+// included in responses.
+func (cfg config) isDevelopment() bool {
+	return cfg.env.IsDevelopment()
+}
+
+// validateSecrets rejects a missing or default JWT_SECRET outside
+// development.
+func (cfg config) validateSecrets() error {
+	if cfg.isDevelopment() {
+		return nil
+	}
+	if cfg.auth.token.secret == "" || cfg.auth.token.secret == defaultJWTSecret {
+		return fmt.Errorf("JWT_SECRET must be set to a non-default value when ENV_NAME is %q", cfg.env.EnvName)
+	}
+	return nil
+}
+
+// String renders the config with secrets masked so it is safe to log.
+func (cfg config) String() string {
+	type plain config
+	redacted := plain(cfg)
+	redacted.db.addr = db.RedactAddr(cfg.db.addr)
+	redacted.db.replicaAddr = db.RedactAddr(cfg.db.replicaAddr)
+	if redacted.auth.token.secret != "" {
+		redacted.auth.token.secret = "****"
+	}
+
+	return fmt.Sprintf("%+v", redacted)
 }
 
 func (app *application) mount() http.Handler {
 	r := chi.NewRouter()
 
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(app.requestID)
+	r.Use(app.recoverPanic)
+	r.Use(app.enableCORS)
+	r.Use(app.realIP)
+	r.Use(app.trace)
+	r.Use(app.logRequest)
+	r.Use(app.instrument)
+	r.Use(app.rateLimit)
+	r.Use(app.maxBodyBytes(app.config.maxRequestBytes))
+	r.Use(app.compress(app.config.compressMinBytes))
+	r.Use(app.logBodies)
+
+	// Registered before any routes so chi propagates them to every
+	// sub-router mounted below.
+	r.NotFound(app.routeNotFoundResponse)
+	r.MethodNotAllowed(func(w http.ResponseWriter, req *http.Request) {
+		app.methodNotAllowedResponse(w, req, allowedMethods(r, req.URL.Path))
+	})
+
+	// Streaming endpoints sit outside the request timeout.
+	r.Method(http.MethodGet, "/metrics", app.metrics.handler())
+
+	if app.config.blob.kind == "local" {
+		r.Handle("/uploads/*", http.StripPrefix("/uploads/", http.FileServer(http.Dir(app.config.blob.dir))))
+	}
+
+	// Data exports stream for as long as the user's history takes to write.
+	r.With(app.authenticate).Get(apiVersionPrefix+"/users/me/export", app.exportUserDataHandler)
 
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Group(func(r chi.Router) {
+		r.Use(app.requestTimeout(app.config.requestTimeout))
 
-	r.Route("/v1", func(r chi.Router) {
-		r.Get("/health", app.healthCheckHandler)
+		r.Get("/healthz", app.healthzHandler)
+		r.Get("/livez", app.livezHandler)
+		r.Get("/readyz", app.readyzHandler)
+		r.Get("/version", app.versionHandler)
+		r.Get("/openapi.json", app.openAPIHandler(r))
+		r.Get("/docs", app.docsHandler)
+
+		r.Route(apiVersionPrefix, func(r chi.Router) {
+			r.Get("/health", app.healthCheckHandler)
+
+			r.Route("/users", func(r chi.Router) {
+				r.Post("/", app.registerUserHandler)
+				r.Put("/activate/{token}", app.activateUserHandler)
+				r.Post("/activate/resend", app.resendActivationHandler)
+
+				r.Group(func(r chi.Router) {
+					r.Use(app.authenticate)
+					r.Get("/feed", app.getUserFeedHandler)
+					r.Get("/me", app.getCurrentUserHandler)
+					r.Delete("/me", app.deleteCurrentUserHandler)
+					r.Post("/me/avatar", app.uploadAvatarHandler)
+					r.Post("/me/following", app.followManyHandler)
+					r.Post("/{username}/follow", app.followUserHandler)
+					r.Delete("/{username}/follow", app.unfollowUserHandler)
+					r.Post("/{id}/block", app.blockUserHandler)
+					r.Delete("/{id}/block", app.unblockUserHandler)
+				})
+
+				r.Get("/{username}", app.getUserProfileHandler)
+				r.Get("/{username}/posts", app.listUserPostsHandler)
+			})
+
+			r.Route("/posts", func(r chi.Router) {
+				r.Get("/", app.listPostsHandler)
+				r.Get("/search", app.searchPostsHandler)
+				r.Get("/{id}", app.getPostHandler)
+				r.Get("/{id}/comments", app.listCommentsHandler)
+
+				r.Group(func(r chi.Router) {
+					r.Use(app.authenticate)
+					r.With(app.idempotent).Post("/", app.createPostHandler)
+					r.Post("/{id}/like", app.likePostHandler)
+					r.Delete("/{id}/like", app.unlikePostHandler)
+					r.Post("/{id}/comments", app.createCommentHandler)
+
+					r.With(app.requireOwnership(postCtxKey, app.loadPost)).Put("/{id}", app.updatePostHandler)
+					r.With(app.requireOwnership(postCtxKey, app.loadPost)).Patch("/{id}", app.patchPostHandler)
+					r.With(app.requireOwnership(postCtxKey, app.loadPost)).Delete("/{id}", app.deletePostHandler)
+				})
+			})
+
+			r.Route("/tags", func(r chi.Router) {
+				r.Get("/trending", app.trendingTagsHandler)
+			})
+
+			r.Route("/comments", func(r chi.Router) {
+				r.Use(app.authenticate)
+				r.With(app.requireOwnership(commentCtxKey, app.loadComment)).Delete("/{id}", app.deleteCommentHandler)
+			})
+
+			r.Route("/notifications", func(r chi.Router) {
+				r.Use(app.authenticate)
+				r.Get("/", app.listNotificationsHandler)
+				r.Post("/{id}/read", app.markNotificationReadHandler)
+			})
+
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(app.authenticate)
+				r.Use(app.requireRole(store.RoleAdmin))
+				r.Get("/users", app.adminListUsersHandler)
+				r.Post("/webhooks", app.adminCreateWebhookHandler)
+			})
+
+			r.Route("/auth", func(r chi.Router) {
+				r.Post("/token", app.createTokenHandler)
+				r.Post("/refresh", app.refreshTokenHandler)
+				r.Post("/logout", app.logoutHandler)
+				r.Post("/forgot-password", app.forgotPasswordHandler)
+				r.Post("/reset-password", app.resetPasswordHandler)
+			})
+		})
 	})
 
 	return r
 }
 
+// routeMethods are the methods probed when building a 405 Allow header.
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// allowedMethods reports which methods have a route registered for path.
+func allowedMethods(routes chi.Routes, path string) []string {
+	var allowed []string
+	for _, method := range routeMethods {
+		if routes.Match(chi.NewRouteContext(), method, path) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// newServer builds the HTTP server for mux with the configured timeouts.
+func (app *application) newServer(mux http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              app.config.addr,
+		Handler:           mux,
+		WriteTimeout:      app.config.writeTimeout,
+		ReadTimeout:       app.config.readTimeout,
+		IdleTimeout:       app.config.idleTimeout,
+		ReadHeaderTimeout: app.config.readHeaderTimeout,
+	}
+}
+
 func (app *application) run(mux http.Handler) error {
-	// Implementation of the run method goes here
-	srv := &http.Server{
-		Addr:         app.config.addr,
-		Handler:      mux,
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 10,
-		IdleTimeout:  time.Minute,
+	srv := app.newServer(mux)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if app.config.db.statsInterval > 0 {
+		go app.collectDBStats(ctx, app.config.db.statsInterval, app.store.Stats)
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		bi := buildinfo.Get()
+		app.logger.Info("starting server",
+			"addr", app.config.addr,
+			"version", bi.Version,
+			"commit", bi.Commit,
+			"build_time", bi.BuildTime,
+			"go_version", bi.GoVersion,
+		)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	return app.shutdown(srv)
+}
+
+// shutdown stops the application in dependency order under a single
+// shutdownTimeout deadline: fail readiness so load balancers stop routing,
+// stop the HTTP server and wait for in-flight requests, drain background
+// tasks, give up on webhook retries still waiting, and only then close the
+// database they may still be writing to.
+// Every phase runs even if an earlier one timed out.
+func (app *application) shutdown(srv *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	app.draining.Store(true)
+	app.logger.Info("shutdown: readiness failing, draining", "delay", app.config.drainDelay)
+	select {
+	case <-time.After(app.config.drainDelay):
+	case <-ctx.Done():
+	}
+
+	app.logger.Info("shutdown: stopping http server")
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	}
+
+	app.logger.Info("shutdown: draining background tasks")
+	if err := app.workers.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("worker pool: %w", err))
+	}
+
+	app.logger.Info("shutdown: recording pending webhook retries as failed")
+	app.webhookRetries.flush()
+
+	app.logger.Info("shutdown: closing database")
+	if err := app.store.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("database: %w", err))
 	}
 
-	log.Printf("Starting server on %s", app.config.addr)
+	app.logger.Info("shutdown: complete")
 
-	return srv.ListenAndServe()
+	return errors.Join(errs...)
 }
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rissabekov-wes/social/internal/mailer"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// newDummyPasswordHash hashes, at the configured cost, a password no account
+// has. It is compared against when no account has the submitted email, so
+// that a login for an unknown email costs as much as one with a wrong
+// password and response times do not reveal which emails are registered.
+func newDummyPasswordHash(cost int) (string, error) {
+	return store.HashPassword("not the password of any account", cost)
+}
+
+type CreateUserTokenPayload struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateUserTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	user, err := app.store.Users.GetByEmail(r.Context(), payload.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			_ = (&store.User{Password: app.dummyPasswordHash}).ComparePassword(payload.Password)
+			app.unauthorizedResponse(w, r, errors.New("invalid credentials"))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := user.ComparePassword(payload.Password); err != nil {
+		app.unauthorizedResponse(w, r, errors.New("invalid credentials"))
+		return
+	}
+
+	if !user.IsActive {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	tokens, err := app.issueTokens(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, tokens); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// issueTokens starts a new session for userID: a short-lived access token
+// and the first refresh token of a new rotation chain.
+func (app *application) issueTokens(ctx context.Context, userID int64) (*tokenPair, error) {
+	token, err := app.generateAccessToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The plain token is only ever handed to the user; the store keeps a hash.
+	refreshToken := uuid.NewString()
+	if err := app.store.RefreshTokens.Create(ctx, userID, refreshToken, app.config.auth.token.refreshTTL); err != nil {
+		return nil, err
+	}
+
+	return &tokenPair{Token: token, RefreshToken: refreshToken}, nil
+}
+
+func (app *application) generateAccessToken(userID int64) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": userID,
+		"exp": now.Add(app.config.auth.token.ttl).Unix(),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"iss": app.config.auth.token.iss,
+		"aud": app.config.auth.token.iss,
+	}
+
+	return app.authenticator.GenerateToken(claims)
+}
+
+type RefreshTokenPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=255"`
+}
+
+// refreshTokenHandler exchanges a refresh token for a new access token and a
+// new refresh token; the one presented is revoked. Presenting a refresh
+// token a second time is treated as a sign it was stolen, and the whole
+// chain it belongs to is revoked, signing out whoever holds the newer one.
+func (app *application) refreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	refreshToken := uuid.NewString()
+	userID, err := app.store.RefreshTokens.Rotate(r.Context(), payload.RefreshToken, refreshToken, app.config.auth.token.refreshTTL)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.unauthorizedResponse(w, r, errors.New("invalid refresh token"))
+		case errors.Is(err, store.ErrRefreshTokenReused):
+			app.logger.WarnContext(r.Context(), "refresh token reused, session chain revoked")
+			app.unauthorizedResponse(w, r, err)
+		default:
+			app.handleError(w, r, err)
+		}
+		return
+	}
+
+	token, err := app.generateAccessToken(userID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, &tokenPair{Token: token, RefreshToken: refreshToken}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// logoutHandler revokes the given refresh token. Access tokens already
+// issued stay valid until they expire, which the short access TTL bounds.
+func (app *application) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := app.store.RefreshTokens.Revoke(r.Context(), payload.RefreshToken); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.unauthorizedResponse(w, r, errors.New("invalid refresh token"))
+		default:
+			app.handleError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// forgotPasswordHandler emails a single-use reset link to the account with
+// the given email. The response does not depend on whether such an account
+// exists.
+func (app *application) forgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ForgotPasswordPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	// The plain token is only ever handed to the user; the store keeps a hash.
+	token := uuid.NewString()
+	user, err := app.store.Users.CreatePasswordReset(r.Context(), payload.Email, token, app.config.auth.passwordResetTTL)
+	switch {
+	case err == nil:
+		app.sendEmail(user.Email, mailer.PasswordResetTemplate, map[string]string{
+			"Username":  user.Username,
+			"ResetURL":  app.config.mail.frontendURL + "/reset-password/" + token,
+			"ExpiresIn": app.config.auth.passwordResetTTL.String(),
+		})
+	case errors.Is(err, store.ErrNotFound):
+	default:
+		app.handleError(w, r, err)
+		return
+	}
+
+	resp := map[string]string{"message": "if an account exists for that email, a password reset link has been sent"}
+	if err := writeJSON(w, http.StatusAccepted, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// resetPasswordHandler sets a new password using a token from
+// forgotPasswordHandler. Unknown, used and expired tokens all yield 404.
+func (app *application) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResetPasswordPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := app.store.Users.ResetPassword(r.Context(), payload.Token, payload.Password); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
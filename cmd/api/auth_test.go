@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	appconfig "github.com/rissabekov-wes/social/internal/config"
+	"github.com/rissabekov-wes/social/internal/mailer"
+	"github.com/rissabekov-wes/social/internal/store"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCreateTokenHandler(t *testing.T) {
+	hash, err := store.HashPassword("correct horse", testPasswordCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := map[string]*store.User{
+		"alice@example.com": {ID: 1, Email: "alice@example.com", Password: hash, IsActive: true},
+		"bob@example.com":   {ID: 2, Email: "bob@example.com", Password: hash, IsActive: false},
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "valid credentials", body: `{"email":"alice@example.com","password":"correct horse"}`, wantStatus: http.StatusCreated},
+		{name: "wrong password", body: `{"email":"alice@example.com","password":"wrong horse"}`, wantStatus: http.StatusUnauthorized},
+		{name: "unknown email", body: `{"email":"nobody@example.com","password":"correct horse"}`, wantStatus: http.StatusUnauthorized},
+		{name: "inactive user", body: `{"email":"bob@example.com","password":"correct horse"}`, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{
+				Users: &fakeUsersStore{getByEmail: func(_ context.Context, email string) (*store.User, error) {
+					if u, ok := users[email]; ok {
+						return u, nil
+					}
+					return nil, store.ErrNotFound
+				}},
+				RefreshTokens: &fakeRefreshTokensStore{create: func(context.Context, int64, string, time.Duration) error {
+					return nil
+				}},
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/token", strings.NewReader(tt.body))
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var tokens tokenPair
+			if err := json.Unmarshal(rr.Body.Bytes(), &tokens); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := app.authenticator.ValidateToken(tokens.Token); err != nil {
+				t.Errorf("issued token does not validate: %v", err)
+			}
+			if tokens.RefreshToken == "" {
+				t.Error("no refresh token issued")
+			}
+		})
+	}
+}
+
+func TestDummyPasswordHash(t *testing.T) {
+	// The unknown-email path only costs as much as a real login if the dummy
+	// is a genuine hash at the configured cost.
+	const cost = bcrypt.MinCost + 1
+	hash, err := newDummyPasswordHash(cost)
+	if err != nil {
+		t.Fatalf("newDummyPasswordHash() error = %v", err)
+	}
+	got, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		t.Fatalf("dummy hash is not a bcrypt hash: %v", err)
+	}
+	if got != cost {
+		t.Errorf("dummy hash cost = %d, want %d", got, cost)
+	}
+
+	// Rather than an empty hash, which would skip the comparison's cost.
+	if _, err := newDummyPasswordHash(bcrypt.MaxCost + 1); err == nil {
+		t.Error("newDummyPasswordHash() with an invalid cost succeeded")
+	}
+}
+
+func TestValidateSecrets(t *testing.T) {
+	tests := []struct {
+		env     string
+		secret  string
+		wantErr bool
+	}{
+		{env: appconfig.EnvLocal, secret: defaultJWTSecret},
+		{env: appconfig.EnvDevelopment, secret: ""},
+		{env: appconfig.EnvProduction, secret: "a-long-random-secret"},
+		{env: appconfig.EnvProduction, secret: defaultJWTSecret, wantErr: true},
+		{env: appconfig.EnvProduction, secret: "", wantErr: true},
+		{env: "staging", secret: defaultJWTSecret, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env+"/"+tt.secret, func(t *testing.T) {
+			var cfg config
+			cfg.env.EnvName = tt.env
+			cfg.auth.token.secret = tt.secret
+
+			if err := cfg.validateSecrets(); (err != nil) != tt.wantErr {
+				t.Errorf("validateSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// resetStore is an in-memory stand-in for the password reset part of the
+// users store, holding one account.
+type resetStore struct {
+	user   *store.User
+	token  string
+	expiry time.Time
+}
+
+func (s *resetStore) users() *fakeUsersStore {
+	return &fakeUsersStore{
+		getByEmail: func(_ context.Context, email string) (*store.User, error) {
+			if email != s.user.Email {
+				return nil, store.ErrNotFound
+			}
+			return s.user, nil
+		},
+		createPasswordReset: func(_ context.Context, email, token string, exp time.Duration) (*store.User, error) {
+			if email != s.user.Email {
+				return nil, store.ErrNotFound
+			}
+			s.token, s.expiry = token, time.Now().Add(exp)
+			return s.user, nil
+		},
+		resetPassword: func(_ context.Context, token, password string) error {
+			if token == "" || token != s.token || !time.Now().Before(s.expiry) {
+				return store.ErrNotFound
+			}
+			hash, err := store.HashPassword(password, testPasswordCost)
+			if err != nil {
+				return err
+			}
+			s.user.Password = hash
+			s.token = ""
+			return nil
+		},
+	}
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	hash, err := store.HashPassword("correct horse", testPasswordCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// requestReset calls forgot-password for email and returns the token
+	// from the emailed link, or "" when nothing was sent.
+	requestReset := func(t *testing.T, app *application, sent *recordingMailer, email string) (string, string) {
+		t.Helper()
+
+		body := `{"email":"` + email + `"}`
+		rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodPost, "/v1/auth/forgot-password", strings.NewReader(body)))
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("forgot-password status = %d, want %d", rr.Code, http.StatusAccepted)
+		}
+
+		if err := app.workers.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case msg := <-sent.sent:
+			if msg.to != email || msg.template != mailer.PasswordResetTemplate {
+				t.Errorf("sent %q to %q, want the reset email to %s", msg.template, msg.to, email)
+			}
+			data, _ := msg.data.(map[string]string)
+			token, ok := strings.CutPrefix(data["ResetURL"], app.config.mail.frontendURL+"/reset-password/")
+			if !ok || token == "" {
+				t.Fatalf("ResetURL = %q, want a link to the reset page", data["ResetURL"])
+			}
+			return token, rr.Body.String()
+		default:
+			return "", rr.Body.String()
+		}
+	}
+	reset := func(app *application, token, password string) int {
+		body := `{"token":"` + token + `","password":"` + password + `"}`
+		return executeRequest(app.mount(), httptest.NewRequest(http.MethodPost, "/v1/auth/reset-password", strings.NewReader(body))).Code
+	}
+	login := func(app *application, password string) int {
+		body := `{"email":"alice@example.com","password":"` + password + `"}`
+		return executeRequest(app.mount(), httptest.NewRequest(http.MethodPost, "/v1/auth/token", strings.NewReader(body))).Code
+	}
+
+	newApp := func(t *testing.T, s *resetStore) (*application, *recordingMailer) {
+		app := newTestApplication(t, store.Storage{
+			Users: s.users(),
+			RefreshTokens: &fakeRefreshTokensStore{create: func(context.Context, int64, string, time.Duration) error {
+				return nil
+			}},
+		})
+		sent := newRecordingMailer()
+		app.mailer = sent
+		return app, sent
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		s := &resetStore{user: &store.User{ID: 1, Username: "alice", Email: "alice@example.com", Password: hash, IsActive: true}}
+		app, sent := newApp(t, s)
+
+		token, found := requestReset(t, app, sent, "alice@example.com")
+		if token == "" {
+			t.Fatal("no reset email was sent")
+		}
+
+		if status := reset(app, token, "new battery staple"); status != http.StatusNoContent {
+			t.Fatalf("reset-password status = %d, want %d", status, http.StatusNoContent)
+		}
+		if status := login(app, "new battery staple"); status != http.StatusCreated {
+			t.Errorf("login with the new password status = %d, want %d", status, http.StatusCreated)
+		}
+		if status := login(app, "correct horse"); status != http.StatusUnauthorized {
+			t.Errorf("login with the old password status = %d, want %d", status, http.StatusUnauthorized)
+		}
+		if status := reset(app, token, "another password"); status != http.StatusNotFound {
+			t.Errorf("reusing the token status = %d, want %d", status, http.StatusNotFound)
+		}
+
+		// An unknown email gets the same answer and no email.
+		app, sent = newApp(t, s)
+		if token, unknown := requestReset(t, app, sent, "nobody@example.com"); token != "" || unknown != found {
+			t.Errorf("unknown email: token %q, body %s; want no email and the body %s", token, unknown, found)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		s := &resetStore{user: &store.User{ID: 1, Username: "alice", Email: "alice@example.com", Password: hash, IsActive: true}}
+		app, sent := newApp(t, s)
+		app.config.auth.passwordResetTTL = -time.Minute
+
+		token, _ := requestReset(t, app, sent, "alice@example.com")
+		if token == "" {
+			t.Fatal("no reset email was sent")
+		}
+
+		if status := reset(app, token, "new battery staple"); status != http.StatusNotFound {
+			t.Errorf("reset-password status = %d, want %d", status, http.StatusNotFound)
+		}
+		if status := login(app, "correct horse"); status != http.StatusCreated {
+			t.Errorf("login with the unchanged password status = %d, want %d", status, http.StatusCreated)
+		}
+	})
+}
+
+// refreshChains is an in-memory refresh token store that keeps rotated
+// tokens in the family of the login they descend from, like the real one.
+type refreshChains struct {
+	userID  map[string]int64
+	family  map[string]string
+	revoked map[string]bool
+}
+
+func newRefreshChains() *refreshChains {
+	return &refreshChains{userID: map[string]int64{}, family: map[string]string{}, revoked: map[string]bool{}}
+}
+
+func (c *refreshChains) store() *fakeRefreshTokensStore {
+	return &fakeRefreshTokensStore{
+		create: func(_ context.Context, userID int64, token string, _ time.Duration) error {
+			c.userID[token], c.family[token] = userID, token
+			return nil
+		},
+		rotate: func(_ context.Context, token, newToken string, _ time.Duration) (int64, error) {
+			userID, ok := c.userID[token]
+			if !ok {
+				return 0, store.ErrNotFound
+			}
+			if c.revoked[token] {
+				for t, f := range c.family {
+					if f == c.family[token] {
+						c.revoked[t] = true
+					}
+				}
+				return 0, store.ErrRefreshTokenReused
+			}
+			c.revoked[token] = true
+			c.userID[newToken], c.family[newToken] = userID, c.family[token]
+			return userID, nil
+		},
+		revoke: func(_ context.Context, token string) error {
+			if _, ok := c.userID[token]; !ok || c.revoked[token] {
+				return store.ErrNotFound
+			}
+			c.revoked[token] = true
+			return nil
+		},
+	}
+}
+
+func TestRefreshTokenLifecycle(t *testing.T) {
+	hash, err := store.HashPassword("correct horse", testPasswordCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := &store.User{ID: 1, Email: "alice@example.com", Password: hash, IsActive: true}
+
+	newApp := func(t *testing.T) *application {
+		return newTestApplication(t, store.Storage{
+			Users: &fakeUsersStore{
+				getByID: usersByID(alice),
+				getByEmail: func(context.Context, string) (*store.User, error) {
+					return alice, nil
+				},
+			},
+			RefreshTokens: newRefreshChains().store(),
+		})
+	}
+
+	post := func(t *testing.T, app *application, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		return executeRequest(app.mount(), httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	}
+	tokens := func(t *testing.T, rr *httptest.ResponseRecorder) tokenPair {
+		t.Helper()
+		var pair tokenPair
+		if err := json.Unmarshal(rr.Body.Bytes(), &pair); err != nil {
+			t.Fatal(err)
+		}
+		return pair
+	}
+	login := func(t *testing.T, app *application) tokenPair {
+		t.Helper()
+		rr := post(t, app, "/v1/auth/token", `{"email":"alice@example.com","password":"correct horse"}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("login status = %d, want %d", rr.Code, http.StatusCreated)
+		}
+		return tokens(t, rr)
+	}
+	refresh := func(t *testing.T, app *application, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		return post(t, app, "/v1/auth/refresh", `{"refresh_token":"`+token+`"}`)
+	}
+
+	t.Run("rotation", func(t *testing.T) {
+		app := newApp(t)
+		first := login(t, app)
+
+		rr := refresh(t, app, first.RefreshToken)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("refresh status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		second := tokens(t, rr)
+		if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+			t.Errorf("refresh token %q was not replaced by a new one", first.RefreshToken)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+second.Token)
+		if rr := executeRequest(app.mount(), req); rr.Code != http.StatusOK {
+			t.Errorf("GET /v1/users/me with the rotated access token status = %d, want %d", rr.Code, http.StatusOK)
+		}
+
+		if rr := refresh(t, app, second.RefreshToken); rr.Code != http.StatusOK {
+			t.Errorf("refresh with the rotated token status = %d, want %d", rr.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		app := newApp(t)
+		pair := login(t, app)
+
+		body := `{"refresh_token":"` + pair.RefreshToken + `"}`
+		if rr := post(t, app, "/v1/auth/logout", body); rr.Code != http.StatusNoContent {
+			t.Fatalf("logout status = %d, want %d", rr.Code, http.StatusNoContent)
+		}
+		if rr := refresh(t, app, pair.RefreshToken); rr.Code != http.StatusUnauthorized {
+			t.Errorf("refresh after logout status = %d, want %d", rr.Code, http.StatusUnauthorized)
+		}
+		if rr := post(t, app, "/v1/auth/logout", body); rr.Code != http.StatusUnauthorized {
+			t.Errorf("second logout status = %d, want %d", rr.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("reuse revokes the chain", func(t *testing.T) {
+		app := newApp(t)
+		stolen := login(t, app)
+
+		rr := refresh(t, app, stolen.RefreshToken)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("refresh status = %d, want %d", rr.Code, http.StatusOK)
+		}
+		current := tokens(t, rr)
+
+		if rr := refresh(t, app, stolen.RefreshToken); rr.Code != http.StatusUnauthorized {
+			t.Errorf("reusing a rotated token status = %d, want %d", rr.Code, http.StatusUnauthorized)
+		}
+		if rr := refresh(t, app, current.RefreshToken); rr.Code != http.StatusUnauthorized {
+			t.Errorf("refresh with the newest token of a revoked chain status = %d, want %d", rr.Code, http.StatusUnauthorized)
+		}
+
+		// Other sessions are not affected.
+		other := login(t, app)
+		if rr := refresh(t, app, other.RefreshToken); rr.Code != http.StatusOK {
+			t.Errorf("refresh of an unrelated session status = %d, want %d", rr.Code, http.StatusOK)
+		}
+	})
+}
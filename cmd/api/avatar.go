@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const avatarFormField = "avatar"
+
+// avatarExtensions lists the accepted image types keyed by their sniffed
+// content type.
+var avatarExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// uploadAvatarHandler accepts a multipart upload with the image in the
+// "avatar" field. The declared content type must agree with the type sniffed
+// from the file's magic bytes, so a renamed executable is rejected.
+func (app *application) uploadAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		app.badRequestResponse(w, r, errors.New("body must be multipart/form-data"))
+		return
+	}
+
+	var (
+		data     []byte
+		declared string
+	)
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		if part.FormName() != avatarFormField {
+			continue
+		}
+
+		declared = part.Header.Get("Content-Type")
+		if _, ok := avatarExtensions[declared]; !ok {
+			app.unsupportedMediaTypeResponse(w, r, errors.New("avatar must be a JPEG or PNG image"))
+			return
+		}
+
+		data, err = io.ReadAll(io.LimitReader(part, app.config.blob.maxAvatarBytes+1))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		break
+	}
+
+	if len(data) == 0 {
+		app.badRequestResponse(w, r, fmt.Errorf("%s file must be provided", avatarFormField))
+		return
+	}
+	if int64(len(data)) > app.config.blob.maxAvatarBytes {
+		app.payloadTooLargeResponse(w, r, fmt.Errorf("%w: avatar exceeds %d bytes", errBodyTooLarge, app.config.blob.maxAvatarBytes))
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := avatarExtensions[contentType]
+	if !ok {
+		app.unsupportedMediaTypeResponse(w, r, errors.New("avatar must be a JPEG or PNG image"))
+		return
+	}
+	if contentType != declared {
+		app.unsupportedMediaTypeResponse(w, r, fmt.Errorf("avatar content is %s but was declared as %s", contentType, declared))
+		return
+	}
+
+	key := "avatars/" + strconv.FormatInt(user.ID, 10) + "/" + uuid.NewString() + ext
+	if err := app.blobs.Put(r.Context(), key, bytes.NewReader(data), contentType); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	url := app.blobs.URL(key)
+	if err := app.store.Users.SetAvatarURL(r.Context(), user.ID, url); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, map[string]string{"avatar_url": url}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/blobstore"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func encodeImage(t *testing.T, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// avatarRequest builds a multipart upload with data in field, declared as
+// contentType.
+func avatarRequest(t *testing.T, field, contentType string, data []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="`+field+`"; filename="avatar"`)
+	header.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/me/avatar", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestUploadAvatarHandler(t *testing.T) {
+	pngData := encodeImage(t, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+	jpegData := encodeImage(t, func(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) })
+
+	tests := []struct {
+		name        string
+		field       string
+		contentType string
+		data        []byte
+		wantStatus  int
+		wantExt     string
+	}{
+		{name: "png", field: "avatar", contentType: "image/png", data: pngData, wantStatus: http.StatusOK, wantExt: ".png"},
+		{name: "jpeg", field: "avatar", contentType: "image/jpeg", data: jpegData, wantStatus: http.StatusOK, wantExt: ".jpg"},
+		{name: "jpeg declared as png", field: "avatar", contentType: "image/png", data: jpegData, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "png declared as jpeg", field: "avatar", contentType: "image/jpeg", data: pngData, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "script declared as png", field: "avatar", contentType: "image/png", data: []byte("#!/bin/sh\necho hi\n"), wantStatus: http.StatusUnsupportedMediaType},
+		{name: "gif declared", field: "avatar", contentType: "image/gif", data: []byte("GIF89a"), wantStatus: http.StatusUnsupportedMediaType},
+		{name: "too large", field: "avatar", contentType: "image/png", data: append(pngData, make([]byte, 512<<10)...), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "missing field", field: "picture", contentType: "image/png", data: pngData, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+			dir := t.TempDir()
+
+			var savedURL string
+			app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{
+				getByID: usersByID(alice),
+				setAvatarURL: func(_ context.Context, id int64, url string) error {
+					savedURL = url
+					return nil
+				},
+			}})
+			app.config.maxRequestBytes = 2 << 20
+			app.blobs = blobstore.NewLocalStore(dir, "http://localhost/uploads")
+
+			req := avatarRequest(t, tt.field, tt.contentType, tt.data)
+			authorize(t, app, req, alice.ID)
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if savedURL != "" {
+					t.Errorf("avatar URL saved for a rejected upload: %s", savedURL)
+				}
+				return
+			}
+
+			var body map[string]string
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["avatar_url"] != savedURL || !strings.HasSuffix(savedURL, tt.wantExt) {
+				t.Errorf("avatar_url = %q, saved %q, want a %s URL", body["avatar_url"], savedURL, tt.wantExt)
+			}
+
+			key := strings.TrimPrefix(savedURL, "http://localhost/uploads/")
+			stored, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(key)))
+			if err != nil || !bytes.Equal(stored, tt.data) {
+				t.Errorf("stored avatar does not match the upload (err %v)", err)
+			}
+		})
+	}
+}
+
+func TestUploadAvatarHandlerRequiresMultipart(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+	app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{getByID: usersByID(alice)}})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/me/avatar", strings.NewReader(`{"avatar":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	authorize(t, app, req, alice.ID)
+	rr := executeRequest(app.mount(), req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import "net/http"
+
+// blockUserHandler blocks the user with the ID in the path. Their posts drop
+// out of the caller's feed and any follow between the two is removed.
+func (app *application) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	blockedID, err := readIDParam(r, "id")
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := app.store.Blocks.Block(r.Context(), user.ID, blockedID); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+	app.feeds.Invalidate(r.Context(), user.ID, blockedID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	blockedID, err := readIDParam(r, "id")
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := app.store.Blocks.Unblock(r.Context(), user.ID, blockedID); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+	app.feeds.Invalidate(r.Context(), user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
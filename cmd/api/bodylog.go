@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// maxLoggedBodyBytes caps how much of each body is logged.
+const maxLoggedBodyBytes = 2048
+
+// sensitiveBodyKeys are JSON keys whose values are never logged, matched
+// case-insensitively.
+var sensitiveBodyKeys = map[string]bool{
+	"password":      true,
+	"new_password":  true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"secret":        true,
+}
+
+// sensitiveFieldPattern redacts sensitive string fields in JSON that could not
+// be parsed, typically because it was truncated.
+var sensitiveFieldPattern = regexp.MustCompile(`(?i)("(?:password|new_password|token|access_token|refresh_token|secret)"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+
+// logBodies logs request and response bodies at debug level. It only wraps
+// the chain when the logger has debug enabled, so it costs nothing
+// otherwise. Bodies are capped at maxLoggedBodyBytes, sensitive JSON fields
+// are redacted and non-text content types are summarised rather than logged.
+func (app *application) logBodies(next http.Handler) http.Handler {
+	if !app.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody []byte
+		if r.Body != nil && r.Body != http.NoBody {
+			reqBody, _ = io.ReadAll(io.LimitReader(r.Body, maxLoggedBodyBytes+1))
+			r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(reqBody), r.Body), Closer: r.Body}
+		}
+
+		bw := &bodyCaptureWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+
+		app.logger.DebugContext(r.Context(), "request bodies",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"request_body", loggableBody(r.Header.Get("Content-Type"), reqBody),
+			"response_body", loggableBody(w.Header().Get("Content-Type"), bw.body.Bytes()),
+		)
+	})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCaptureWriter keeps a copy of the first maxLoggedBodyBytes+1 bytes
+// written, enough to tell whether the body was truncated.
+type bodyCaptureWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	if room := maxLoggedBodyBytes + 1 - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(room, len(b))])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// loggableBody renders a captured body for the debug log.
+func loggableBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !isTextMediaType(mediaType) {
+		return "[" + mediaType + " body omitted]"
+	}
+
+	truncated := len(body) > maxLoggedBodyBytes
+	if truncated {
+		body = body[:maxLoggedBodyBytes]
+	}
+
+	out := redactBody(mediaType, body)
+	if truncated {
+		out += "...[truncated]"
+	}
+	return out
+}
+
+func isTextMediaType(mediaType string) bool {
+	switch {
+	case mediaType == "":
+		return true
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return true
+	case mediaType == "application/x-www-form-urlencoded", mediaType == "application/xml":
+		return true
+	}
+	return false
+}
+
+func redactBody(mediaType string, body []byte) string {
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return string(body)
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return sensitiveFieldPattern.ReplaceAllString(string(body), `$1"****"`)
+	}
+
+	redacted, err := json.Marshal(redactJSON(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if sensitiveBodyKeys[strings.ToLower(key)] {
+				v[key] = "****"
+				continue
+			}
+			v[key] = redactJSON(val)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = redactJSON(val)
+		}
+	}
+	return v
+}
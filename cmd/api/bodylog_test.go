@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// logBodiesRecord serves req through logBodies around h with a debug logger
+// and returns the logged record.
+func logBodiesRecord(t *testing.T, req *http.Request, h http.HandlerFunc) map[string]any {
+	t.Helper()
+
+	var buf bytes.Buffer
+	app := newTestApplication(t, store.Storage{})
+	app.logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	app.logBodies(h).ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log output is not one JSON record: %v: %s", err, buf.String())
+	}
+	return record
+}
+
+func TestLogBodiesRedactsSensitiveFields(t *testing.T) {
+	body := `{"email":"alice@example.com","password":"hunter2","nested":{"Refresh_Token":"abc"}}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/token", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	var handlerSaw string
+	record := logBodiesRecord(t, req, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		handlerSaw = string(b)
+		writeJSON(w, http.StatusCreated, map[string]string{"access_token": "jwt.value.here"})
+	})
+
+	if handlerSaw != body {
+		t.Errorf("handler read %q, want the original body", handlerSaw)
+	}
+
+	logged := record["request_body"].(string) + record["response_body"].(string)
+	for _, secret := range []string{"hunter2", "abc", "jwt.value.here"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("logged bodies contain %q: %s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "alice@example.com") || !strings.Contains(logged, `"password":"****"`) {
+		t.Errorf("logged bodies = %s, want the email kept and the password masked", logged)
+	}
+}
+
+func TestLogBodiesTruncatesLargeBodies(t *testing.T) {
+	body := `{"password":"hunter2","content":"` + strings.Repeat("x", 3*maxLoggedBodyBytes) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	var handlerRead int
+	record := logBodiesRecord(t, req, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		handlerRead = len(b)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if handlerRead != len(body) {
+		t.Errorf("handler read %d bytes, want all %d", handlerRead, len(body))
+	}
+
+	logged := record["request_body"].(string)
+	if !strings.HasSuffix(logged, "...[truncated]") || len(logged) > maxLoggedBodyBytes+len("...[truncated]") {
+		t.Errorf("logged %d bytes ending %q, want at most %d plus a truncation marker", len(logged), logged[max(0, len(logged)-20):], maxLoggedBodyBytes)
+	}
+	// Truncated JSON cannot be parsed, so redaction falls back to the pattern.
+	if strings.Contains(logged, "hunter2") {
+		t.Error("truncated body leaks the password")
+	}
+}
+
+func TestLogBodiesSkipsBinaryAndNonDebug(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/me/avatar", strings.NewReader("\x89PNG..."))
+	req.Header.Set("Content-Type", "image/png")
+
+	record := logBodiesRecord(t, req, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	if got := record["request_body"]; got != "[image/png body omitted]" {
+		t.Errorf("request_body = %q, want the binary body summarised", got)
+	}
+
+	var buf bytes.Buffer
+	app := newTestApplication(t, store.Storage{})
+	app.logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	app.logBodies(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if buf.Len() != 0 {
+		t.Errorf("bodies logged above debug level: %s", buf.String())
+	}
+}
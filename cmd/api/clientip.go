@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// parseTrustedProxies parses TRUSTED_PROXIES entries, each either a CIDR or a
+// single address.
+func parseTrustedProxies(entries []string) ([]net.IPNet, error) {
+	nets := make([]net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		nets = append(nets, *n)
+	}
+	return nets, nil
+}
+
+func isTrusted(ip net.IP, trusted []net.IPNet) bool {
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client that originated r. Forwarding
+// headers are only believed when the immediate peer is a trusted proxy, so a
+// direct client cannot spoof its address. X-Forwarded-For is walked from the
+// nearest hop outwards and the first address that is not itself a trusted
+// proxy wins.
+func clientIP(r *http.Request, trusted []net.IPNet) string {
+	peer := remoteHost(r)
+
+	peerIP := net.ParseIP(peer)
+	if peerIP == nil || !isTrusted(peerIP, trusted) {
+		return peer
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		client := peer
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			client = ip.String()
+			if !isTrusted(ip, trusted) {
+				break
+			}
+		}
+		return client
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+
+	return peer
+}
+
+// remoteHost strips the port from r.RemoteAddr.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// realIP replaces r.RemoteAddr with the resolved client address so the rate
+// limiter, logs and handlers all see the same, unspoofable value.
+func (app *application) realIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.RemoteAddr = clientIP(r, app.config.trustedProxies)
+		next.ServeHTTP(w, r)
+	})
+}
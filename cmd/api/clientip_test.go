@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		xRealIP    string
+		want       string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "direct client spoofing XFF", remoteAddr: "203.0.113.7:5000", xff: []string{"1.2.3.4"}, want: "203.0.113.7"},
+		{name: "direct client spoofing X-Real-IP", remoteAddr: "203.0.113.7:5000", xRealIP: "1.2.3.4", want: "203.0.113.7"},
+		{name: "one trusted proxy", remoteAddr: "10.0.0.5:443", xff: []string{"198.51.100.2"}, want: "198.51.100.2"},
+		{
+			name:       "trusted proxy chain",
+			remoteAddr: "10.0.0.5:443",
+			xff:        []string{"198.51.100.2, 192.168.1.1", "10.1.2.3"},
+			want:       "198.51.100.2",
+		},
+		{
+			// The client prepended a fake hop; only the address the first
+			// trusted proxy saw counts.
+			name:       "spoofed hop behind proxies",
+			remoteAddr: "10.0.0.5:443",
+			xff:        []string{"1.2.3.4, 198.51.100.2, 10.1.2.3"},
+			want:       "198.51.100.2",
+		},
+		{name: "malformed hop", remoteAddr: "10.0.0.5:443", xff: []string{"garbage, 10.1.2.3"}, want: "10.1.2.3"},
+		{name: "X-Real-IP from trusted proxy", remoteAddr: "192.168.1.1:80", xRealIP: " 198.51.100.9 ", want: "198.51.100.9"},
+		{name: "ipv6 proxy", remoteAddr: "[fd00::1]:443", xff: []string{"2001:db8::5"}, want: "2001:db8::5"},
+		{name: "trusted proxy without headers", remoteAddr: "10.0.0.5:443", want: "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.xRealIP != "" {
+				r.Header.Set("X-Real-IP", tt.xRealIP)
+			}
+
+			if got := clientIP(r, trusted); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	for _, entries := range [][]string{{"10.0.0.0/33"}, {"proxy.internal"}, {"10.0.0.1", ""}} {
+		if _, err := parseTrustedProxies(entries); err == nil {
+			t.Errorf("parseTrustedProxies(%q) succeeded, want an error", entries)
+		}
+	}
+}
+
+func TestRealIPMiddleware(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+	app.config.trustedProxies, _ = parseTrustedProxies([]string{"10.0.0.0/8"})
+
+	var seen string
+	h := app.realIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { seen = r.RemoteAddr }))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.5:443"
+	r.Header.Set("X-Forwarded-For", "198.51.100.2")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if seen != "198.51.100.2" {
+		t.Errorf("handler saw RemoteAddr %q, want the forwarded client", seen)
+	}
+}
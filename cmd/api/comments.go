@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+var (
+	errCommentTooDeep    = errors.New("replies are nested too deeply")
+	errParentOnOtherPost = errors.New("parent comment belongs to a different post")
+)
+
+type CreateCommentPayload struct {
+	Content  string `json:"content" validate:"required,max=1000"`
+	ParentID *int64 `json:"parent_id" validate:"omitempty,gt=0"`
+}
+
+func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	postID, err := readIDParam(r, "id")
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	var payload CreateCommentPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	post, err := app.store.Posts.GetByIDPrimary(r.Context(), postID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	comment := &store.Comment{
+		PostID:   post.ID,
+		UserID:   user.ID,
+		ParentID: payload.ParentID,
+		Username: user.Username,
+		Content:  payload.Content,
+	}
+
+	if payload.ParentID != nil {
+		parent, err := app.store.Comments.GetByID(r.Context(), *payload.ParentID)
+		if err != nil {
+			app.handleError(w, r, err)
+			return
+		}
+		if parent.PostID != post.ID {
+			app.handleError(w, r, errParentOnOtherPost)
+			return
+		}
+
+		comment.Depth = parent.Depth + 1
+		if comment.Depth > app.config.maxCommentDepth {
+			app.handleError(w, r, fmt.Errorf("%w: at most %d levels of replies are allowed", errCommentTooDeep, app.config.maxCommentDepth))
+			return
+		}
+	}
+
+	err = app.store.WithTx(r.Context(), func(tx *sql.Tx) error {
+		if err := app.store.Comments.CreateTx(r.Context(), tx, comment); err != nil {
+			return err
+		}
+
+		if post.UserID == user.ID {
+			return nil
+		}
+
+		return app.store.Notifications.CreateTx(r.Context(), tx, &store.Notification{
+			Type:     store.NotificationComment,
+			UserID:   post.UserID,
+			ActorID:  user.ID,
+			EntityID: comment.ID,
+		})
+	})
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, comment); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// listCommentsHandler returns the comments of a post as a thread, replies
+// nested under the comment they answer.
+func (app *application) listCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := readIDParam(r, "id")
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if _, err := app.store.Posts.GetByID(r.Context(), postID); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	thread, err := app.store.Comments.GetThread(r.Context(), postID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, thread); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deleteCommentHandler deletes a comment and, through the parent_id foreign
+// key, every reply beneath it.
+func (app *application) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment := commentFromContext(r)
+
+	if err := app.store.Comments.Delete(r.Context(), comment.ID); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
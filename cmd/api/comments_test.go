@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+var commentColumns = []string{"id", "post_id", "user_id", "parent_id", "depth", "username", "content", "created_at"}
+
+func TestCreateCommentReply(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+	bob := &store.User{ID: 2, Username: "bob", IsActive: true}
+	post := &store.Post{ID: 5, UserID: bob.ID, Title: "Hello"}
+
+	tests := []struct {
+		name        string
+		parentPost  int64
+		parentDepth int
+		wantStatus  int
+		wantDepth   int
+	}{
+		{name: "reply to a top-level comment", parentPost: post.ID, parentDepth: 0, wantStatus: http.StatusCreated, wantDepth: 1},
+		{name: "reply beyond the max depth", parentPost: post.ID, parentDepth: 1, wantStatus: http.StatusUnprocessableEntity},
+		{name: "parent on another post", parentPost: 6, parentDepth: 0, wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Close() })
+
+			mock.ExpectQuery(`FROM comments c\s+JOIN users u ON u.id = c.user_id\s+WHERE c.id = \$1`).
+				WithArgs(int64(10)).
+				WillReturnRows(sqlmock.NewRows(commentColumns).AddRow(10, tt.parentPost, bob.ID, nil, tt.parentDepth, "bob", "parent", "2024-01-01T00:00:00Z"))
+			if tt.wantStatus == http.StatusCreated {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO comments \(post_id, user_id, parent_id, depth, content\)`).
+					WithArgs(post.ID, alice.ID, int64(10), tt.wantDepth, "a reply").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(11, "2024-01-02T00:00:00Z"))
+				mock.ExpectQuery(`INSERT INTO notifications`).
+					WithArgs(store.NotificationComment, bob.ID, alice.ID, int64(11)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, "2024-01-02T00:00:00Z"))
+				mock.ExpectCommit()
+			}
+
+			// Behind a lagging replica, so the post and author must come from
+			// the primary.
+			storage := store.NewStorage(db, nil, time.Second, testPasswordCost)
+			storage.Users = laggingUsersStore{&fakeUsersStore{getByID: usersByID(alice, bob)}}
+			storage.Posts = laggingPostsStore{&fakePostsStore{getByID: func(context.Context, int64) (*store.Post, error) {
+				return post, nil
+			}}}
+			app := newTestApplication(t, storage)
+			app.config.maxCommentDepth = 1
+
+			body := `{"content":"a reply","parent_id":10}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/posts/5/comments", strings.NewReader(body))
+			authorize(t, app, req, alice.ID)
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var comment store.Comment
+			if err := json.Unmarshal(rr.Body.Bytes(), &comment); err != nil {
+				t.Fatal(err)
+			}
+			if comment.ID != 11 || comment.ParentID == nil || *comment.ParentID != 10 || comment.Depth != tt.wantDepth {
+				t.Errorf("comment = %+v, want a reply to 10 at depth %d", comment, tt.wantDepth)
+			}
+		})
+	}
+}
+
+func TestListCommentsHandlerThread(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mock.ExpectQuery(`FROM comments c\s+JOIN users u ON u.id = c.user_id\s+WHERE c.post_id = \$1`).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows(commentColumns).
+			AddRow(10, 5, 2, nil, 0, "bob", "first", "2024-01-01T00:00:00Z").
+			AddRow(11, 5, 1, 10, 1, "alice", "reply", "2024-01-02T00:00:00Z").
+			AddRow(12, 5, 1, nil, 0, "alice", "second", "2024-01-03T00:00:00Z"))
+
+	storage := store.NewStorage(db, nil, time.Second, testPasswordCost)
+	storage.Posts = &fakePostsStore{getByID: func(context.Context, int64) (*store.Post, error) {
+		return &store.Post{ID: 5}, nil
+	}}
+	app := newTestApplication(t, storage)
+
+	rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/v1/posts/5/comments", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+	}
+
+	var thread []struct {
+		ID      int64 `json:"id"`
+		Replies []struct {
+			ID      int64             `json:"id"`
+			Replies []json.RawMessage `json:"replies"`
+		} `json:"replies"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &thread); err != nil {
+		t.Fatal(err)
+	}
+	if len(thread) != 2 || thread[0].ID != 10 || thread[1].ID != 12 {
+		t.Fatalf("thread = %+v, want top-level comments 10 and 12", thread)
+	}
+	if len(thread[0].Replies) != 1 || thread[0].Replies[0].ID != 11 || thread[0].Replies[0].Replies == nil {
+		t.Errorf("replies of 10 = %+v, want 11 with an empty list of replies", thread[0].Replies)
+	}
+	if thread[1].Replies == nil || len(thread[1].Replies) != 0 {
+		t.Errorf("replies of 12 = %+v, want an empty list", thread[1].Replies)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestCompress(t *testing.T) {
+	const minSize = 1024
+	app := newTestApplication(t, store.Storage{})
+
+	large := `{"data":"` + strings.Repeat("feed item ", 500) + `"}`
+	tiny := `{"ok":true}`
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantGzip       bool
+	}{
+		{name: "large JSON", path: "/v1/feed", acceptEncoding: "gzip, deflate", contentType: "application/json", body: large, wantGzip: true},
+		{name: "tiny JSON", path: "/v1/feed", acceptEncoding: "gzip", contentType: "application/json", body: tiny},
+		{name: "client without gzip", path: "/v1/feed", acceptEncoding: "br", contentType: "application/json", body: large},
+		{name: "gzip refused", path: "/v1/feed", acceptEncoding: "gzip;q=0", contentType: "application/json", body: large},
+		{name: "already compressed", path: "/uploads/a.png", acceptEncoding: "gzip", contentType: "image/png", body: large},
+		{name: "metrics", path: "/metrics", acceptEncoding: "gzip", contentType: "text/plain", body: large},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := app.compress(minSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				// Written in pieces so the size decision spans several writes.
+				for rest := tt.body; rest != ""; {
+					n := min(len(rest), 256)
+					io.WriteString(w, rest[:n])
+					rest = rest[n:]
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			gzipped := rr.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q, want gzip: %v", rr.Header().Get("Content-Encoding"), tt.wantGzip)
+			}
+
+			body := rr.Body.Bytes()
+			if gzipped {
+				zr, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatal(err)
+				}
+				if rr.Body.Len() >= len(tt.body) {
+					t.Errorf("compressed body is %d bytes, not smaller than %d", rr.Body.Len(), len(tt.body))
+				}
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %.40q..., want the handler's %d bytes", body, len(tt.body))
+			}
+
+			if tt.path != "/metrics" && !strings.Contains(rr.Header().Get("Vary"), "Accept-Encoding") {
+				t.Errorf("Vary = %q, want Accept-Encoding", rr.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCompressKeepsStatus(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+	h := app.compress(1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusCreated, map[string]string{"id": "1"})
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/posts", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated || rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("status = %d, Content-Encoding = %q; want a gzipped 201", rr.Code, rr.Header().Get("Content-Encoding"))
+	}
+}
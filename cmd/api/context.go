@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+type contextKey string
+
+const (
+	userCtxKey    = contextKey("user")
+	postCtxKey    = contextKey("post")
+	commentCtxKey = contextKey("comment")
+)
+
+var errUnauthenticated = errors.New("authentication required")
+
+func contextWithUser(ctx context.Context, user *store.User) context.Context {
+	return context.WithValue(ctx, userCtxKey, user)
+}
+
+func userFromContext(r *http.Request) (*store.User, bool) {
+	user, ok := r.Context().Value(userCtxKey).(*store.User)
+	return user, ok && user != nil
+}
+
+func postFromContext(r *http.Request) *store.Post {
+	post, _ := r.Context().Value(postCtxKey).(*store.Post)
+	return post
+}
+
+func commentFromContext(r *http.Request) *store.Comment {
+	comment, _ := r.Context().Value(commentCtxKey).(*store.Comment)
+	return comment
+}
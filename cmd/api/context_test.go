@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestUserFromContext(t *testing.T) {
+	t.Run("present", func(t *testing.T) {
+		user := &store.User{ID: 1}
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(contextWithUser(r.Context(), user))
+
+		got, ok := userFromContext(r)
+		if !ok || got != user {
+			t.Errorf("userFromContext() = %v, %v; want the stored user", got, ok)
+		}
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		if got, ok := userFromContext(r); ok || got != nil {
+			t.Errorf("userFromContext() = %v, %v; want nil, false", got, ok)
+		}
+	})
+
+	t.Run("nil user", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(contextWithUser(r.Context(), nil))
+
+		if _, ok := userFromContext(r); ok {
+			t.Error("userFromContext() ok = true for a nil user")
+		}
+	})
+
+	t.Run("string key does not collide", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), "user", &store.User{ID: 1})) //nolint:staticcheck // the collision under test
+
+		if _, ok := userFromContext(r); ok {
+			t.Error("userFromContext() found a value stored under a plain string key")
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+)
+
+const (
+	corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowedHeaders = "Authorization, Content-Type, Idempotency-Key, X-Request-ID"
+	corsMaxAge         = "300"
+)
+
+type corsConfig struct {
+	allowedOrigins   []string
+	allowCredentials bool
+}
+
+// enableCORS echoes the request origin back only when it is allowlisted. A
+// "*" entry allows any origin, but is never combined with credentials since
+// browsers reject that pairing.
+func (app *application) enableCORS(next http.Handler) http.Handler {
+	allowed := app.config.cors.allowedOrigins
+	wildcard := slices.Contains(allowed, "*")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch {
+		case slices.Contains(allowed, origin):
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if app.config.cors.allowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		case wildcard:
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestEnableCORS(t *testing.T) {
+	tests := []struct {
+		name            string
+		allowed         []string
+		credentials     bool
+		method          string
+		origin          string
+		preflight       bool
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials string
+		wantNextCalled  bool
+	}{
+		{
+			name:            "allowed origin",
+			allowed:         []string{"https://app.example.com"},
+			credentials:     true,
+			method:          http.MethodGet,
+			origin:          "https://app.example.com",
+			wantStatus:      http.StatusOK,
+			wantOrigin:      "https://app.example.com",
+			wantCredentials: "true",
+			wantNextCalled:  true,
+		},
+		{
+			name:           "disallowed origin",
+			allowed:        []string{"https://app.example.com"},
+			method:         http.MethodGet,
+			origin:         "https://evil.example.com",
+			wantStatus:     http.StatusOK,
+			wantNextCalled: true,
+		},
+		{
+			name:       "preflight",
+			allowed:    []string{"https://app.example.com"},
+			method:     http.MethodOptions,
+			origin:     "https://app.example.com",
+			preflight:  true,
+			wantStatus: http.StatusNoContent,
+			wantOrigin: "https://app.example.com",
+		},
+		{
+			name:           "wildcard never sends credentials",
+			allowed:        []string{"*"},
+			credentials:    true,
+			method:         http.MethodGet,
+			origin:         "https://anywhere.example.com",
+			wantStatus:     http.StatusOK,
+			wantOrigin:     "*",
+			wantNextCalled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{})
+			app.config.cors = corsConfig{allowedOrigins: tt.allowed, allowCredentials: tt.credentials}
+
+			var called bool
+			h := app.enableCORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+			req := httptest.NewRequest(tt.method, "/v1/posts", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rr := executeRequest(h, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+			if called != tt.wantNextCalled {
+				t.Errorf("next handler called = %v, want %v", called, tt.wantNextCalled)
+			}
+			if tt.preflight {
+				if rr.Header().Get("Access-Control-Allow-Methods") == "" || rr.Header().Get("Access-Control-Allow-Headers") == "" {
+					t.Errorf("preflight headers missing: %v", rr.Header())
+				}
+			}
+		})
+	}
+}
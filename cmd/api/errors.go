@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// handleError translates store and validation errors into the matching HTTP
+// response. Anything it does not recognise is logged and reported as a 500
+// without exposing the underlying error to the client.
+func (app *application) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	var verrs validator.ValidationErrors
+
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		app.payloadTooLargeResponse(w, r, err)
+	case errors.As(err, &verrs):
+		app.failedValidationResponse(w, r, err)
+	case errors.Is(err, store.ErrNotFound):
+		app.notFoundResponse(w, r, err)
+	case errors.Is(err, store.ErrConflict),
+		errors.Is(err, store.ErrDuplicateEmail),
+		errors.Is(err, store.ErrDuplicateUsername):
+		app.conflictResponse(w, r, err)
+	case errors.Is(err, store.ErrBlocked):
+		app.blockedResponse(w, r, err)
+	case errors.Is(err, store.ErrSelfFollow),
+		errors.Is(err, store.ErrSelfBlock),
+		errors.Is(err, store.ErrInvalidCursor),
+		errors.Is(err, errInvalidID):
+		app.badRequestResponse(w, r, err)
+	case errors.Is(err, errCommentTooDeep),
+		errors.Is(err, errParentOnOtherPost):
+		app.unprocessableEntityResponse(w, r, err)
+	case errors.Is(err, context.Canceled):
+		app.requestCanceledResponse(w, r, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		app.timeoutResponse(w, r, err)
+	default:
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) internalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.ErrorContext(r.Context(), "internal server error", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	msg := "the server encountered a problem"
+	if app.config.isDevelopment() {
+		msg += ": " + err.Error()
+	}
+
+	writeError(w, r, http.StatusInternalServerError, msg)
+}
+
+// statusClientClosedRequest is the non-standard status nginx uses for a client
+// that went away before the response was ready. It only ever reaches logs
+// and metrics.
+const statusClientClosedRequest = 499
+
+func (app *application) requestCanceledResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.InfoContext(r.Context(), "request canceled", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	writeError(w, r, statusClientClosedRequest, "request canceled")
+}
+
+func (app *application) timeoutResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.WarnContext(r.Context(), "request timed out", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	writeError(w, r, http.StatusGatewayTimeout, "the request took too long to process")
+}
+
+func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
+	// Body size limits surface from readJSON like any other decode error but
+	// deserve their own status.
+	if errors.Is(err, errBodyTooLarge) {
+		app.payloadTooLargeResponse(w, r, err)
+		return
+	}
+
+	app.logger.WarnContext(r.Context(), "bad request", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	writeError(w, r, http.StatusBadRequest, err.Error())
+}
+
+func (app *application) payloadTooLargeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.WarnContext(r.Context(), "payload too large", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+}
+
+func (app *application) unsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.WarnContext(r.Context(), "unsupported media type", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	writeError(w, r, http.StatusUnsupportedMediaType, err.Error())
+}
+
+func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.WarnContext(r.Context(), "not found", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	writeError(w, r, http.StatusNotFound, "not found")
+}
+
+func (app *application) routeNotFoundResponse(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, "the requested resource could not be found")
+}
+
+func (app *application) methodNotAllowedResponse(w http.ResponseWriter, r *http.Request, allowed []string) {
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+	writeError(w, r, http.StatusMethodNotAllowed, "the "+r.Method+" method is not supported for this resource")
+}
+
+func (app *application) conflictResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.WarnContext(r.Context(), "conflict", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	writeError(w, r, http.StatusConflict, err.Error())
+}
+
+func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.WarnContext(r.Context(), "failed validation", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	type envelope struct {
+		Error  string            `json:"error"`
+		Fields map[string]string `json:"fields"`
+	}
+
+	data := &envelope{Error: "validation failed", Fields: validationErrors(err)}
+	if err := writeJSON(w, http.StatusUnprocessableEntity, data); err != nil {
+		app.logger.ErrorContext(r.Context(), "failed to write error response", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+}
+
+func (app *application) unprocessableEntityResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.WarnContext(r.Context(), "unprocessable entity", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+}
+
+func (app *application) contentRejectedResponse(w http.ResponseWriter, r *http.Request, reason string) {
+	app.logger.WarnContext(r.Context(), "content rejected", "method", r.Method, "path", r.URL.Path, "reason", reason)
+
+	writeError(w, r, http.StatusUnprocessableEntity, reason)
+}
+
+func (app *application) unauthorizedResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.WarnContext(r.Context(), "unauthorized", "method", r.Method, "path", r.URL.Path, "error", err)
+
+	w.Header().Set("WWW-Authenticate", `Bearer realm="restricted"`)
+	writeError(w, r, http.StatusUnauthorized, "unauthorized")
+}
+
+func (app *application) forbiddenResponse(w http.ResponseWriter, r *http.Request) {
+	app.logger.WarnContext(r.Context(), "forbidden", "method", r.Method, "path", r.URL.Path)
+
+	writeError(w, r, http.StatusForbidden, "you do not have permission to access this resource")
+}
+
+// blockedResponse explains a 403 caused by a block rather than by the
+// caller's role, so clients can tell the two apart.
+func (app *application) blockedResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.logger.InfoContext(r.Context(), "blocked", "method", r.Method, "path", r.URL.Path)
+
+	writeError(w, r, http.StatusForbidden, err.Error())
+}
+
+func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	app.logger.WarnContext(r.Context(), "rate limit exceeded", "method", r.Method, "path", r.URL.Path)
+
+	w.Header().Set("Retry-After", retryAfterSeconds(retryAfter))
+	writeError(w, r, http.StatusTooManyRequests, "rate limit exceeded, retry after "+retryAfterSeconds(retryAfter)+"s")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	appconfig "github.com/rissabekov-wes/social/internal/config"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestHandleError(t *testing.T) {
+	validationErr := Validate.Struct(struct {
+		Title string `validate:"required"`
+	}{})
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantError  string
+	}{
+		{name: "not found", err: store.ErrNotFound, wantStatus: http.StatusNotFound, wantError: "not found"},
+		{name: "conflict", err: store.ErrConflict, wantStatus: http.StatusConflict, wantError: store.ErrConflict.Error()},
+		{name: "duplicate email", err: store.ErrDuplicateEmail, wantStatus: http.StatusConflict, wantError: store.ErrDuplicateEmail.Error()},
+		{name: "duplicate username", err: store.ErrDuplicateUsername, wantStatus: http.StatusConflict, wantError: store.ErrDuplicateUsername.Error()},
+		{name: "self follow", err: store.ErrSelfFollow, wantStatus: http.StatusBadRequest, wantError: store.ErrSelfFollow.Error()},
+		{name: "self block", err: store.ErrSelfBlock, wantStatus: http.StatusBadRequest, wantError: store.ErrSelfBlock.Error()},
+		{name: "blocked", err: store.ErrBlocked, wantStatus: http.StatusForbidden, wantError: store.ErrBlocked.Error()},
+		{name: "invalid cursor", err: store.ErrInvalidCursor, wantStatus: http.StatusBadRequest, wantError: store.ErrInvalidCursor.Error()},
+		{name: "invalid id", err: errInvalidID, wantStatus: http.StatusBadRequest, wantError: errInvalidID.Error()},
+		{name: "comment too deep", err: errCommentTooDeep, wantStatus: http.StatusUnprocessableEntity, wantError: errCommentTooDeep.Error()},
+		{name: "parent on other post", err: errParentOnOtherPost, wantStatus: http.StatusUnprocessableEntity, wantError: errParentOnOtherPost.Error()},
+		{name: "validation", err: validationErr, wantStatus: http.StatusUnprocessableEntity, wantError: "validation failed"},
+		{name: "body too large", err: errBodyTooLarge, wantStatus: http.StatusRequestEntityTooLarge, wantError: "request body too large"},
+		{name: "canceled", err: context.Canceled, wantStatus: statusClientClosedRequest, wantError: "request canceled"},
+		{name: "deadline", err: context.DeadlineExceeded, wantStatus: http.StatusGatewayTimeout, wantError: "the request took too long to process"},
+		{name: "wrapped", err: fmt.Errorf("loading post 7: %w", store.ErrNotFound), wantStatus: http.StatusNotFound, wantError: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{})
+			rr := httptest.NewRecorder()
+
+			app.handleError(rr, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestHandleErrorUnknown(t *testing.T) {
+	secret := errors.New("pq: password authentication failed for user social")
+
+	tests := []struct {
+		env        string
+		wantDetail bool
+	}{
+		{env: appconfig.EnvLocal, wantDetail: true},
+		{env: appconfig.EnvDevelopment, wantDetail: true},
+		{env: appconfig.EnvProduction, wantDetail: false},
+		// Anything unrecognised is scrubbed too.
+		{env: "staging", wantDetail: false},
+		{env: "prod", wantDetail: false},
+		{env: "Production", wantDetail: false},
+		{env: "", wantDetail: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{})
+			app.config.env.EnvName = tt.env
+			rr := httptest.NewRecorder()
+
+			app.handleError(rr, httptest.NewRequest(http.MethodGet, "/", nil), secret)
+
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+			}
+			if got := strings.Contains(rr.Body.String(), secret.Error()); got != tt.wantDetail {
+				t.Errorf("body %s exposes the error: %v, want %v", rr.Body, got, tt.wantDetail)
+			}
+		})
+	}
+}
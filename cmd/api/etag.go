@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// bodyETag derives a weak validator from a rendered response body, so any
+// change to what the client would see, embedded comments and author names
+// included, yields a new ETag.
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeJSONWithETag is writeJSON for cacheable 200 responses: it tags the
+// marshalled body and answers 304 instead when If-None-Match already matches.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, data any) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return writeJSON(w, http.StatusOK, data)
+	}
+
+	if checkNotModified(w, r, bodyETag(js)) {
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(append(js, '\n'))
+
+	return err
+}
+
+// checkNotModified sets the ETag header and reports whether the request's
+// If-None-Match already matches it, in which case a 304 has been written.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	inm := r.Header.Get("If-None-Match")
+	if inm == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(inm, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestGetPostHandlerETag(t *testing.T) {
+	edit := func(*store.PostDetails) {}
+	posts := &fakePostsStore{getWithDetails: func(ctx context.Context, id int64) (*store.PostDetails, error) {
+		post, err := postDetails(ctx, id)
+		if err == nil {
+			edit(post)
+		}
+		return post, err
+	}}
+	mux := newTestApplication(t, store.Storage{Posts: posts}).mount()
+
+	get := func(t *testing.T, ifNoneMatch string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/v1/posts/11", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		return executeRequest(mux, req)
+	}
+
+	first := get(t, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q; want 200 with an ETag", first.Code, etag)
+	}
+
+	t.Run("not modified", func(t *testing.T) {
+		for _, inm := range []string{etag, `"other", ` + etag, "*"} {
+			rr := get(t, inm)
+			if rr.Code != http.StatusNotModified {
+				t.Errorf("If-None-Match %s: status = %d, want %d", inm, rr.Code, http.StatusNotModified)
+			}
+			if rr.Body.Len() != 0 {
+				t.Errorf("If-None-Match %s: 304 carried a body: %s", inm, rr.Body)
+			}
+			if got := rr.Header().Get("ETag"); got != etag {
+				t.Errorf("If-None-Match %s: ETag = %q, want %q", inm, got, etag)
+			}
+		}
+	})
+
+	t.Run("stale validator", func(t *testing.T) {
+		if rr := get(t, `W/"stale"`); rr.Code != http.StatusOK {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusOK)
+		}
+	})
+
+	// Every rendered field feeds the ETag, including ones the post's version
+	// does not track.
+	for _, tt := range []struct {
+		name string
+		edit func(*store.PostDetails)
+	}{
+		{name: "post updated", edit: func(p *store.PostDetails) { p.Version++; p.Title = "edited" }},
+		{name: "comment edited", edit: func(p *store.PostDetails) { p.Comments[0].Content = "edited" }},
+		{name: "author renamed", edit: func(p *store.PostDetails) { p.Username = "alicia" }},
+		{name: "liked", edit: func(p *store.PostDetails) { p.LikesCount++ }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			edit = tt.edit
+			t.Cleanup(func() { edit = func(*store.PostDetails) {} })
+
+			rr := get(t, etag)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d after the change", rr.Code, http.StatusOK)
+			}
+			if got := rr.Header().Get("ETag"); got == etag || got == "" {
+				t.Errorf("ETag = %q, want a new one", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// exportWriter renders an export as it streams out of the store. Nothing
+// is sent to the client before Profile, so a failure up to that point can
+// still be answered with a normal error response.
+type exportWriter interface {
+	store.ExportSink
+	Started() bool
+	Close() error
+}
+
+// exportUserDataHandler streams everything stored about the caller: their
+// profile, posts, comments and follows. The default is one JSON document;
+// format=csv sends a zip with a CSV file per section instead. Neither is
+// built up in memory, so the route sits outside the buffering request
+// timeout, and the server's write timeout is pushed out to exportTimeout.
+func (app *application) exportUserDataHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(app.config.exportTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		app.logger.WarnContext(r.Context(), "could not extend the export write deadline", "error", err)
+	}
+
+	filename := "social-export-" + user.Username
+
+	bw := bufio.NewWriterSize(w, 32<<10)
+
+	var ew exportWriter
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		ew = newJSONExportWriter(w, bw, filename+".json")
+	case "csv":
+		ew = newCSVExportWriter(w, bw, filename+".zip")
+	default:
+		app.badRequestResponse(w, r, fmt.Errorf("unsupported export format %q", format))
+		return
+	}
+
+	err := app.store.Export.Export(r.Context(), user.ID, ew)
+	if err == nil {
+		err = ew.Close()
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		if !ew.Started() {
+			app.handleError(w, r, err)
+			return
+		}
+		// The status line has gone out; the truncated document is the only
+		// signal the client gets.
+		app.logger.ErrorContext(r.Context(), "export failed mid-stream", "user_id", user.ID, "error", err)
+	}
+}
+
+func setExportHeaders(w http.ResponseWriter, contentType, filename string) {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	h.Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+type jsonExportWriter struct {
+	w        http.ResponseWriter
+	bw       *bufio.Writer
+	enc      *json.Encoder
+	filename string
+	started  bool
+	section  bool
+	first    bool
+}
+
+func newJSONExportWriter(w http.ResponseWriter, bw *bufio.Writer, filename string) *jsonExportWriter {
+	return &jsonExportWriter{w: w, bw: bw, enc: json.NewEncoder(bw), filename: filename}
+}
+
+func (e *jsonExportWriter) Started() bool { return e.started }
+
+func (e *jsonExportWriter) Profile(u *store.User) error {
+	setExportHeaders(e.w, "application/json", e.filename)
+	e.started = true
+
+	if _, err := e.bw.WriteString(`{"user":`); err != nil {
+		return err
+	}
+	return e.enc.Encode(u)
+}
+
+func (e *jsonExportWriter) Section(name string) error {
+	if e.section {
+		if err := e.bw.WriteByte(']'); err != nil {
+			return err
+		}
+	}
+	e.section, e.first = true, true
+
+	_, err := fmt.Fprintf(e.bw, ",%q:[", name)
+	return err
+}
+
+func (e *jsonExportWriter) Post(p *store.Post) error       { return e.record(p) }
+func (e *jsonExportWriter) Comment(c *store.Comment) error { return e.record(c) }
+func (e *jsonExportWriter) Follow(f *store.Follow) error   { return e.record(f) }
+
+func (e *jsonExportWriter) record(v any) error {
+	if !e.first {
+		if err := e.bw.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	e.first = false
+	return e.enc.Encode(v)
+}
+
+func (e *jsonExportWriter) Close() error {
+	if e.section {
+		if err := e.bw.WriteByte(']'); err != nil {
+			return err
+		}
+	}
+	_, err := e.bw.WriteString("}\n")
+	return err
+}
+
+// exportCSVHeaders are the columns of each file in the CSV export.
+var exportCSVHeaders = map[string][]string{
+	"profile":                    {"id", "username", "email", "role", "is_active", "avatar_url", "created_at"},
+	store.ExportSectionPosts:     {"id", "title", "content", "tags", "version", "likes_count", "created_at", "updated_at"},
+	store.ExportSectionComments:  {"id", "post_id", "parent_id", "content", "created_at"},
+	store.ExportSectionFollowing: {"user_id", "username", "created_at"},
+	store.ExportSectionFollowers: {"user_id", "username", "created_at"},
+}
+
+type csvExportWriter struct {
+	w        http.ResponseWriter
+	zw       *zip.Writer
+	cw       *csv.Writer
+	filename string
+	started  bool
+}
+
+func newCSVExportWriter(w http.ResponseWriter, bw *bufio.Writer, filename string) *csvExportWriter {
+	return &csvExportWriter{w: w, zw: zip.NewWriter(bw), filename: filename}
+}
+
+func (e *csvExportWriter) Started() bool { return e.started }
+
+// startFile ends the current CSV file and opens the next one in the zip.
+func (e *csvExportWriter) startFile(name string) error {
+	if err := e.flush(); err != nil {
+		return err
+	}
+
+	f, err := e.zw.Create(name + ".csv")
+	if err != nil {
+		return err
+	}
+	e.cw = csv.NewWriter(f)
+
+	header, ok := exportCSVHeaders[name]
+	if !ok {
+		return fmt.Errorf("no CSV columns for export section %q", name)
+	}
+	return e.cw.Write(header)
+}
+
+func (e *csvExportWriter) flush() error {
+	if e.cw == nil {
+		return nil
+	}
+	e.cw.Flush()
+	return e.cw.Error()
+}
+
+func (e *csvExportWriter) Profile(u *store.User) error {
+	setExportHeaders(e.w, "application/zip", e.filename)
+	e.started = true
+
+	if err := e.startFile("profile"); err != nil {
+		return err
+	}
+	return e.cw.Write([]string{
+		strconv.FormatInt(u.ID, 10),
+		u.Username,
+		u.Email,
+		u.Role,
+		strconv.FormatBool(u.IsActive),
+		u.AvatarURL,
+		u.CreatedAt,
+	})
+}
+
+func (e *csvExportWriter) Section(name string) error {
+	return e.startFile(name)
+}
+
+func (e *csvExportWriter) Post(p *store.Post) error {
+	return e.cw.Write([]string{
+		strconv.FormatInt(p.ID, 10),
+		p.Title,
+		p.Content,
+		strings.Join(p.Tags, ","),
+		strconv.Itoa(p.Version),
+		strconv.FormatInt(p.LikesCount, 10),
+		p.CreatedAt,
+		p.UpdatedAt,
+	})
+}
+
+func (e *csvExportWriter) Comment(c *store.Comment) error {
+	var parentID string
+	if c.ParentID != nil {
+		parentID = strconv.FormatInt(*c.ParentID, 10)
+	}
+
+	return e.cw.Write([]string{
+		strconv.FormatInt(c.ID, 10),
+		strconv.FormatInt(c.PostID, 10),
+		parentID,
+		c.Content,
+		c.CreatedAt,
+	})
+}
+
+func (e *csvExportWriter) Follow(f *store.Follow) error {
+	return e.cw.Write([]string{
+		strconv.FormatInt(f.UserID, 10),
+		f.Username,
+		f.CreatedAt,
+	})
+}
+
+func (e *csvExportWriter) Close() error {
+	return errors.Join(e.flush(), e.zw.Close())
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// exportFixture feeds a small export of user through sink, sleeping for
+// delay before each post.
+func exportFixture(user *store.User, delay time.Duration) func(context.Context, int64, store.ExportSink) error {
+	return func(_ context.Context, _ int64, sink store.ExportSink) error {
+		if err := sink.Profile(user); err != nil {
+			return err
+		}
+		if err := sink.Section(store.ExportSectionPosts); err != nil {
+			return err
+		}
+		for _, p := range []store.Post{
+			{ID: 11, UserID: user.ID, Title: "First", Content: "hello", Tags: []string{"go"}, Version: 1},
+			{ID: 12, UserID: user.ID, Title: "Second", Content: "again", Version: 2},
+		} {
+			time.Sleep(delay)
+			if err := sink.Post(&p); err != nil {
+				return err
+			}
+		}
+		if err := sink.Section(store.ExportSectionComments); err != nil {
+			return err
+		}
+		if err := sink.Comment(&store.Comment{ID: 21, PostID: 11, UserID: user.ID, Content: "reply"}); err != nil {
+			return err
+		}
+		if err := sink.Section(store.ExportSectionFollowing); err != nil {
+			return err
+		}
+		if err := sink.Follow(&store.Follow{UserID: 2, Username: "bob"}); err != nil {
+			return err
+		}
+		return sink.Section(store.ExportSectionFollowers)
+	}
+}
+
+func TestExportUserDataHandler(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", Email: "alice@example.com", IsActive: true}
+
+	newApp := func(t *testing.T, export func(context.Context, int64, store.ExportSink) error) *application {
+		return newTestApplication(t, store.Storage{
+			Users:  &fakeUsersStore{getByID: usersByID(alice)},
+			Export: &fakeExportStore{export: export},
+		})
+	}
+	get := func(t *testing.T, app *application, query string, auth bool) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/me/export"+query, nil)
+		if auth {
+			authorize(t, app, req, alice.ID)
+		}
+		return executeRequest(app.mount(), req)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		rr := get(t, newApp(t, exportFixture(alice, 0)), "", true)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		if got, want := rr.Header().Get("Content-Disposition"), `attachment; filename="social-export-alice.json"`; got != want {
+			t.Errorf("Content-Disposition = %q, want %q", got, want)
+		}
+
+		var body struct {
+			User      store.User      `json:"user"`
+			Posts     []store.Post    `json:"posts"`
+			Comments  []store.Comment `json:"comments"`
+			Following []store.Follow  `json:"following"`
+			Followers []store.Follow  `json:"followers"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("export is not valid JSON: %v\n%s", err, rr.Body)
+		}
+		if body.User.Username != "alice" || len(body.Posts) != 2 || body.Posts[0].Title != "First" || body.Posts[1].ID != 12 {
+			t.Errorf("export = %+v, want alice with both posts", body)
+		}
+		if len(body.Comments) != 1 || len(body.Following) != 1 || body.Followers == nil || len(body.Followers) != 0 {
+			t.Errorf("comments, following, followers = %v, %v, %v; want one, one and an empty list", body.Comments, body.Following, body.Followers)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		rr := get(t, newApp(t, exportFixture(alice, 0)), "?format=csv", true)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		if got := rr.Header().Get("Content-Type"); got != "application/zip" {
+			t.Errorf("Content-Type = %q, want application/zip", got)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+		if err != nil {
+			t.Fatalf("export is not a zip: %v", err)
+		}
+
+		files := map[string][][]string{}
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			records, err := csv.NewReader(rc).ReadAll()
+			rc.Close()
+			if err != nil {
+				t.Fatalf("%s: %v", f.Name, err)
+			}
+			files[f.Name] = records
+		}
+
+		for name, rows := range map[string]int{"profile.csv": 1, "posts.csv": 2, "comments.csv": 1, "following.csv": 1, "followers.csv": 0} {
+			records, ok := files[name]
+			if !ok {
+				t.Errorf("zip has no %s", name)
+				continue
+			}
+			if len(records) != rows+1 {
+				t.Errorf("%s has %d rows, want a header and %d", name, len(records), rows)
+			}
+		}
+		if posts := files["posts.csv"]; len(posts) == 3 && (posts[1][1] != "First" || posts[2][1] != "Second") {
+			t.Errorf("posts.csv = %q, want First and Second", posts)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		rr := get(t, newApp(t, func(context.Context, int64, store.ExportSink) error {
+			t.Error("Export() was called for an unsupported format")
+			return nil
+		}), "?format=xml", true)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("fails before streaming", func(t *testing.T) {
+		rr := get(t, newApp(t, func(context.Context, int64, store.ExportSink) error {
+			return store.ErrNotFound
+		}), "", true)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		if rr := get(t, newApp(t, exportFixture(alice, 0)), "", false); rr.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusUnauthorized)
+		}
+	})
+}
+
+// TestExportOutlivesWriteTimeout runs a real server whose write timeout is
+// shorter than the export takes, which the handler must extend.
+func TestExportOutlivesWriteTimeout(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+
+	app := newTestApplication(t, store.Storage{
+		Users:  &fakeUsersStore{getByID: usersByID(alice)},
+		Export: &fakeExportStore{export: exportFixture(alice, 100*time.Millisecond)},
+	})
+
+	srv := httptest.NewUnstartedServer(app.mount())
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/users/me/export", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorize(t, app, req, alice.ID)
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("export request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("export was cut off: %v", err)
+	}
+	if !json.Valid(body) {
+		t.Errorf("export is not complete JSON: %s", body)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+type feedResponse struct {
+	Data       []store.PostWithMetadata `json:"data"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+func (app *application) getUserFeedHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	fq, err := app.parseFeedQuery(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	feed, err := app.feeds.GetUserFeed(r.Context(), user.ID, fq)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	resp := feedResponse{Data: feed}
+	if len(feed) == fq.Limit {
+		last := feed[len(feed)-1]
+		resp.NextCursor = store.EncodeCursor(store.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// parseFeedQuery reads the limit, sort and cursor query parameters shared by
+// the keyset-paginated endpoints. The result still needs validating.
+func (app *application) parseFeedQuery(r *http.Request) (store.FeedQuery, error) {
+	limit, _, err := app.parsePagination(r)
+	if err != nil {
+		return store.FeedQuery{}, err
+	}
+
+	fq := store.FeedQuery{
+		Limit: limit,
+		Sort:  "desc",
+	}
+
+	qs := r.URL.Query()
+	if sort := qs.Get("sort"); sort != "" {
+		fq.Sort = sort
+	}
+	fq.Cursor = qs.Get("cursor")
+
+	return fq, nil
+}
+
+// listUserPostsHandler lists the posts of the user named in the path, newest
+// first, for profile pages.
+func (app *application) listUserPostsHandler(w http.ResponseWriter, r *http.Request) {
+	author, err := app.store.Users.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	fq, err := app.parseFeedQuery(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	posts, err := app.store.Posts.GetByUser(r.Context(), author.ID, fq)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	resp := feedResponse{Data: posts}
+	if len(posts) == fq.Limit {
+		last := posts[len(posts)-1]
+		resp.NextCursor = store.EncodeCursor(store.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
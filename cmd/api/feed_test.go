@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestListUserPostsHandler(t *testing.T) {
+	alice := &store.User{ID: 3, Username: "alice", IsActive: true}
+
+	posts := []store.PostWithMetadata{
+		{Post: store.Post{ID: 12, UserID: alice.ID, Title: "Newer", LikesCount: 7, CreatedAt: "2024-01-02T00:00:00Z"}, Username: "alice", CommentsCount: 4},
+		{Post: store.Post{ID: 11, UserID: alice.ID, Title: "Older", LikesCount: 1, CreatedAt: "2024-01-01T00:00:00Z"}, Username: "alice"},
+	}
+
+	users := &fakeUsersStore{getByUsername: func(_ context.Context, username string) (*store.User, error) {
+		if username != alice.Username {
+			return nil, store.ErrNotFound
+		}
+		return alice, nil
+	}}
+
+	t.Run("existing user", func(t *testing.T) {
+		var gotID int64
+		var gotQuery store.FeedQuery
+		app := newTestApplication(t, store.Storage{
+			Users: users,
+			Posts: &fakePostsStore{getByUser: func(_ context.Context, userID int64, fq store.FeedQuery) ([]store.PostWithMetadata, error) {
+				gotID, gotQuery = userID, fq
+				return posts, nil
+			}},
+		})
+
+		rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/v1/users/alice/posts?limit=2", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		if gotID != alice.ID || gotQuery.Limit != 2 || gotQuery.Sort != "desc" {
+			t.Errorf("GetByUser(%d, %+v), want alice's ID, limit 2, newest first", gotID, gotQuery)
+		}
+
+		var body struct {
+			Data []struct {
+				ID            int64 `json:"id"`
+				LikesCount    int   `json:"likes_count"`
+				CommentsCount int   `json:"comments_count"`
+			} `json:"data"`
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Data) != 2 || body.Data[0].ID != 12 || body.Data[0].LikesCount != 7 || body.Data[0].CommentsCount != 4 {
+			t.Errorf("data = %+v, want both posts with their counts", body.Data)
+		}
+		if body.NextCursor == "" {
+			t.Error("a full page has no next_cursor")
+		}
+	})
+
+	t.Run("nonexistent user", func(t *testing.T) {
+		app := newTestApplication(t, store.Storage{
+			Users: users,
+			Posts: &fakePostsStore{getByUser: func(context.Context, int64, store.FeedQuery) ([]store.PostWithMetadata, error) {
+				t.Error("GetByUser() was called for an unknown user")
+				return nil, nil
+			}},
+		})
+
+		rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/v1/users/nobody/posts", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/cache"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// feedCache memoises GetUserFeed pages per user. Rather than tracking every
+// cursor a user has fetched, each user has a generation key that is folded
+// into the page keys; invalidating a feed deletes the generation so the old
+// pages are never read again and simply expire.
+type feedCache struct {
+	cache     cache.Store
+	ttl       time.Duration
+	posts     store.PostsStore
+	followers store.FollowersStore
+	logger    *slog.Logger
+}
+
+func newFeedCache(c cache.Store, ttl time.Duration, s store.Storage, logger *slog.Logger) *feedCache {
+	return &feedCache{cache: c, ttl: ttl, posts: s.Posts, followers: s.Followers, logger: logger}
+}
+
+func (f *feedCache) enabled() bool {
+	_, noop := f.cache.(cache.NoopStore)
+	return !noop && f.ttl > 0
+}
+
+// GetUserFeed returns the cached page when there is one and otherwise loads
+// it from the store and caches it. Cache failures are logged and fall through
+// to the store; they never fail the request.
+func (f *feedCache) GetUserFeed(ctx context.Context, userID int64, fq store.FeedQuery) ([]store.PostWithMetadata, error) {
+	if !f.enabled() {
+		return f.posts.GetUserFeed(ctx, userID, fq)
+	}
+
+	gen, err := f.generation(ctx, userID)
+	if err != nil {
+		f.logger.WarnContext(ctx, "feed cache unavailable", "user_id", userID, "error", err)
+		return f.posts.GetUserFeed(ctx, userID, fq)
+	}
+
+	key := feedPageKey(userID, gen, fq)
+	if data, ok, err := f.cache.Get(ctx, key); err != nil {
+		f.logger.WarnContext(ctx, "feed cache read failed", "user_id", userID, "error", err)
+	} else if ok {
+		var feed []store.PostWithMetadata
+		if err := json.Unmarshal(data, &feed); err == nil {
+			return feed, nil
+		}
+	}
+
+	feed, err := f.posts.GetUserFeed(ctx, userID, fq)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(feed); err == nil {
+		if err := f.cache.Set(ctx, key, data, f.ttl); err != nil {
+			f.logger.WarnContext(ctx, "feed cache write failed", "user_id", userID, "error", err)
+		}
+	}
+
+	return feed, nil
+}
+
+// Invalidate drops the cached feeds of the given users.
+func (f *feedCache) Invalidate(ctx context.Context, userIDs ...int64) {
+	if !f.enabled() || len(userIDs) == 0 {
+		return
+	}
+
+	keys := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		keys[i] = feedGenerationKey(id)
+	}
+	if err := f.cache.Delete(ctx, keys...); err != nil {
+		f.logger.WarnContext(ctx, "feed cache invalidation failed", "users", len(userIDs), "error", err)
+	}
+}
+
+// InvalidateAuthor drops the feeds a post by authorID appears in, after it is
+// created, edited or deleted: the author's own and those of everyone
+// following them.
+func (f *feedCache) InvalidateAuthor(ctx context.Context, authorID int64) {
+	if !f.enabled() {
+		return
+	}
+
+	ids, err := f.followers.FollowerIDs(ctx, authorID)
+	if err != nil {
+		f.logger.WarnContext(ctx, "feed cache invalidation failed", "author_id", authorID, "error", err)
+	}
+	f.Invalidate(ctx, append(ids, authorID)...)
+}
+
+// generation returns the user's current feed generation, starting a new one
+// when none is cached.
+func (f *feedCache) generation(ctx context.Context, userID int64) (string, error) {
+	key := feedGenerationKey(userID)
+
+	gen, ok, err := f.cache.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(gen), nil
+	}
+
+	gen = []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+	if err := f.cache.Set(ctx, key, gen, f.ttl); err != nil {
+		return "", err
+	}
+	return string(gen), nil
+}
+
+func feedGenerationKey(userID int64) string {
+	return "feed:" + strconv.FormatInt(userID, 10) + ":gen"
+}
+
+func feedPageKey(userID int64, gen string, fq store.FeedQuery) string {
+	return "feed:" + strconv.FormatInt(userID, 10) + ":" + gen + ":" + fq.Sort + ":" + strconv.Itoa(fq.Limit) + ":" + fq.Cursor
+}
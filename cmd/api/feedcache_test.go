@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/cache"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// fakeFollowersStore serves FollowerIDs from a fixed map.
+type fakeFollowersStore struct {
+	store.FollowersStore
+
+	followers map[int64][]int64
+}
+
+func (f *fakeFollowersStore) FollowerIDs(_ context.Context, userID int64) ([]int64, error) {
+	return f.followers[userID], nil
+}
+
+// newCountingFeedCache returns a feedCache over c whose store serves a
+// one-post feed per user and counts the loads per user.
+func newCountingFeedCache(c cache.Store, followers map[int64][]int64) (*feedCache, map[int64]int) {
+	loads := make(map[int64]int)
+	posts := &fakePostsStore{getUserFeed: func(_ context.Context, userID int64, fq store.FeedQuery) ([]store.PostWithMetadata, error) {
+		loads[userID]++
+		var post store.PostWithMetadata
+		post.ID = userID*100 + int64(loads[userID])
+		return []store.PostWithMetadata{post}, nil
+	}}
+
+	s := store.Storage{Posts: posts, Followers: &fakeFollowersStore{followers: followers}}
+	return newFeedCache(c, time.Minute, s, slog.New(slog.NewTextHandler(io.Discard, nil))), loads
+}
+
+func TestFeedCacheHitAndMiss(t *testing.T) {
+	ctx := context.Background()
+	feeds, loads := newCountingFeedCache(cache.NewMemoryStore(), nil)
+	first := store.FeedQuery{Limit: 20, Sort: "desc"}
+	next := store.FeedQuery{Limit: 20, Sort: "desc", Cursor: "abc"}
+
+	miss, err := feeds.GetUserFeed(ctx, 1, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hit, err := feeds.GetUserFeed(ctx, 1, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loads[1] != 1 {
+		t.Fatalf("store loaded %d times, want the second read served from cache", loads[1])
+	}
+	if hit[0].ID != miss[0].ID {
+		t.Errorf("cached feed = post %d, want post %d", hit[0].ID, miss[0].ID)
+	}
+
+	// Another cursor, or another user, is a different page.
+	feeds.GetUserFeed(ctx, 1, next)
+	feeds.GetUserFeed(ctx, 2, first)
+	if loads[1] != 2 || loads[2] != 1 {
+		t.Errorf("loads = %v, want a miss for the new cursor and the new user", loads)
+	}
+}
+
+func TestFeedCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	first := store.FeedQuery{Limit: 20, Sort: "desc"}
+
+	t.Run("follow", func(t *testing.T) {
+		feeds, loads := newCountingFeedCache(cache.NewMemoryStore(), nil)
+		feeds.GetUserFeed(ctx, 1, first)
+		feeds.GetUserFeed(ctx, 2, first)
+
+		feeds.Invalidate(ctx, 1)
+
+		feed, _ := feeds.GetUserFeed(ctx, 1, first)
+		feeds.GetUserFeed(ctx, 2, first)
+		if loads[1] != 2 || feed[0].ID != 102 {
+			t.Errorf("user 1 loaded %d times (post %d), want a fresh load after invalidation", loads[1], feed[0].ID)
+		}
+		if loads[2] != 1 {
+			t.Errorf("user 2 loaded %d times, want their cache kept", loads[2])
+		}
+	})
+
+	t.Run("new post", func(t *testing.T) {
+		// Users 2 and 3 follow author 1; user 4 does not.
+		feeds, loads := newCountingFeedCache(cache.NewMemoryStore(), map[int64][]int64{1: {2, 3}})
+		for id := int64(1); id <= 4; id++ {
+			feeds.GetUserFeed(ctx, id, first)
+		}
+
+		feeds.InvalidateAuthor(ctx, 1)
+
+		for id := int64(1); id <= 4; id++ {
+			feeds.GetUserFeed(ctx, id, first)
+		}
+		want := map[int64]int{1: 2, 2: 2, 3: 2, 4: 1}
+		for id, n := range want {
+			if loads[id] != n {
+				t.Errorf("user %d loaded %d times, want %d", id, loads[id], n)
+			}
+		}
+	})
+}
+
+func TestFeedCacheDisabled(t *testing.T) {
+	ctx := context.Background()
+	feeds, loads := newCountingFeedCache(cache.NoopStore{}, nil)
+
+	for range 3 {
+		if _, err := feeds.GetUserFeed(ctx, 1, store.FeedQuery{Limit: 20, Sort: "desc"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loads[1] != 3 {
+		t.Errorf("store loaded %d times, want every read to reach it", loads[1])
+	}
+}
+
+func TestPostWritesInvalidateFeeds(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true, Role: store.RoleUser}
+	bob := &store.User{ID: 2, Username: "bob", IsActive: true, Role: store.RoleUser}
+
+	tests := []struct {
+		name      string
+		method    string
+		body      string
+		wantTitle string // empty when the post should be gone from the feed
+	}{
+		{name: "update", method: http.MethodPut, body: `{"title":"edited","content":"new content"}`, wantTitle: "edited"},
+		{name: "patch", method: http.MethodPatch, body: `{"title":"patched"}`, wantTitle: "patched"},
+		{name: "delete", method: http.MethodDelete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The store holds alice's one post, which bob's feed shows.
+			post := &store.Post{ID: 5, UserID: alice.ID, Title: "original", Content: "content"}
+			posts := &fakePostsStore{
+				getByID: func(context.Context, int64) (*store.Post, error) {
+					if post == nil {
+						return nil, store.ErrNotFound
+					}
+					p := *post
+					return &p, nil
+				},
+				getUserFeed: func(context.Context, int64, store.FeedQuery) ([]store.PostWithMetadata, error) {
+					feed := []store.PostWithMetadata{}
+					if post != nil {
+						feed = append(feed, store.PostWithMetadata{Post: *post, Username: alice.Username})
+					}
+					return feed, nil
+				},
+				update: func(_ context.Context, p *store.Post) error {
+					post = p
+					return nil
+				},
+				patch: func(_ context.Context, _ int64, fields store.PostPatch) (*store.Post, error) {
+					post.Title = *fields.Title
+					return post, nil
+				},
+				delete: func(context.Context, int64) error {
+					post = nil
+					return nil
+				},
+			}
+			storage := store.Storage{
+				Users:     &fakeUsersStore{getByID: usersByID(alice, bob)},
+				Posts:     posts,
+				Followers: &fakeFollowersStore{followers: map[int64][]int64{alice.ID: {bob.ID}}},
+			}
+			app := newTestApplication(t, storage)
+			app.feeds = newFeedCache(cache.NewMemoryStore(), time.Minute, storage, app.logger)
+			mux := app.mount()
+
+			feedTitles := func() []string {
+				t.Helper()
+				req := httptest.NewRequest(http.MethodGet, "/v1/users/feed", nil)
+				authorize(t, app, req, bob.ID)
+				rr := executeRequest(mux, req)
+				if rr.Code != http.StatusOK {
+					t.Fatalf("feed status = %d; body %s", rr.Code, rr.Body)
+				}
+				var body struct {
+					Data []store.PostWithMetadata `json:"data"`
+				}
+				if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+					t.Fatal(err)
+				}
+				var titles []string
+				for _, p := range body.Data {
+					titles = append(titles, p.Title)
+				}
+				return titles
+			}
+
+			// Fill bob's cached feed.
+			if got := feedTitles(); !slices.Equal(got, []string{"original"}) {
+				t.Fatalf("feed before the write = %q", got)
+			}
+
+			req := httptest.NewRequest(tt.method, "/v1/posts/5", strings.NewReader(tt.body))
+			authorize(t, app, req, alice.ID)
+			if rr := executeRequest(mux, req); rr.Code >= 300 {
+				t.Fatalf("%s status = %d; body %s", tt.method, rr.Code, rr.Body)
+			}
+
+			var want []string
+			if tt.wantTitle != "" {
+				want = []string{tt.wantTitle}
+			}
+			if got := feedTitles(); !slices.Equal(got, want) {
+				t.Errorf("feed after the write = %q, want %q", got, want)
+			}
+		})
+	}
+}
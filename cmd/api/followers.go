@@ -0,0 +1,136 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// followUserHandler follows the user named in the path and notifies them in
+// the same transaction, so a follow is never recorded without its
+// notification.
+func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	target, err := app.store.Users.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	err = app.store.WithTx(r.Context(), func(tx *sql.Tx) error {
+		if err := app.store.Followers.FollowTx(r.Context(), tx, user.ID, target.ID); err != nil {
+			return err
+		}
+
+		return app.store.Notifications.CreateTx(r.Context(), tx, &store.Notification{
+			Type:     store.NotificationFollow,
+			UserID:   target.ID,
+			ActorID:  user.ID,
+			EntityID: user.ID,
+		})
+	})
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+	app.feeds.Invalidate(r.Context(), user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	target, err := app.store.Users.GetByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := app.store.Followers.Unfollow(r.Context(), user.ID, target.ID); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+	app.feeds.Invalidate(r.Context(), user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type FollowManyPayload struct {
+	IDs []int64 `json:"ids" validate:"required,min=1,max=100,dive,gt=0"`
+}
+
+type followManyResponse struct {
+	Requested int     `json:"requested"`
+	Followed  int     `json:"followed"`
+	IDs       []int64 `json:"ids"`
+}
+
+// followManyHandler follows up to 100 users at once, e.g. when importing
+// contacts. Each new follow is notified inside the same transaction.
+func (app *application) followManyHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	var payload FollowManyPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	ids := slices.Compact(slices.Sorted(slices.Values(payload.IDs)))
+
+	var followed []int64
+	err := app.store.WithTx(r.Context(), func(tx *sql.Tx) error {
+		var err error
+		followed, err = app.store.Followers.FollowManyTx(r.Context(), tx, user.ID, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range followed {
+			err := app.store.Notifications.CreateTx(r.Context(), tx, &store.Notification{
+				Type:     store.NotificationFollow,
+				UserID:   id,
+				ActorID:  user.ID,
+				EntityID: user.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+	if len(followed) > 0 {
+		app.feeds.Invalidate(r.Context(), user.ID)
+	}
+
+	resp := followManyResponse{Requested: len(ids), Followed: len(followed), IDs: followed}
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestFollowUserHandlerNotifies(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+	bob := &store.User{ID: 2, Username: "bob", IsActive: true}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO followers`).
+		WithArgs(bob.ID, alice.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`INSERT INTO notifications \(type, user_id, actor_id, entity_id\)`).
+		WithArgs(store.NotificationFollow, bob.ID, alice.ID, alice.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, "2024-01-01T00:00:00Z"))
+	mock.ExpectCommit()
+
+	storage := store.NewStorage(db, nil, time.Second, testPasswordCost)
+	storage.Users = &fakeUsersStore{
+		getByID: usersByID(alice, bob),
+		getByUsername: func(_ context.Context, username string) (*store.User, error) {
+			if username == bob.Username {
+				return bob, nil
+			}
+			return nil, store.ErrNotFound
+		},
+	}
+	app := newTestApplication(t, storage)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/bob/follow", nil)
+	authorize(t, app, req, alice.ID)
+	rr := executeRequest(app.mount(), req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusNoContent, rr.Body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFollowManyHandler(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+
+	t.Run("deduplicated and summarised", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		// User 3 is already followed, so only 2 comes back.
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO followers`).
+			WithArgs(alice.ID, "{2,3}").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "bool"}).AddRow(2, false))
+		mock.ExpectQuery(`INSERT INTO notifications`).
+			WithArgs(store.NotificationFollow, int64(2), alice.ID, alice.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, "2024-01-01T00:00:00Z"))
+		mock.ExpectCommit()
+
+		storage := store.NewStorage(db, nil, time.Second, testPasswordCost)
+		storage.Users = &fakeUsersStore{getByID: usersByID(alice)}
+		app := newTestApplication(t, storage)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/me/following", strings.NewReader(`{"ids":[3,2,3,2]}`))
+		authorize(t, app, req, alice.ID)
+		rr := executeRequest(app.mount(), req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		var resp followManyResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Requested != 2 || resp.Followed != 1 || !slices.Equal(resp.IDs, []int64{2}) {
+			t.Errorf("response = %+v, want 2 requested and user 2 followed", resp)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		body string
+	}{
+		{name: "empty", body: `{"ids":[]}`},
+		{name: "non-positive id", body: `{"ids":[0]}`},
+		{name: "too many", body: `{"ids":[` + strings.Repeat("1,", 100) + `1]}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{getByID: usersByID(alice)}})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/users/me/following", strings.NewReader(tt.body))
+			authorize(t, app, req, alice.ID)
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want %d", rr.Code, http.StatusUnprocessableEntity)
+			}
+		})
+	}
+}
+
+func TestFollowBlockedUserForbidden(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+	bob := &store.User{ID: 2, Username: "bob", IsActive: true}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// No notification is written for a follow the block prevented.
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO followers`).
+		WithArgs(bob.ID, alice.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	storage := store.NewStorage(db, nil, time.Second, testPasswordCost)
+	storage.Users = &fakeUsersStore{
+		getByID:       usersByID(alice, bob),
+		getByUsername: func(context.Context, string) (*store.User, error) { return bob, nil },
+	}
+	app := newTestApplication(t, storage)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/bob/follow", nil)
+	authorize(t, app, req, alice.ID)
+	rr := executeRequest(app.mount(), req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusForbidden, rr.Body)
+	}
+	if !strings.Contains(rr.Body.String(), store.ErrBlocked.Error()) {
+		t.Errorf("body = %s, want the block explained", rr.Body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type fakeBlocksStore struct {
+	store.BlocksStore
+
+	blocked [][2]int64
+}
+
+func (f *fakeBlocksStore) Block(_ context.Context, blockerID, blockedID int64) error {
+	if blockerID == blockedID {
+		return store.ErrSelfBlock
+	}
+	f.blocked = append(f.blocked, [2]int64{blockerID, blockedID})
+	return nil
+}
+
+func TestBlockUserHandler(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantBlocked [][2]int64
+	}{
+		{name: "block", path: "/v1/users/2/block", wantStatus: http.StatusNoContent, wantBlocked: [][2]int64{{1, 2}}},
+		{name: "self", path: "/v1/users/1/block", wantStatus: http.StatusBadRequest},
+		{name: "invalid id", path: "/v1/users/bob/block", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := &fakeBlocksStore{}
+			app := newTestApplication(t, store.Storage{
+				Users:  &fakeUsersStore{getByID: usersByID(alice)},
+				Blocks: blocks,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			authorize(t, app, req, alice.ID)
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if !slices.Equal(blocks.blocked, tt.wantBlocked) {
+				t.Errorf("blocked = %v, want %v", blocks.blocked, tt.wantBlocked)
+			}
+		})
+	}
+}
@@ -1,18 +1,101 @@
 package main
 
 import (
-	"log"
+	"context"
 	"net/http"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/buildinfo"
+)
+
+const (
+	healthzPingTimeout = 2 * time.Second
+	readyzPingTimeout  = 500 * time.Millisecond
 )
 
 func (app *application) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	data := map[string]string{
 		"status":  "ok",
-		"env":     app.config.env,
-		"version": version,
+		"env":     app.config.env.EnvName,
+		"version": buildinfo.Version,
 	}
 
 	if err := writeJSON(w, http.StatusOK, data); err != nil {
-		log.Print(err.Error())
+		app.logger.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+func (app *application) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	status, data := http.StatusOK, map[string]string{"status": "ok", "db": "up"}
+	if err := app.pingDB(r.Context(), healthzPingTimeout); err != nil {
+		app.logger.WarnContext(r.Context(), "healthz: database ping failed", "error", err)
+		status, data = http.StatusServiceUnavailable, map[string]string{"status": "degraded", "db": "down"}
+	}
+	data["version"] = buildinfo.Version
+	data["uptime"] = app.uptime().String()
+
+	if err := writeJSON(w, status, data); err != nil {
+		app.logger.ErrorContext(r.Context(), "failed to write response", "error", err)
 	}
 }
+
+// uptime is how long the process has been serving, to the second, so it
+// renders as e.g. "3m20s".
+func (app *application) uptime() time.Duration {
+	return time.Since(app.startedAt).Round(time.Second)
+}
+
+func (app *application) livezHandler(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSON(w, http.StatusOK, map[string]string{"status": "alive"}); err != nil {
+		app.logger.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// readyzHandler reports 503 while the application is draining so that load
+// balancers stop routing to it before the listener closes. Dependency pings
+// use a tighter deadline than healthz so a slow database also takes the
+// instance out of rotation.
+func (app *application) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	resp := readinessResponse{Status: "ready", Checks: map[string]string{}}
+	status := http.StatusOK
+
+	if app.draining.Load() {
+		resp.Status = "draining"
+		status = http.StatusServiceUnavailable
+	}
+
+	for name, check := range app.readinessChecks() {
+		if err := check(r.Context()); err != nil {
+			app.logger.WarnContext(r.Context(), "readyz: check failed", "check", name, "error", err)
+			resp.Checks[name] = "down"
+			resp.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		resp.Checks[name] = "up"
+	}
+
+	if err := writeJSON(w, status, resp); err != nil {
+		app.logger.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+// readinessChecks lists the dependencies that must be reachable for the
+// instance to serve traffic.
+func (app *application) readinessChecks() map[string]func(context.Context) error {
+	return map[string]func(context.Context) error{
+		"db": func(ctx context.Context) error { return app.pingDB(ctx, readyzPingTimeout) },
+	}
+}
+
+func (app *application) pingDB(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return app.store.Ping(ctx)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// newPingStorage returns a Storage whose database pings succeed, or fail
+// with pingErr when it is non-nil.
+func newPingStorage(t *testing.T, pingErr error) store.Storage {
+	t.Helper()
+
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mock.ExpectPing().WillReturnError(pingErr)
+
+	return store.NewStorage(db, nil, time.Second, testPasswordCost)
+}
+
+func TestHealthz(t *testing.T) {
+	tests := []struct {
+		name       string
+		pingErr    error
+		wantStatus int
+		wantBody   map[string]string
+	}{
+		{
+			name:       "up",
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]string{"status": "ok", "db": "up"},
+		},
+		{
+			name:       "down",
+			pingErr:    errors.New("connection refused"),
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   map[string]string{"status": "degraded", "db": "down"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, newPingStorage(t, tt.pingErr))
+
+			rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+
+			var body map[string]string
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.wantBody {
+				if body[k] != v {
+					t.Errorf("%s = %q, want %q", k, body[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthzUptime(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mock.ExpectPing()
+	mock.ExpectPing()
+
+	app := newTestApplication(t, store.NewStorage(db, nil, time.Second, testPasswordCost))
+	app.startedAt = time.Now().Add(-200 * time.Second)
+
+	healthz := func() (string, time.Duration) {
+		t.Helper()
+
+		rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var body map[string]string
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		uptime, err := time.ParseDuration(body["uptime"])
+		if err != nil {
+			t.Fatalf("uptime %q is not a duration: %v", body["uptime"], err)
+		}
+		return body["uptime"], uptime
+	}
+
+	first, before := healthz()
+	if first != "3m20s" {
+		t.Errorf("uptime = %q, want %q", first, "3m20s")
+	}
+
+	// Move the start back rather than sleep past a whole second.
+	app.startedAt = app.startedAt.Add(-5 * time.Second)
+
+	second, after := healthz()
+	if after <= before {
+		t.Errorf("uptime went from %s to %s, want it to increase", first, second)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		pingErr    error
+		draining   bool
+		wantStatus int
+		wantState  string
+		wantDB     string
+	}{
+		{name: "ready", wantStatus: http.StatusOK, wantState: "ready", wantDB: "up"},
+		{name: "db down", pingErr: errors.New("connection refused"), wantStatus: http.StatusServiceUnavailable, wantState: "not_ready", wantDB: "down"},
+		{name: "draining", draining: true, wantStatus: http.StatusServiceUnavailable, wantState: "draining", wantDB: "up"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, newPingStorage(t, tt.pingErr))
+			app.draining.Store(tt.draining)
+
+			rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+
+			var body readinessResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != tt.wantState || body.Checks["db"] != tt.wantDB {
+				t.Errorf("body = %+v, want status %q and db %q", body, tt.wantState, tt.wantDB)
+			}
+		})
+	}
+}
+
+func TestShutdownFailsReadinessBeforeStopping(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectPing()
+	mock.ExpectPing()
+	mock.ExpectClose()
+
+	app := newTestApplication(t, store.NewStorage(db, nil, time.Second, testPasswordCost))
+	app.config.drainDelay = 200 * time.Millisecond
+	app.config.shutdownTimeout = 5 * time.Second
+	mux := app.mount()
+
+	if rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/readyz", nil)); rr.Code != http.StatusOK {
+		t.Fatalf("readyz before shutdown = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- app.shutdown(app.newServer(mux)) }()
+
+	for !app.draining.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The drain delay keeps the instance serving while readiness fails.
+	if rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/readyz", nil)); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz while draining = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+	if rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/livez", nil)); rr.Code != http.StatusOK {
+		t.Errorf("livez while draining = %d, want %d", rr.Code, http.StatusOK)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("shutdown returned before the drain delay: %v", err)
+	default:
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestShutdownOrdering checks that a request still in flight when shutdown
+// starts can queue background work, that the work finishes, and that only
+// then is the database closed.
+func TestShutdownOrdering(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sqlmock matches in order, so a Close before the task's write fails.
+	mock.ExpectExec(`INSERT INTO notifications`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectClose()
+
+	app := newTestApplication(t, store.NewStorage(db, nil, time.Second, testPasswordCost))
+	app.config.drainDelay = 0
+	app.config.shutdownTimeout = 5 * time.Second
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+
+		err := app.workers.Submit(func(ctx context.Context) {
+			time.Sleep(20 * time.Millisecond)
+			if _, err := db.ExecContext(ctx, `INSERT INTO notifications DEFAULT VALUES`); err != nil {
+				t.Errorf("background write: %v", err)
+			}
+			record("task done")
+		})
+		if err != nil {
+			t.Errorf("Submit() from an in-flight request error = %v", err)
+		}
+		record("request done")
+		w.WriteHeader(http.StatusNoContent)
+	})}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+
+	respErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		respErr <- err
+	}()
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- app.shutdown(srv) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("shutdown returned with a request in flight: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+	if err := <-respErr; err != nil {
+		t.Errorf("in-flight request failed: %v", err)
+	}
+
+	mu.Lock()
+	got := strings.Join(events, ", ")
+	mu.Unlock()
+	if want := "request done, task done"; got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
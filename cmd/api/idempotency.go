@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotencyMaxKeyLength = 255
+)
+
+// keyedMutex hands out one mutex per key so that concurrent requests sharing
+// an idempotency key run one at a time. Entries are dropped once unused.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*refMutex
+}
+
+type refMutex struct {
+	sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*refMutex)}
+}
+
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	m, ok := k.locks[key]
+	if !ok {
+		m = &refMutex{}
+		k.locks[key] = m
+	}
+	m.refs++
+	k.mu.Unlock()
+
+	m.Lock()
+
+	return func() {
+		m.Unlock()
+
+		k.mu.Lock()
+		m.refs--
+		if m.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// recordingWriter tees the response so it can be stored for replay.
+type recordingWriter struct {
+	*responseWriter
+	body bytes.Buffer
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.responseWriter.Write(b)
+}
+
+// idempotent replays the first response for a repeated Idempotency-Key. Keys
+// are scoped to the authenticated user, so it must run after authenticate.
+// Requests without the header pass straight through. A replay carries the
+// stored status, body, Content-Type and Location only.
+//
+// Duplicates are serialised with an in-process lock, so only within one
+// instance: copies of a request that reach two instances at once can both
+// run the handler, and the later response is the one kept.
+func (app *application) idempotent(next http.Handler) http.Handler {
+	locks := newKeyedMutex()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > idempotencyMaxKeyLength {
+			app.badRequestResponse(w, r, errors.New("Idempotency-Key header is too long"))
+			return
+		}
+
+		user, ok := userFromContext(r)
+		if !ok {
+			app.unauthorizedResponse(w, r, errUnauthenticated)
+			return
+		}
+
+		unlock := locks.lock(strconv.FormatInt(user.ID, 10) + ":" + key)
+		defer unlock()
+
+		stored, err := app.store.Idempotency.Get(r.Context(), user.ID, key)
+		switch {
+		case err == nil:
+			w.Header().Set("Content-Type", stored.ContentType)
+			if stored.Location != "" {
+				w.Header().Set("Location", stored.Location)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Body)
+			return
+		case !errors.Is(err, store.ErrNotFound):
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		rec := &recordingWriter{responseWriter: newResponseWriter(w)}
+		next.ServeHTTP(rec, r)
+
+		// Server errors are not cached so that a retry can still succeed.
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		resp := &store.IdempotentResponse{
+			UserID:      user.ID,
+			Key:         key,
+			StatusCode:  rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Location:    rec.Header().Get("Location"),
+			Body:        rec.body.Bytes(),
+		}
+		if err := app.store.Idempotency.Save(r.Context(), resp, app.config.idempotencyTTL); err != nil {
+			app.logger.ErrorContext(r.Context(), "failed to store idempotent response", "key", key, "error", err)
+		}
+	})
+}
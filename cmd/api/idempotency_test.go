@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// memoryIdempotencyStore keeps responses in a map and ignores expiry.
+type memoryIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]store.IdempotentResponse
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{responses: make(map[string]store.IdempotentResponse)}
+}
+
+func (s *memoryIdempotencyStore) Get(_ context.Context, userID int64, key string) (*store.IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp, ok := s.responses[strconv.FormatInt(userID, 10)+":"+key]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &resp, nil
+}
+
+func (s *memoryIdempotencyStore) Save(_ context.Context, resp *store.IdempotentResponse, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses[strconv.FormatInt(resp.UserID, 10)+":"+resp.Key] = *resp
+	return nil
+}
+
+// idempotentCounter returns a handler wrapped in app.idempotent that counts
+// its executions and answers status with the running count as the body.
+func idempotentCounter(app *application, status int, delay time.Duration) (http.Handler, *atomic.Int32) {
+	var calls atomic.Int32
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(status)
+		w.Write([]byte(strconv.Itoa(int(n))))
+	})
+	return app.idempotent(next), &calls
+}
+
+func newIdempotentRequest(key string, userID int64) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/posts", nil)
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	return req.WithContext(contextWithUser(req.Context(), &store.User{ID: userID}))
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	app := newTestApplication(t, store.Storage{Idempotency: newMemoryIdempotencyStore()})
+	h, calls := idempotentCounter(app, http.StatusCreated, 0)
+
+	first := executeRequest(h, newIdempotentRequest("key-1", 1))
+	second := executeRequest(h, newIdempotentRequest("key-1", 1))
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler ran %d times, want 1", n)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+	if got := second.Header().Get("Content-Type"); got != "text/plain" {
+		t.Errorf("replay Content-Type = %q, want text/plain", got)
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("only the replay should carry Idempotent-Replayed")
+	}
+}
+
+func TestIdempotentReplayLocation(t *testing.T) {
+	app := newTestApplication(t, store.Storage{Idempotency: newMemoryIdempotencyStore()})
+	var calls atomic.Int32
+	h := app.idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Location", "/v1/posts/42")
+		w.Header().Set("X-Other", "not replayed")
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	executeRequest(h, newIdempotentRequest("key-1", 1))
+	replay := executeRequest(h, newIdempotentRequest("key-1", 1))
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler ran %d times, want 1", n)
+	}
+	if got := replay.Header().Get("Location"); got != "/v1/posts/42" {
+		t.Errorf("replay Location = %q, want /v1/posts/42", got)
+	}
+	if got := replay.Header().Get("X-Other"); got != "" {
+		t.Errorf("replay X-Other = %q, want only the stored headers", got)
+	}
+}
+
+func TestIdempotentScoping(t *testing.T) {
+	tests := []struct {
+		name      string
+		first     *http.Request
+		second    *http.Request
+		wantCalls int32
+	}{
+		{
+			name:      "no key",
+			first:     newIdempotentRequest("", 1),
+			second:    newIdempotentRequest("", 1),
+			wantCalls: 2,
+		},
+		{
+			name:      "different keys",
+			first:     newIdempotentRequest("key-1", 1),
+			second:    newIdempotentRequest("key-2", 1),
+			wantCalls: 2,
+		},
+		{
+			name:      "same key, different users",
+			first:     newIdempotentRequest("key-1", 1),
+			second:    newIdempotentRequest("key-1", 2),
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{Idempotency: newMemoryIdempotencyStore()})
+			h, calls := idempotentCounter(app, http.StatusCreated, 0)
+
+			executeRequest(h, tt.first)
+			executeRequest(h, tt.second)
+
+			if n := calls.Load(); n != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotentServerErrorNotStored(t *testing.T) {
+	app := newTestApplication(t, store.Storage{Idempotency: newMemoryIdempotencyStore()})
+	h, calls := idempotentCounter(app, http.StatusInternalServerError, 0)
+
+	executeRequest(h, newIdempotentRequest("key-1", 1))
+	executeRequest(h, newIdempotentRequest("key-1", 1))
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("handler ran %d times, want 2 so retries can succeed", n)
+	}
+}
+
+func TestIdempotentConcurrentDuplicates(t *testing.T) {
+	app := newTestApplication(t, store.Storage{Idempotency: newMemoryIdempotencyStore()})
+	h, calls := idempotentCounter(app, http.StatusCreated, 20*time.Millisecond)
+
+	const requests = 8
+	responses := make([]*httptest.ResponseRecorder, requests)
+
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = executeRequest(h, newIdempotentRequest("key-1", 1))
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler ran %d times, want 1", n)
+	}
+	for i, rr := range responses {
+		if rr.Code != http.StatusCreated || rr.Body.String() != "1" {
+			t.Errorf("response %d = %d %q, want %d %q", i, rr.Code, rr.Body, http.StatusCreated, "1")
+		}
+	}
+}
+
+func TestIdempotentRequiresUser(t *testing.T) {
+	app := newTestApplication(t, store.Storage{Idempotency: newMemoryIdempotencyStore()})
+	h, calls := idempotentCounter(app, http.StatusCreated, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/posts", nil)
+	req.Header.Set(idempotencyKeyHeader, "key-1")
+	rr := executeRequest(h, req)
+
+	if rr.Code != http.StatusUnauthorized || calls.Load() != 0 {
+		t.Errorf("status = %d with %d calls, want %d and none", rr.Code, calls.Load(), http.StatusUnauthorized)
+	}
+}
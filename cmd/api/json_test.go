@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testPayload struct {
+	Name string `json:"name"`
+}
+
+func TestReadJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "valid", body: `{"name":"alice"}`},
+		{name: "unknown field", body: `{"name":"alice","admin":true}`, wantErr: `body contains unknown key "admin"`},
+		{name: "malformed", body: `{"name":`, wantErr: "body contains badly-formed JSON"},
+		{name: "syntax error", body: `{"name" "alice"}`, wantErr: "body contains badly-formed JSON (at character 9)"},
+		{name: "wrong type", body: `{"name":1}`, wantErr: `body contains incorrect JSON type for field "name"`},
+		{name: "empty", body: ``, wantErr: "body must not be empty"},
+		{name: "trailing data", body: `{"name":"alice"}{}`, wantErr: "body must only contain a single JSON value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			var dst testPayload
+			err := readJSON(w, r, &dst)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("readJSON() error = %v", err)
+				}
+				if dst.Name != "alice" {
+					t.Errorf("Name = %q, want %q", dst.Name, "alice")
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("readJSON() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("oversized", func(t *testing.T) {
+		body := `{"name":"` + strings.Repeat("a", maxJSONBytes) + `"}`
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		var dst testPayload
+		err := readJSON(w, r, &dst)
+		if !errors.Is(err, errBodyTooLarge) {
+			t.Errorf("readJSON() error = %v, want errBodyTooLarge", err)
+		}
+	})
+}
+
+func TestWriteJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	if err := writeJSON(w, http.StatusCreated, testPayload{Name: "alice"}); err != nil {
+		t.Fatalf("writeJSON() error = %v", err)
+	}
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if got, want := w.Body.String(), "{\"name\":\"alice\"}\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestWriteJSONMarshalFailure(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	if err := writeJSON(w, http.StatusOK, map[string]any{"bad": make(chan int)}); err == nil {
+		t.Fatal("writeJSON() error = nil, want a marshal error")
+	}
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	writeError(w, r, http.StatusNotFound, "not here")
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if got, want := w.Body.String(), "{\"error\":\"not here\"}\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import "net/http"
+
+type likeResponse struct {
+	PostID     int64 `json:"post_id"`
+	LikesCount int64 `json:"likes_count"`
+}
+
+func (app *application) likePostHandler(w http.ResponseWriter, r *http.Request) {
+	app.toggleLike(w, r, true)
+}
+
+func (app *application) unlikePostHandler(w http.ResponseWriter, r *http.Request) {
+	app.toggleLike(w, r, false)
+}
+
+// toggleLike applies the like or unlike and responds with the post's updated
+// like count. Both operations are idempotent, so repeating either one simply
+// returns the current count.
+func (app *application) toggleLike(w http.ResponseWriter, r *http.Request, like bool) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	postID, err := readIDParam(r, "id")
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if like {
+		err = app.store.Likes.Like(r.Context(), user.ID, postID)
+	} else {
+		err = app.store.Likes.Unlike(r.Context(), user.ID, postID)
+	}
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	count, err := app.store.Likes.Count(r.Context(), postID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, likeResponse{PostID: postID, LikesCount: count}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// memoryLikesStore keeps likes for post 1 in a set; other posts do not exist.
+type memoryLikesStore struct {
+	store.LikesStore
+
+	mu    sync.Mutex
+	likes map[int64]bool
+}
+
+func (s *memoryLikesStore) Like(_ context.Context, userID, postID int64) error {
+	if postID != 1 {
+		return store.ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.likes[userID] = true
+	return nil
+}
+
+func (s *memoryLikesStore) Unlike(_ context.Context, userID, postID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.likes, userID)
+	return nil
+}
+
+func (s *memoryLikesStore) Count(_ context.Context, postID int64) (int64, error) {
+	if postID != 1 {
+		return 0, store.ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.likes)), nil
+}
+
+func TestToggleLike(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+
+	app := newTestApplication(t, store.Storage{
+		Users: &fakeUsersStore{getByID: usersByID(alice)},
+		Likes: &memoryLikesStore{likes: make(map[int64]bool)},
+	})
+	mux := app.mount()
+
+	do := func(method, path string) (int, likeResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		authorize(t, app, req, alice.ID)
+		rr := executeRequest(mux, req)
+
+		var resp likeResponse
+		if rr.Code == http.StatusOK {
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+		}
+		return rr.Code, resp
+	}
+
+	steps := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCount  int64
+	}{
+		{name: "unlike a post not liked", method: http.MethodDelete, path: "/v1/posts/1/like", wantStatus: http.StatusOK, wantCount: 0},
+		{name: "like", method: http.MethodPost, path: "/v1/posts/1/like", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "like again", method: http.MethodPost, path: "/v1/posts/1/like", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "unlike", method: http.MethodDelete, path: "/v1/posts/1/like", wantStatus: http.StatusOK, wantCount: 0},
+		{name: "like a missing post", method: http.MethodPost, path: "/v1/posts/2/like", wantStatus: http.StatusNotFound},
+	}
+
+	for _, step := range steps {
+		status, resp := do(step.method, step.path)
+		if status != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", step.name, status, step.wantStatus)
+		}
+		if status == http.StatusOK && (resp.PostID != 1 || resp.LikesCount != step.wantCount) {
+			t.Errorf("%s: response = %+v, want post 1 with %d likes", step.name, resp, step.wantCount)
+		}
+	}
+}
+
+func TestToggleLikeRequiresAuthentication(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+
+	rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodPost, "/v1/posts/1/like", nil))
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
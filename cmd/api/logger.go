@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type logConfig struct {
+	level  string
+	format string
+}
+
+// newLogger builds the application logger from cfg. Records are always
+// decorated with the request ID when the context carries one.
+func newLogger(w io.Writer, cfg logConfig) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", cfg.level)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch strings.ToLower(cfg.format) {
+	case "json", "":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", cfg.format)
+	}
+
+	return slog.New(newRequestIDHandler(h)), nil
+}
+
+// fatal logs err and exits the process. Deferred functions do not run.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
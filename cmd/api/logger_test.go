@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewLoggerLevel(t *testing.T) {
+	t.Run("production suppresses debug", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, logConfig{level: "info", format: "json"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		logger.Debug("cache miss", "key", "feed:1")
+		if buf.Len() != 0 {
+			t.Fatalf("debug line written at info level: %s", buf.String())
+		}
+
+		logger.Info("server started", "addr", ":8081")
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("info line is not JSON: %v: %s", err, buf.String())
+		}
+		if record["level"] != "INFO" || record["msg"] != "server started" || record["addr"] != ":8081" {
+			t.Errorf("record = %v", record)
+		}
+	})
+
+	t.Run("debug level keeps debug", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := newLogger(&buf, logConfig{level: "DEBUG", format: "text"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		logger.Debug("cache miss", "key", "feed:1")
+		if got := buf.String(); !strings.Contains(got, "level=DEBUG") || !strings.Contains(got, "key=feed:1") {
+			t.Errorf("output = %q, want a text debug line", got)
+		}
+	})
+
+	for _, cfg := range []logConfig{
+		{level: "loud", format: "json"},
+		{level: "info", format: "xml"},
+	} {
+		if _, err := newLogger(&bytes.Buffer{}, cfg); err == nil {
+			t.Errorf("newLogger(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
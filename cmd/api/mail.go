@@ -0,0 +1,30 @@
+package main
+
+import "context"
+
+// sendEmail delivers the email on the background worker pool so that slow
+// mail providers never hold up a request. Failures are logged, not reported
+// to the caller.
+func (app *application) sendEmail(to, templateName string, data any) {
+	err := app.workers.Submit(func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, app.config.mail.sendTimeout)
+		defer cancel()
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- app.mailer.Send(to, templateName, data)
+		}()
+
+		select {
+		case err := <-errc:
+			if err != nil {
+				app.logger.Error("failed to send email", "template", templateName, "error", err)
+			}
+		case <-ctx.Done():
+			app.logger.Error("timed out sending email", "template", templateName, "error", ctx.Err())
+		}
+	})
+	if err != nil {
+		app.logger.Error("failed to queue email", "template", templateName, "error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type metrics struct {
+	registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	inFlight        prometheus.Gauge
+
+	dbOpenConnections *prometheus.GaugeVec
+	dbWaitCount       prometheus.Gauge
+	dbWaitDuration    prometheus.Gauge
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "social",
+			Name:      "http_requests_total",
+			Help:      "Total number of HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "social",
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "social",
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
+		}),
+		dbOpenConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "social",
+			Name:      "db_connections",
+			Help:      "Database pool connections by state (open, in_use, idle).",
+		}, []string{"state"}),
+		dbWaitCount: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "social",
+			Name:      "db_wait_count",
+			Help:      "Total number of connections waited for since startup.",
+		}),
+		dbWaitDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "social",
+			Name:      "db_wait_duration_seconds",
+			Help:      "Total time spent waiting for a connection since startup.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestsTotal,
+		m.requestDuration,
+		m.inFlight,
+		m.dbOpenConnections,
+		m.dbWaitCount,
+		m.dbWaitDuration,
+	)
+
+	return m
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// instrument records request metrics labelled by the matched chi route
+// pattern rather than the raw path, which keeps label cardinality bounded.
+func (app *application) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := newResponseWriter(w)
+
+		app.metrics.inFlight.Inc()
+		defer app.metrics.inFlight.Dec()
+
+		next.ServeHTTP(rw, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		app.metrics.requestsTotal.WithLabelValues(route, r.Method, strconv.Itoa(rw.status)).Inc()
+		app.metrics.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+func (m *metrics) observeDBStats(stats sql.DBStats) {
+	m.dbOpenConnections.WithLabelValues("open").Set(float64(stats.OpenConnections))
+	m.dbOpenConnections.WithLabelValues("in_use").Set(float64(stats.InUse))
+	m.dbOpenConnections.WithLabelValues("idle").Set(float64(stats.Idle))
+	m.dbWaitCount.Set(float64(stats.WaitCount))
+	m.dbWaitDuration.Set(stats.WaitDuration.Seconds())
+}
+
+// collectDBStats samples the pool every interval until ctx is done, updating
+// the gauges and logging a one-line summary.
+func (app *application) collectDBStats(ctx context.Context, interval time.Duration, source func() sql.DBStats) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := source()
+			app.metrics.observeDBStats(stats)
+			app.logger.Info("db pool stats",
+				"open", stats.OpenConnections,
+				"in_use", stats.InUse,
+				"idle", stats.Idle,
+				"wait_count", stats.WaitCount,
+				"wait_duration", stats.WaitDuration,
+			)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestInstrumentCountsRequests(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+	mux := app.mount()
+
+	for range 2 {
+		executeRequest(mux, httptest.NewRequest(http.MethodGet, "/version", nil))
+	}
+	executeRequest(mux, httptest.NewRequest(http.MethodGet, "/v1/posts/abc", nil))
+
+	tests := []struct {
+		route, status string
+		want          float64
+	}{
+		{route: "/version", status: "200", want: 2},
+		// The chi pattern is used, not the raw path.
+		{route: "/v1/posts/{id}", status: "400", want: 1},
+	}
+	for _, tt := range tests {
+		counter := app.metrics.requestsTotal.WithLabelValues(tt.route, http.MethodGet, tt.status)
+		if got := testutil.ToFloat64(counter); got != tt.want {
+			t.Errorf("requests{route=%q, status=%s} = %v, want %v", tt.route, tt.status, got, tt.want)
+		}
+	}
+
+	if got := testutil.ToFloat64(app.metrics.inFlight); got != 0 {
+		t.Errorf("in flight = %v, want 0", got)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	app := newTestApplication(t, newPingStorage(t, nil))
+	mux := app.mount()
+
+	executeRequest(mux, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	want := `social_http_requests_total{method="GET",route="/healthz",status="200"} 1`
+	if !strings.Contains(rr.Body.String(), want) {
+		t.Errorf("metrics output missing %q", want)
+	}
+}
+
+func TestCollectDBStats(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+
+	stats := sql.DBStats{
+		OpenConnections: 10,
+		InUse:           7,
+		Idle:            3,
+		WaitCount:       42,
+		WaitDuration:    1500 * time.Millisecond,
+	}
+	var sampled atomic.Int32
+	source := func() sql.DBStats {
+		sampled.Add(1)
+		return stats
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		app.collectDBStats(ctx, time.Millisecond, source)
+		close(done)
+	}()
+
+	for sampled.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("collectDBStats did not stop after cancel")
+	}
+
+	gauges := []struct {
+		name  string
+		gauge prometheus.Gauge
+		want  float64
+	}{
+		{name: "open", gauge: app.metrics.dbOpenConnections.WithLabelValues("open"), want: 10},
+		{name: "in_use", gauge: app.metrics.dbOpenConnections.WithLabelValues("in_use"), want: 7},
+		{name: "idle", gauge: app.metrics.dbOpenConnections.WithLabelValues("idle"), want: 3},
+		{name: "wait_count", gauge: app.metrics.dbWaitCount, want: 42},
+		{name: "wait_duration", gauge: app.metrics.dbWaitDuration, want: 1.5},
+	}
+	for _, g := range gauges {
+		if got := testutil.ToFloat64(g.gauge); got != g.want {
+			t.Errorf("%s = %v, want %v", g.name, got, g.want)
+		}
+	}
+
+	// The registry exposes every pool gauge.
+	rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, name := range []string{"social_db_connections", "social_db_wait_count", "social_db_wait_duration_seconds"} {
+		if !strings.Contains(rr.Body.String(), name) {
+			t.Errorf("metrics output missing %s", name)
+		}
+	}
+}
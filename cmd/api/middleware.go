@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.status = status
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (app *application) logRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := newResponseWriter(w)
+
+		next.ServeHTTP(rw, r)
+
+		app.logger.InfoContext(r.Context(), "request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"ip", remoteHost(r),
+			"status", rw.status,
+			"bytes", rw.bytes,
+			"duration", time.Since(start),
+		)
+	})
+}
+
+func (app *application) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				app.logger.ErrorContext(r.Context(), "panic recovered",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"error", fmt.Sprint(rec),
+					"stack", string(debug.Stack()),
+				)
+
+				w.Header().Set("Connection", "close")
+				writeError(w, r, http.StatusInternalServerError, "the server encountered a problem")
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (app *application) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			app.unauthorizedResponse(w, r, errors.New("authorization header is missing"))
+			return
+		}
+
+		scheme, tokenString, ok := strings.Cut(authHeader, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || tokenString == "" {
+			app.unauthorizedResponse(w, r, errors.New("authorization header is malformed"))
+			return
+		}
+
+		token, err := app.authenticator.ValidateToken(tokenString)
+		if err != nil {
+			app.unauthorizedResponse(w, r, err)
+			return
+		}
+
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			app.unauthorizedResponse(w, r, errors.New("invalid token claims"))
+			return
+		}
+
+		sub, ok := claims["sub"].(float64)
+		if !ok {
+			app.unauthorizedResponse(w, r, errors.New("invalid token subject"))
+			return
+		}
+
+		user, err := app.store.Users.GetByIDPrimary(r.Context(), int64(sub))
+		if err != nil {
+			app.unauthorizedResponse(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(contextWithUser(r.Context(), user)))
+	})
+}
+
+// requireRole rejects requests whose authenticated user does not hold role or
+// a higher one. It must run after authenticate.
+func (app *application) requireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := userFromContext(r)
+			if !ok {
+				app.unauthorizedResponse(w, r, errUnauthenticated)
+				return
+			}
+
+			if !user.HasRole(role) {
+				app.forbiddenResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// maxBodyBytes caps every request body at limit bytes. Requests that declare
+// a larger Content-Length are rejected up front; chunked bodies are cut off by
+// http.MaxBytesReader as soon as a handler reads past the limit.
+func (app *application) maxBodyBytes(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				app.payloadTooLargeResponse(w, r, fmt.Errorf("%w: content length %d exceeds %d", errBodyTooLarge, r.ContentLength, limit))
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestTimeout bounds each request to d. The request context is cancelled
+// at the deadline so in-flight queries abort, and a handler that still has
+// not responded is answered with 503. Responses are buffered, so it must not
+// wrap streaming endpoints.
+func (app *application) requestTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		th := http.TimeoutHandler(next, d, `{"error":"request timed out"}`)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Only used for the timeout body; handler headers replace it.
+			w.Header().Set("Content-Type", "application/json")
+			th.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rissabekov-wes/social/internal/auth"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestLogRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBytes  int
+	}{
+		{
+			name:       "explicit status",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) },
+			wantStatus: http.StatusTeapot,
+		},
+		{
+			name:       "implicit 200",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hello")) },
+			wantStatus: http.StatusOK,
+			wantBytes:  5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			app := newTestApplication(t, store.Storage{})
+			app.logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/things?page=2", nil)
+			executeRequest(app.logRequest(tt.handler), req)
+
+			var entry struct {
+				Msg    string `json:"msg"`
+				Method string `json:"method"`
+				Path   string `json:"path"`
+				Status int    `json:"status"`
+				Bytes  int    `json:"bytes"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("log line is not JSON: %v: %s", err, buf.String())
+			}
+
+			if entry.Msg != "request completed" || entry.Method != http.MethodGet || entry.Path != "/v1/things" {
+				t.Errorf("log entry = %+v", entry)
+			}
+			if entry.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", entry.Status, tt.wantStatus)
+			}
+			if entry.Bytes != tt.wantBytes {
+				t.Errorf("bytes = %d, want %d", entry.Bytes, tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+
+	h := app.recoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rr := executeRequest(h, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if got := rr.Header().Get("Connection"); got != "close" {
+		t.Errorf("Connection = %q, want close", got)
+	}
+
+	var body map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || body["error"] == "" {
+		t.Errorf("body = %s, want an error envelope", rr.Body)
+	}
+}
+
+func TestRecoverPanicRepanicsOnAbort(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+
+	h := app.recoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", rec)
+		}
+	}()
+	executeRequest(h, httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestAuthenticate(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", Role: store.RoleUser, IsActive: true}
+
+	newApp := func(t *testing.T) *application {
+		return newTestApplication(t, store.Storage{Users: &fakeUsersStore{getByID: usersByID(alice)}})
+	}
+
+	sign := func(t *testing.T, app *application, claims jwt.MapClaims) string {
+		t.Helper()
+		token, err := app.authenticator.GenerateToken(claims)
+		if err != nil {
+			t.Fatalf("GenerateToken() error = %v", err)
+		}
+		return token
+	}
+
+	claims := func(sub int64, exp time.Time) jwt.MapClaims {
+		return jwt.MapClaims{"sub": sub, "exp": exp.Unix(), "iss": "social", "aud": "social"}
+	}
+
+	tests := []struct {
+		name       string
+		header     func(t *testing.T, app *application) string
+		wantStatus int
+	}{
+		{
+			name: "valid token",
+			header: func(t *testing.T, app *application) string {
+				return "Bearer " + sign(t, app, claims(1, time.Now().Add(time.Minute)))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "expired token",
+			header: func(t *testing.T, app *application) string {
+				return "Bearer " + sign(t, app, claims(1, time.Now().Add(-time.Minute)))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered signature",
+			header: func(t *testing.T, app *application) string {
+				// Swap a character well inside the signature; the final
+				// one may only carry padding bits.
+				token := []byte(sign(t, app, claims(1, time.Now().Add(time.Minute))))
+				i := len(token) - 10
+				if token[i] == 'A' {
+					token[i] = 'B'
+				} else {
+					token[i] = 'A'
+				}
+				return "Bearer " + string(token)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "signed with another secret",
+			header: func(t *testing.T, app *application) string {
+				other := auth.NewJWTAuthenticator("another-secret", "social", "social")
+				token, err := other.GenerateToken(claims(1, time.Now().Add(time.Minute)))
+				if err != nil {
+					t.Fatalf("GenerateToken() error = %v", err)
+				}
+				return "Bearer " + token
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "unknown user",
+			header: func(t *testing.T, app *application) string {
+				return "Bearer " + sign(t, app, claims(2, time.Now().Add(time.Minute)))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing header",
+			header:     func(*testing.T, *application) string { return "" },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong scheme",
+			header:     func(*testing.T, *application) string { return "Basic dXNlcjpwYXNz" },
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newApp(t)
+
+			var gotUser *store.User
+			h := app.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser, _ = userFromContext(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if header := tt.header(t, app); header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rr := executeRequest(h, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if tt.wantStatus == http.StatusOK && gotUser != alice {
+				t.Errorf("user in context = %v, want alice", gotUser)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *store.User
+		role       string
+		wantStatus int
+	}{
+		{name: "admin on admin route", user: &store.User{ID: 1, Role: store.RoleAdmin}, role: store.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "admin on moderator route", user: &store.User{ID: 1, Role: store.RoleAdmin}, role: store.RoleModerator, wantStatus: http.StatusOK},
+		{name: "moderator on admin route", user: &store.User{ID: 1, Role: store.RoleModerator}, role: store.RoleAdmin, wantStatus: http.StatusForbidden},
+		{name: "user on admin route", user: &store.User{ID: 1, Role: store.RoleUser}, role: store.RoleAdmin, wantStatus: http.StatusForbidden},
+		{name: "anonymous", role: store.RoleUser, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{})
+			h := app.requireRole(tt.role)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.user != nil {
+				req = req.WithContext(contextWithUser(req.Context(), tt.user))
+			}
+			rr := executeRequest(h, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	const limit = 16
+
+	tests := []struct {
+		name        string
+		body        string
+		chunked     bool
+		wantStatus  int
+		wantHandled bool
+	}{
+		{name: "within limit", body: strings.Repeat("a", limit), wantStatus: http.StatusOK, wantHandled: true},
+		{name: "declared too large", body: strings.Repeat("a", limit+1), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "chunked too large", body: strings.Repeat("a", limit+1), chunked: true, wantStatus: http.StatusRequestEntityTooLarge, wantHandled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{})
+
+			handled := false
+			h := app.maxBodyBytes(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handled = true
+				if _, err := io.ReadAll(r.Body); err != nil {
+					app.badRequestResponse(w, r, fmt.Errorf("%w: %w", errBodyTooLarge, err))
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rr := executeRequest(h, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if handled != tt.wantHandled {
+				t.Errorf("handler ran = %v, want %v", handled, tt.wantHandled)
+			}
+		})
+	}
+}
+
+func TestMaxBodyBytesOnRoutes(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+	app.config.maxRequestBytes = 64
+
+	body := `{"username":"alice","email":"alice@example.com","password":"` + strings.Repeat("x", 64) + `"}`
+	rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(body)))
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+
+	t.Run("slow handler is cut off", func(t *testing.T) {
+		cancelled := make(chan error, 1)
+		slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			cancelled <- r.Context().Err()
+			w.Write([]byte("too late"))
+		})
+
+		rr := httptest.NewRecorder()
+		app.requestTimeout(20*time.Millisecond)(slow).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+		}
+		if got := rr.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		if got := strings.TrimSpace(rr.Body.String()); got != `{"error":"request timed out"}` {
+			t.Errorf("body = %s", got)
+		}
+
+		select {
+		case err := <-cancelled:
+			if err != context.DeadlineExceeded {
+				t.Errorf("handler context error = %v, want DeadlineExceeded", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("handler context was not cancelled")
+		}
+	})
+
+	t.Run("fast handler is untouched", func(t *testing.T) {
+		fast := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusCreated, map[string]string{"ok": "yes"})
+		})
+
+		rr := httptest.NewRecorder()
+		app.requestTimeout(time.Second)(fast).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"ok":"yes"`) {
+			t.Errorf("status = %d, body = %s; want the handler's response", rr.Code, rr.Body)
+		}
+	})
+
+	t.Run("metrics are exempt", func(t *testing.T) {
+		app := newTestApplication(t, store.Storage{})
+		app.config.requestTimeout = time.Nanosecond
+
+		rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusOK)
+		}
+	})
+}
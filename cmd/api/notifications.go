@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+type notificationsResponse struct {
+	Data       []store.Notification `json:"data"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+func (app *application) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	fq, err := app.parseFeedQuery(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	var onlyUnread bool
+	if unread := r.URL.Query().Get("unread"); unread != "" {
+		onlyUnread, err = strconv.ParseBool(unread)
+		if err != nil {
+			app.badRequestResponse(w, r, errors.New("unread must be a boolean"))
+			return
+		}
+	}
+
+	notifications, err := app.store.Notifications.ListForUser(r.Context(), user.ID, onlyUnread, fq)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	resp := notificationsResponse{Data: notifications}
+	if len(notifications) == fq.Limit {
+		last := notifications[len(notifications)-1]
+		resp.NextCursor = store.EncodeCursor(store.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) markNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	id, err := readIDParam(r, "id")
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := app.store.Notifications.MarkRead(r.Context(), user.ID, id); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rissabekov-wes/social/internal/buildinfo"
+)
+
+//go:embed docs/index.html
+var docsPage []byte
+
+// apiOperation is the hand-written part of a route's documentation. The set
+// of paths and methods itself comes from the router, so a route can never be
+// missing from the spec; it can only be missing its description, which is
+// logged when the spec is first built.
+type apiOperation struct {
+	summary string
+	status  int
+	auth    bool
+}
+
+// apiOperations documents every route by "METHOD path" as chi reports it,
+// without the trailing slash on collection routes.
+var apiOperations = map[string]apiOperation{
+	"GET /healthz": {summary: "Report service and dependency health, version and uptime", status: http.StatusOK},
+	"GET /livez":   {summary: "Liveness probe", status: http.StatusOK},
+	"GET /readyz":  {summary: "Readiness probe; fails while draining", status: http.StatusOK},
+	"GET /version": {summary: "Report version, commit, build time and Go version", status: http.StatusOK},
+
+	"GET /v1/health": {summary: "Report service status and version", status: http.StatusOK},
+
+	"POST /v1/users":                     {summary: "Register a user and send an activation email", status: http.StatusCreated},
+	"PUT /v1/users/activate/{token}":     {summary: "Activate a user with the emailed token", status: http.StatusNoContent},
+	"POST /v1/users/activate/resend":     {summary: "Email a fresh activation link; always answers the same", status: http.StatusAccepted},
+	"GET /v1/users/feed":                 {summary: "List posts from the caller and the users they follow", status: http.StatusOK, auth: true},
+	"DELETE /v1/users/me":                {summary: "Delete the caller's account and sign out every session", status: http.StatusNoContent, auth: true},
+	"GET /v1/users/me":                   {summary: "Get the authenticated user", status: http.StatusOK, auth: true},
+	"GET /v1/users/me/export":            {summary: "Download the caller's data as JSON, or as zipped CSVs with format=csv", status: http.StatusOK, auth: true},
+	"POST /v1/users/me/avatar":           {summary: "Upload a JPEG or PNG avatar", status: http.StatusOK, auth: true},
+	"POST /v1/users/me/following":        {summary: "Follow up to 100 users by ID", status: http.StatusOK, auth: true},
+	"GET /v1/users/{username}":           {summary: "Get a user's public profile", status: http.StatusOK},
+	"GET /v1/users/{username}/posts":     {summary: "List a user's posts, newest first", status: http.StatusOK},
+	"POST /v1/users/{username}/follow":   {summary: "Follow a user", status: http.StatusNoContent, auth: true},
+	"DELETE /v1/users/{username}/follow": {summary: "Unfollow a user", status: http.StatusNoContent, auth: true},
+	"POST /v1/users/{id}/block":          {summary: "Block a user, hiding their posts and removing follows", status: http.StatusNoContent, auth: true},
+	"DELETE /v1/users/{id}/block":        {summary: "Unblock a user", status: http.StatusNoContent, auth: true},
+
+	"GET /v1/posts":                    {summary: "List posts, optionally filtered by tag", status: http.StatusOK},
+	"POST /v1/posts":                   {summary: "Create a post; supports Idempotency-Key", status: http.StatusCreated, auth: true},
+	"GET /v1/posts/search":             {summary: "Full-text search over posts", status: http.StatusOK},
+	"GET /v1/posts/{id}":               {summary: "Get a post with its first comments", status: http.StatusOK},
+	"PATCH /v1/posts/{id}":             {summary: "Partially update a post owned by the caller", status: http.StatusOK, auth: true},
+	"PUT /v1/posts/{id}":               {summary: "Update a post owned by the caller", status: http.StatusOK, auth: true},
+	"DELETE /v1/posts/{id}":            {summary: "Delete a post owned by the caller", status: http.StatusNoContent, auth: true},
+	"GET /v1/posts/{id}/comments":      {summary: "List a post's comments as a reply thread", status: http.StatusOK},
+	"POST /v1/posts/{id}/comments":     {summary: "Comment on a post, or reply to a comment with parent_id", status: http.StatusCreated, auth: true},
+	"POST /v1/posts/{id}/like":         {summary: "Like a post", status: http.StatusOK, auth: true},
+	"DELETE /v1/posts/{id}/like":       {summary: "Remove a like from a post", status: http.StatusOK, auth: true},
+	"GET /v1/tags/trending":            {summary: "List the most used tags over the recent window", status: http.StatusOK},
+	"DELETE /v1/comments/{id}":         {summary: "Delete a comment owned by the caller", status: http.StatusNoContent, auth: true},
+	"GET /v1/notifications":            {summary: "List the caller's notifications", status: http.StatusOK, auth: true},
+	"POST /v1/notifications/{id}/read": {summary: "Mark a notification as read", status: http.StatusNoContent, auth: true},
+	"POST /v1/admin/webhooks":          {summary: "Register a webhook subscriber (admin only); returns its signing secret", status: http.StatusCreated, auth: true},
+	"GET /v1/admin/users":              {summary: "List users (admin only)", status: http.StatusOK, auth: true},
+	"POST /v1/auth/forgot-password":    {summary: "Email a password reset link; always answers the same", status: http.StatusAccepted},
+	"POST /v1/auth/reset-password":     {summary: "Set a new password with an emailed reset token", status: http.StatusNoContent},
+	"POST /v1/auth/token":              {summary: "Exchange credentials for an access and a refresh token", status: http.StatusCreated},
+	"POST /v1/auth/refresh":            {summary: "Rotate a refresh token for a new token pair", status: http.StatusOK},
+	"POST /v1/auth/logout":             {summary: "Revoke a refresh token", status: http.StatusNoContent},
+}
+
+// undocumentedRoutes are registered but deliberately left out of the spec.
+var undocumentedRoutes = map[string]bool{
+	"/metrics":      true,
+	"/openapi.json": true,
+	"/docs":         true,
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+type openAPIParameter struct {
+	Name     string            `json:"name"`
+	In       string            `json:"in"`
+	Required bool              `json:"required"`
+	Schema   map[string]string `json:"schema"`
+}
+
+type openAPIResponse struct {
+	Description string `json:"description"`
+}
+
+type openAPIOperation struct {
+	Summary    string                     `json:"summary,omitempty"`
+	Tags       []string                   `json:"tags,omitempty"`
+	Parameters []openAPIParameter         `json:"parameters,omitempty"`
+	Security   []map[string][]string      `json:"security,omitempty"`
+	Responses  map[string]openAPIResponse `json:"responses"`
+}
+
+// buildOpenAPISpec walks routes and renders an OpenAPI 3 document for every
+// registered endpoint, along with the keys of routes that have no entry in
+// apiOperations.
+func buildOpenAPISpec(routes chi.Routes) ([]byte, []string, error) {
+	paths := map[string]map[string]openAPIOperation{}
+	var missing []string
+
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if strings.HasSuffix(route, "/*") {
+			return nil
+		}
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		if undocumentedRoutes[route] {
+			return nil
+		}
+
+		key := method + " " + route
+		doc, ok := apiOperations[key]
+		if !ok {
+			missing = append(missing, key)
+			doc.status = http.StatusOK
+		}
+
+		op := openAPIOperation{
+			Summary: doc.summary,
+			Responses: map[string]openAPIResponse{
+				strconv.Itoa(doc.status): {Description: http.StatusText(doc.status)},
+				"default":                {Description: "Error envelope: {\"error\": ...}"},
+			},
+		}
+		if tag := openAPITag(route); tag != "" {
+			op.Tags = []string{tag}
+		}
+		for _, m := range pathParamPattern.FindAllStringSubmatch(route, -1) {
+			op.Parameters = append(op.Parameters, openAPIParameter{
+				Name:     m[1],
+				In:       "path",
+				Required: true,
+				Schema:   map[string]string{"type": "string"},
+			})
+		}
+		if doc.auth {
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+		}
+
+		path := pathParamPattern.ReplaceAllString(route, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]openAPIOperation{}
+		}
+		paths[path][strings.ToLower(method)] = op
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Strings(missing)
+
+	spec := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   "Social API",
+			"version": buildinfo.Version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]string{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(spec)
+	return data, missing, err
+}
+
+// openAPITag groups operations by the first path segment after the version
+// prefix, e.g. "posts" for /v1/posts/{id}. Unversioned routes are probes.
+func openAPITag(route string) string {
+	route, ok := strings.CutPrefix(route, apiVersionPrefix)
+	if !ok {
+		return "operations"
+	}
+	segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+	if segment == "" || strings.HasPrefix(segment, "{") {
+		return ""
+	}
+	return segment
+}
+
+// openAPIHandler serves the spec for routes. It is built on first request,
+// once every route has been registered.
+func (app *application) openAPIHandler(routes chi.Routes) http.HandlerFunc {
+	var (
+		once sync.Once
+		spec []byte
+		err  error
+	)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var missing []string
+			spec, missing, err = buildOpenAPISpec(routes)
+			if len(missing) > 0 {
+				app.logger.Warn("routes missing from the OpenAPI docs", "routes", missing)
+			}
+		})
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(spec)
+	}
+}
+
+func (app *application) docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(docsPage)
+}
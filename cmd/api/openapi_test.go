@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestOpenAPISpecListsRegisteredRoutes(t *testing.T) {
+	mux := newTestApplication(t, store.Storage{}).mount()
+
+	rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want a 3.x document", spec.OpenAPI)
+	}
+
+	registered := 0
+	err := chi.Walk(mux.(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if strings.HasSuffix(route, "/*") {
+			return nil
+		}
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		if undocumentedRoutes[route] {
+			return nil
+		}
+
+		registered++
+		if _, ok := spec.Paths[route][strings.ToLower(method)]; !ok {
+			t.Errorf("%s %s is registered but missing from the spec", method, route)
+		}
+		if _, ok := apiOperations[method+" "+route]; !ok {
+			t.Errorf("%s %s has no entry in apiOperations", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if registered != len(apiOperations) {
+		t.Errorf("%d routes registered but %d documented; remove stale apiOperations entries", registered, len(apiOperations))
+	}
+	for _, path := range []string{"/v1/users", "/v1/posts/{id}", "/v1/users/feed", "/v1/auth/token"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("spec is missing %s", path)
+		}
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	mux := newTestApplication(t, store.Storage{}).mount()
+
+	rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", got)
+	}
+	if !strings.Contains(rr.Body.String(), "/openapi.json") {
+		t.Error("docs page does not load /openapi.json")
+	}
+}
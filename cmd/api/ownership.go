@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+// resourceLoader fetches the resource named by the {id} URL parameter and
+// reports who owns it.
+type resourceLoader func(ctx context.Context, id int64) (resource any, ownerID int64, err error)
+
+// requireOwnership loads the resource once, lets it through only for its
+// owner or a moderator and above, and stores it in the request context under
+// key so the handler does not fetch it again. It must run after authenticate.
+func (app *application) requireOwnership(key contextKey, load resourceLoader) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := userFromContext(r)
+			if !ok {
+				app.unauthorizedResponse(w, r, errUnauthenticated)
+				return
+			}
+
+			id, err := readIDParam(r, "id")
+			if err != nil {
+				app.handleError(w, r, err)
+				return
+			}
+
+			resource, ownerID, err := load(r.Context(), id)
+			if err != nil {
+				app.handleError(w, r, err)
+				return
+			}
+
+			if ownerID != user.ID && !user.HasRole(store.RoleModerator) {
+				app.forbiddenResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), key, resource)))
+		})
+	}
+}
+
+func (app *application) loadPost(ctx context.Context, id int64) (any, int64, error) {
+	post, err := app.store.Posts.GetByIDPrimary(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return post, post.UserID, nil
+}
+
+func (app *application) loadComment(ctx context.Context, id int64) (any, int64, error) {
+	comment, err := app.store.Comments.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return comment, comment.UserID, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestRequireOwnershipDeletePost(t *testing.T) {
+	var (
+		owner     = &store.User{ID: 1, Username: "alice", IsActive: true, Role: store.RoleUser}
+		other     = &store.User{ID: 2, Username: "bob", IsActive: true, Role: store.RoleUser}
+		moderator = &store.User{ID: 3, Username: "mod", IsActive: true, Role: store.RoleModerator}
+		admin     = &store.User{ID: 4, Username: "root", IsActive: true, Role: store.RoleAdmin}
+	)
+
+	tests := []struct {
+		name       string
+		user       *store.User
+		postID     string
+		wantStatus int
+	}{
+		{name: "owner", user: owner, postID: "5", wantStatus: http.StatusNoContent},
+		{name: "non-owner", user: other, postID: "5", wantStatus: http.StatusForbidden},
+		{name: "moderator", user: moderator, postID: "5", wantStatus: http.StatusNoContent},
+		{name: "admin", user: admin, postID: "5", wantStatus: http.StatusNoContent},
+		{name: "missing post", user: owner, postID: "6", wantStatus: http.StatusNotFound},
+		{name: "anonymous", postID: "5", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loads := 0
+			var deleted []int64
+			app := newTestApplication(t, store.Storage{
+				Users: &fakeUsersStore{getByID: usersByID(owner, other, moderator, admin)},
+				Posts: &fakePostsStore{
+					getByID: func(_ context.Context, id int64) (*store.Post, error) {
+						loads++
+						if id != 5 {
+							return nil, store.ErrNotFound
+						}
+						return &store.Post{ID: 5, UserID: owner.ID, Title: "mine"}, nil
+					},
+					delete: func(_ context.Context, id int64) error {
+						deleted = append(deleted, id)
+						return nil
+					},
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodDelete, "/v1/posts/"+tt.postID, nil)
+			if tt.user != nil {
+				authorize(t, app, req, tt.user.ID)
+			}
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+
+			wantDeleted := tt.wantStatus == http.StatusNoContent
+			if wantDeleted != (len(deleted) == 1 && deleted[0] == 5) {
+				t.Errorf("deleted = %v, want post 5 deleted: %v", deleted, wantDeleted)
+			}
+			if tt.user != nil && loads != 1 {
+				t.Errorf("post loaded %d times, want once", loads)
+			}
+		})
+	}
+}
+
+// laggingUsersStore and laggingPostsStore stand in for a replica that has
+// not caught up: GetByID finds nothing, while the primary has the row.
+type laggingUsersStore struct{ *fakeUsersStore }
+
+func (laggingUsersStore) GetByID(context.Context, int64) (*store.User, error) {
+	return nil, store.ErrNotFound
+}
+
+type laggingPostsStore struct{ *fakePostsStore }
+
+func (laggingPostsStore) GetByID(context.Context, int64) (*store.Post, error) {
+	return nil, store.ErrNotFound
+}
+
+func TestWritesReadFromPrimary(t *testing.T) {
+	owner := &store.User{ID: 1, Username: "alice", IsActive: true, Role: store.RoleUser}
+	post := &store.Post{ID: 5, UserID: owner.ID, Title: "mine", Content: "fresh", Version: 3}
+
+	var patchedVersion int
+	app := newTestApplication(t, store.Storage{
+		Users: laggingUsersStore{&fakeUsersStore{getByID: usersByID(owner)}},
+		Posts: laggingPostsStore{&fakePostsStore{
+			getByID: func(context.Context, int64) (*store.Post, error) {
+				p := *post
+				return &p, nil
+			},
+			patch: func(_ context.Context, id int64, fields store.PostPatch) (*store.Post, error) {
+				patchedVersion = fields.Version
+				p := *post
+				p.Version++
+				return &p, nil
+			},
+			delete: func(context.Context, int64) error { return nil },
+		}},
+	})
+	mux := app.mount()
+
+	req := httptest.NewRequest(http.MethodPatch, "/v1/posts/5", strings.NewReader(`{"title":"edited"}`))
+	authorize(t, app, req, owner.ID)
+	if rr := executeRequest(mux, req); rr.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	if patchedVersion != post.Version {
+		t.Errorf("patch guarded by version %d, want the primary's %d", patchedVersion, post.Version)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1/posts/5", nil)
+	authorize(t, app, req, owner.ID)
+	if rr := executeRequest(mux, req); rr.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want %d; body %s", rr.Code, http.StatusNoContent, rr.Body)
+	}
+}
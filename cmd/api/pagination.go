@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+type paginationConfig struct {
+	defaultLimit int
+	maxLimit     int
+}
+
+// parsePagination reads the limit and offset query parameters used by every
+// list endpoint. A missing limit defaults to the configured page size and one
+// above the maximum is clamped to it; non-numeric values, a limit below one
+// and a negative offset are rejected. Keyset-paginated endpoints simply
+// ignore the offset.
+func (app *application) parsePagination(r *http.Request) (limit, offset int, err error) {
+	qs := r.URL.Query()
+
+	limit = app.config.pagination.defaultLimit
+	if l := qs.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 {
+			return 0, 0, errors.New("limit must be a positive integer")
+		}
+	}
+	limit = min(limit, app.config.pagination.maxLimit)
+
+	if o := qs.Get("offset"); o != "" {
+		offset, err = strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+	}
+
+	return limit, offset, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantLimit  int
+		wantOffset int
+		wantErr    string
+	}{
+		{name: "defaults", wantLimit: 10},
+		{name: "explicit", query: "?limit=5&offset=40", wantLimit: 5, wantOffset: 40},
+		{name: "at max", query: "?limit=50", wantLimit: 50},
+		{name: "clamped to max", query: "?limit=51", wantLimit: 50},
+		{name: "far above max", query: "?limit=1000000", wantLimit: 50},
+		{name: "empty values use defaults", query: "?limit=&offset=", wantLimit: 10},
+		{name: "zero limit", query: "?limit=0", wantErr: "limit must be a positive integer"},
+		{name: "negative limit", query: "?limit=-1", wantErr: "limit must be a positive integer"},
+		{name: "non-numeric limit", query: "?limit=ten", wantErr: "limit must be a positive integer"},
+		{name: "fractional limit", query: "?limit=1.5", wantErr: "limit must be a positive integer"},
+		{name: "negative offset", query: "?offset=-1", wantErr: "offset must be a non-negative integer"},
+		{name: "non-numeric offset", query: "?offset=abc", wantErr: "offset must be a non-negative integer"},
+	}
+
+	app := newTestApplication(t, store.Storage{})
+	// Not the defaults of 20 and 100, so the test shows the configured
+	// values are the ones applied.
+	app.config.pagination = paginationConfig{defaultLimit: 10, maxLimit: 50}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, offset, err := app.parsePagination(httptest.NewRequest(http.MethodGet, "/"+tt.query, nil))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parsePagination(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || limit != tt.wantLimit || offset != tt.wantOffset {
+				t.Errorf("parsePagination(%q) = %d, %d, %v; want %d, %d", tt.query, limit, offset, err, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestListEndpointsRejectInvalidPagination(t *testing.T) {
+	mux := newTestApplication(t, store.Storage{}).mount()
+
+	// Each list endpoint checks its parameters before touching the store,
+	// which is empty here.
+	for _, path := range []string{"/v1/posts?", "/v1/posts/search?q=go&"} {
+		for _, query := range []string{"limit=abc", "limit=-5", "offset=-1"} {
+			rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, path+query, nil))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("GET %s%s status = %d, want %d", path, query, rr.Code, http.StatusBadRequest)
+			}
+		}
+	}
+}
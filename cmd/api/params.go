@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var errInvalidID = errors.New("invalid id")
+
+// readIDParam parses the named URL parameter as a positive int64. Any other
+// value yields an error wrapping errInvalidID.
+func readIDParam(r *http.Request, name string) (int64, error) {
+	raw := chi.URLParam(r, name)
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("%w: %s must be a positive integer", errInvalidID, name)
+	}
+
+	return id, nil
+}
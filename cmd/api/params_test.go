@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestReadIDParam(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int64
+		wantErr bool
+	}{
+		{raw: "42", want: 42},
+		{raw: "9223372036854775807", want: 9223372036854775807},
+		{raw: "abc", wantErr: true},
+		{raw: "0", wantErr: true},
+		{raw: "-5", wantErr: true},
+		{raw: "1.5", wantErr: true},
+		{raw: "9223372036854775808", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.raw)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+			got, err := readIDParam(r, "id")
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidID) {
+					t.Errorf("readIDParam(%q) error = %v, want errInvalidID", tt.raw, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("readIDParam(%q) = %d, %v; want %d", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidIDRespondsBadRequest(t *testing.T) {
+	mux := newTestApplication(t, store.Storage{}).mount()
+
+	for _, id := range []string{"abc", "0", "-5"} {
+		rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/v1/posts/"+id, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("GET /v1/posts/%s status = %d, want %d", id, rr.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/store"
+	"github.com/rissabekov-wes/social/internal/webhook"
+)
+
+type CreatePostPayload struct {
+	Title   string   `json:"title" validate:"required,max=100"`
+	Content string   `json:"content" validate:"required,max=1000"`
+	Tags    []string `json:"tags" validate:"max=10,dive,max=30"`
+}
+
+// createPostHandler publishes a post on behalf of the authenticated user. The
+// author always comes from the auth context, never from the payload.
+func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	var payload CreatePostPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if !app.moderatePost(w, r, payload.Title, payload.Content, payload.Tags) {
+		return
+	}
+
+	post := &store.Post{
+		Title:   payload.Title,
+		Content: payload.Content,
+		Tags:    normalizeTags(payload.Tags),
+		UserID:  user.ID,
+	}
+
+	if err := app.store.Posts.Create(r.Context(), post); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+	app.feeds.InvalidateAuthor(r.Context(), user.ID)
+	app.dispatchWebhooks(webhook.EventPostCreated, *post)
+
+	w.Header().Set("Location", apiVersionPrefix+"/posts/"+strconv.FormatInt(post.ID, 10))
+	if err := writeJSON(w, http.StatusCreated, post); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// moderatePost runs the post's text through the moderation filter. It writes
+// the rejection response itself and reports whether the handler may go on.
+func (app *application) moderatePost(w http.ResponseWriter, r *http.Request, title, content string, tags []string) bool {
+	text := strings.Join(append([]string{title, content}, tags...), "\n")
+
+	allowed, reason, err := app.moderator.Check(r.Context(), text)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return false
+	}
+	if !allowed {
+		app.contentRejectedResponse(w, r, reason)
+		return false
+	}
+
+	return true
+}
+
+// normalizeTags lowercases and trims tags, dropping blanks and duplicates so
+// that tag filters match regardless of how the author typed them.
+func normalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return out
+}
+
+type listPostsResponse struct {
+	Data  []store.Post `json:"data"`
+	Total int          `json:"total"`
+}
+
+func (app *application) listPostsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := app.parsePostFilter(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	posts, total, err := app.store.Posts.List(r.Context(), filter)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, listPostsResponse{Data: posts, Total: total}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) searchPostsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	q := strings.TrimSpace(qs.Get("q"))
+	if q == "" {
+		app.badRequestResponse(w, r, errors.New("q must be provided"))
+		return
+	}
+
+	limit, _, err := app.parsePagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	fq := store.FeedQuery{Limit: limit, Sort: "desc"}
+
+	posts, err := app.store.Posts.Search(r.Context(), q, fq)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, posts); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) parsePostFilter(r *http.Request) (store.PostFilter, error) {
+	qs := r.URL.Query()
+
+	limit, offset, err := app.parsePagination(r)
+	if err != nil {
+		return store.PostFilter{}, err
+	}
+
+	filter := store.PostFilter{
+		Tag:    strings.ToLower(strings.TrimSpace(qs.Get("tag"))),
+		Search: qs.Get("search"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if since := qs.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return filter, errors.New("since must be an RFC 3339 timestamp")
+		}
+		filter.Since = &t
+	}
+
+	return filter, nil
+}
+
+func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := readIDParam(r, "id")
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	post, err := app.store.Posts.GetWithDetails(r.Context(), postID)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSONWithETag(w, r, post); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type UpdatePostPayload struct {
+	Title   string   `json:"title" validate:"required,max=100"`
+	Content string   `json:"content" validate:"required,max=1000"`
+	Tags    []string `json:"tags" validate:"max=10,dive,max=30"`
+}
+
+// updatePostHandler replaces the post loaded by requireOwnership. The update
+// is guarded by the version read at load time, so a concurrent edit yields
+// 409 rather than being silently overwritten.
+func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request) {
+	post := postFromContext(r)
+
+	var payload UpdatePostPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if !app.moderatePost(w, r, payload.Title, payload.Content, payload.Tags) {
+		return
+	}
+
+	post.Title = payload.Title
+	post.Content = payload.Content
+	post.Tags = normalizeTags(payload.Tags)
+
+	if err := app.store.Posts.Update(r.Context(), post); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+	app.feeds.InvalidateAuthor(r.Context(), post.UserID)
+
+	if err := writeJSON(w, http.StatusOK, post); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// PatchPostPayload is a partial post update: omitted fields are left as they
+// are. Title and content cannot be cleared, so an explicit null for either is
+// rejected; a null tags list clears the tags.
+type PatchPostPayload struct {
+	Title   *string   `json:"title" validate:"omitempty,min=1,max=100"`
+	Content *string   `json:"content" validate:"omitempty,min=1,max=1000"`
+	Tags    *[]string `json:"tags" validate:"omitempty,max=10,dive,max=30"`
+}
+
+func (p *PatchPostPayload) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, field := range []string{"title", "content"} {
+		if v, ok := raw[field]; ok && string(v) == "null" {
+			return fmt.Errorf("body contains null for non-nullable field %q", field)
+		}
+	}
+
+	type plain PatchPostPayload
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode((*plain)(p)); err != nil {
+		return err
+	}
+
+	if v, ok := raw["tags"]; ok && string(v) == "null" {
+		p.Tags = &[]string{}
+	}
+	return nil
+}
+
+// patchPostHandler partially updates the post loaded by requireOwnership,
+// guarded by its version like updatePostHandler.
+func (app *application) patchPostHandler(w http.ResponseWriter, r *http.Request) {
+	post := postFromContext(r)
+
+	var payload PatchPostPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	fields := store.PostPatch{
+		Title:   payload.Title,
+		Content: payload.Content,
+		Version: post.Version,
+	}
+	if payload.Tags != nil {
+		tags := normalizeTags(*payload.Tags)
+		fields.Tags = &tags
+	}
+
+	title, content, tags := post.Title, post.Content, post.Tags
+	if fields.Title != nil {
+		title = *fields.Title
+	}
+	if fields.Content != nil {
+		content = *fields.Content
+	}
+	if fields.Tags != nil {
+		tags = *fields.Tags
+	}
+	if !app.moderatePost(w, r, title, content, tags) {
+		return
+	}
+
+	updated, err := app.store.Posts.Patch(r.Context(), post.ID, fields)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+	app.feeds.InvalidateAuthor(r.Context(), post.UserID)
+
+	if err := writeJSON(w, http.StatusOK, updated); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deletePostHandler hard-deletes the post loaded by requireOwnership along
+// with its comments and likes.
+func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request) {
+	post := postFromContext(r)
+
+	if err := app.store.Posts.Delete(r.Context(), post.ID); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+	app.feeds.InvalidateAuthor(r.Context(), post.UserID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
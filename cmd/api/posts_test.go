@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/moderation"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestListPostsHandlerFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       store.PostFilter
+	}{
+		{name: "defaults", query: "", wantStatus: http.StatusOK, want: store.PostFilter{Limit: 20}},
+		{name: "tag is normalised", query: "?tag=%20GoLang%20", wantStatus: http.StatusOK, want: store.PostFilter{Tag: "golang", Limit: 20}},
+		{name: "search and offset", query: "?search=hello&offset=40&limit=10", wantStatus: http.StatusOK, want: store.PostFilter{Search: "hello", Limit: 10, Offset: 40}},
+		{name: "limit clamped to the maximum", query: "?limit=500", wantStatus: http.StatusOK, want: store.PostFilter{Limit: 100}},
+		{name: "limit below one", query: "?limit=0", wantStatus: http.StatusBadRequest},
+		{name: "bad since", query: "?since=yesterday", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got store.PostFilter
+			app := newTestApplication(t, store.Storage{
+				Posts: &fakePostsStore{list: func(_ context.Context, filter store.PostFilter) ([]store.Post, int, error) {
+					got = filter
+					return []store.Post{{ID: 1, Title: "hello"}}, 41, nil
+				}},
+			})
+
+			rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/v1/posts"+tt.query, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got.Tag != tt.want.Tag || got.Search != tt.want.Search || got.Limit != tt.want.Limit || got.Offset != tt.want.Offset || got.Since != nil {
+				t.Errorf("filter = %+v, want %+v", got, tt.want)
+			}
+
+			var body listPostsResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Total != 41 || len(body.Data) != 1 {
+				t.Errorf("body = %+v, want one post and a total of 41", body)
+			}
+		})
+	}
+}
+
+func TestCreatePostModeration(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantReason string
+	}{
+		{
+			name:       "clean",
+			body:       `{"title":"Hello","content":"a friendly post","tags":["intro"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "banned word in content",
+			body:       `{"title":"Hello","content":"great spam here"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantReason: `content contains a banned word: "spam"`,
+		},
+		{
+			name:       "banned word in a tag",
+			body:       `{"title":"Hello","content":"a friendly post","tags":["Spam"]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantReason: `content contains a banned word: "spam"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := false
+			app := newTestApplication(t, store.Storage{
+				Users: &fakeUsersStore{getByID: usersByID(alice)},
+				Posts: &fakePostsStore{create: func(_ context.Context, post *store.Post) error {
+					created = true
+					post.ID = 10
+					return nil
+				}},
+				Webhooks: &fakeWebhooksStore{},
+			})
+			app.moderator = moderation.NewWordFilter([]string{"spam"})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(tt.body))
+			authorize(t, app, req, alice.ID)
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if created != (tt.wantStatus == http.StatusCreated) {
+				t.Errorf("post stored = %v, want %v", created, tt.wantStatus == http.StatusCreated)
+			}
+			if tt.wantReason == "" {
+				return
+			}
+
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error != tt.wantReason {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantReason)
+			}
+		})
+	}
+}
+
+// postDetails is a getWithDetails implementation serving post 11 with two
+// comments.
+func postDetails(_ context.Context, id int64) (*store.PostDetails, error) {
+	if id != 11 {
+		return nil, store.ErrNotFound
+	}
+
+	post := &store.PostDetails{
+		Comments: []store.Comment{
+			{ID: 2, PostID: 11, UserID: 4, Username: "bob", Content: "second"},
+			{ID: 1, PostID: 11, UserID: 4, Username: "bob", Content: "first"},
+		},
+	}
+	post.ID = 11
+	post.UserID = 3
+	post.Title = "Hello"
+	post.Content = "hello world"
+	post.Version = 1
+	post.LikesCount = 5
+	post.Username = "alice"
+	post.CommentsCount = 2
+	return post, nil
+}
+
+func TestGetPostHandler(t *testing.T) {
+	app := newTestApplication(t, store.Storage{Posts: &fakePostsStore{getWithDetails: postDetails}})
+	mux := app.mount()
+
+	t.Run("found", func(t *testing.T) {
+		rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/v1/posts/11", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+		}
+
+		var body store.PostDetails
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.ID != 11 || body.Username != "alice" || body.LikesCount != 5 || body.CommentsCount != 2 {
+			t.Errorf("body = %+v", body)
+		}
+		if len(body.Comments) != 2 || body.Comments[0].Content != "second" || body.HasMoreComments {
+			t.Errorf("comments = %+v (more: %v), want both comments and no more", body.Comments, body.HasMoreComments)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/v1/posts/12", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusNotFound)
+		}
+	})
+}
+
+func TestCreatePostHandler(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+
+	var stored *store.Post
+	app := newTestApplication(t, store.Storage{
+		Users: &fakeUsersStore{getByID: usersByID(alice)},
+		Posts: &fakePostsStore{create: func(_ context.Context, post *store.Post) error {
+			post.ID = 10
+			stored = post
+			return nil
+		}},
+		Webhooks: &fakeWebhooksStore{},
+	})
+	mux := app.mount()
+
+	t.Run("created for the authenticated user", func(t *testing.T) {
+		stored = nil
+		body := `{"title":"Hello","content":"first post","tags":["intro","go"]}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(body))
+		authorize(t, app, req, alice.ID)
+		rr := executeRequest(mux, req)
+
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusCreated, rr.Body)
+		}
+		if stored == nil || stored.UserID != alice.ID {
+			t.Fatalf("stored post = %+v, want one by user %d", stored, alice.ID)
+		}
+
+		var got store.Post
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != 10 || got.UserID != alice.ID || got.Title != "Hello" || got.Content != "first post" || len(got.Tags) != 2 {
+			t.Errorf("response = %+v", got)
+		}
+	})
+
+	t.Run("anonymous rejected", func(t *testing.T) {
+		stored = nil
+		req := httptest.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(`{"title":"Hello","content":"first post"}`))
+		rr := executeRequest(mux, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusUnauthorized)
+		}
+		if stored != nil {
+			t.Errorf("anonymous post was stored: %+v", stored)
+		}
+	})
+
+	for _, tt := range []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "missing title", body: `{"content":"no title"}`, wantStatus: http.StatusUnprocessableEntity},
+		// The author only ever comes from the token.
+		{name: "author in body", body: `{"title":"Hello","content":"x","user_id":2}`, wantStatus: http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stored = nil
+			req := httptest.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(tt.body))
+			authorize(t, app, req, alice.ID)
+			rr := executeRequest(mux, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if stored != nil {
+				t.Errorf("rejected post was stored: %+v", stored)
+			}
+		})
+	}
+}
+
+func TestPatchPostHandler(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+	original := store.Post{ID: 5, UserID: alice.ID, Title: "Hello", Content: "original content", Tags: []string{"go"}, Version: 2}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantPatch  bool
+	}{
+		{name: "title only", body: `{"title":"Renamed"}`, wantStatus: http.StatusOK, wantPatch: true},
+		{name: "null title", body: `{"title":null}`, wantStatus: http.StatusBadRequest},
+		{name: "null content", body: `{"content":null}`, wantStatus: http.StatusBadRequest},
+		{name: "empty title", body: `{"title":""}`, wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *store.PostPatch
+			app := newTestApplication(t, store.Storage{
+				Users: &fakeUsersStore{getByID: usersByID(alice)},
+				Posts: &fakePostsStore{
+					getByID: func(context.Context, int64) (*store.Post, error) {
+						post := original
+						return &post, nil
+					},
+					patch: func(_ context.Context, id int64, fields store.PostPatch) (*store.Post, error) {
+						got = &fields
+						post := original
+						post.Title = *fields.Title
+						post.Version++
+						return &post, nil
+					},
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodPatch, "/v1/posts/5", strings.NewReader(tt.body))
+			authorize(t, app, req, alice.ID)
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if !tt.wantPatch {
+				if got != nil {
+					t.Errorf("rejected patch reached the store: %+v", got)
+				}
+				return
+			}
+
+			if got.Title == nil || *got.Title != "Renamed" || got.Content != nil || got.Tags != nil || got.Version != original.Version {
+				t.Errorf("patch = %+v, want only the title at version %d", got, original.Version)
+			}
+
+			var body store.Post
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Title != "Renamed" || body.Content != original.Content {
+				t.Errorf("response = %+v, want the new title and the original content", body)
+			}
+		})
+	}
+}
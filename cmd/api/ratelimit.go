@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	rateLimiterCleanupInterval = time.Minute
+	rateLimiterClientTTL       = 3 * time.Minute
+)
+
+type rateLimiterConfig struct {
+	enabled bool
+	rps     float64
+	burst   int
+}
+
+type rateLimitClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type ipRateLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*rateLimitClient
+	rps     rate.Limit
+	burst   int
+}
+
+func newIPRateLimiter(rps float64, burst int) *ipRateLimiter {
+	rl := &ipRateLimiter{
+		clients: make(map[string]*rateLimitClient),
+		rps:     rate.Limit(rps),
+		burst:   burst,
+	}
+
+	go rl.cleanup()
+
+	return rl
+}
+
+// allow reports whether a request from ip may proceed, and if not, how long
+// the client should wait before retrying.
+func (rl *ipRateLimiter) allow(ip string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	c, ok := rl.clients[ip]
+	if !ok {
+		c = &rateLimitClient{limiter: rate.NewLimiter(rl.rps, rl.burst)}
+		rl.clients[ip] = c
+	}
+	c.lastSeen = time.Now()
+
+	res := c.limiter.Reserve()
+	if !res.OK() {
+		return false, time.Second
+	}
+	if delay := res.Delay(); delay > 0 {
+		res.Cancel()
+		return false, delay
+	}
+
+	return true, 0
+}
+
+func (rl *ipRateLimiter) cleanup() {
+	ticker := time.NewTicker(rateLimiterCleanupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rl.mu.Lock()
+		for ip, c := range rl.clients {
+			if time.Since(c.lastSeen) > rateLimiterClientTTL {
+				delete(rl.clients, ip)
+			}
+		}
+		rl.mu.Unlock()
+	}
+}
+
+func (app *application) rateLimit(next http.Handler) http.Handler {
+	if !app.config.rateLimiter.enabled {
+		return next
+	}
+
+	limiter := newIPRateLimiter(app.config.rateLimiter.rps, app.config.rateLimiter.burst)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := limiter.allow(remoteHost(r)); !ok {
+			app.rateLimitExceededResponse(w, r, retryAfter)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
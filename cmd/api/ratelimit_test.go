@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestIPRateLimiterConcurrent(t *testing.T) {
+	const burst = 10
+	rl := newIPRateLimiter(0.001, burst)
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int32
+	)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := rl.allow("192.0.2.10"); ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != burst {
+		t.Errorf("%d concurrent requests allowed, want exactly the burst of %d", got, burst)
+	}
+
+	if ok, _ := rl.allow("192.0.2.11"); !ok {
+		t.Error("a different IP was limited by another client's bucket")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+	app.config.rateLimiter = rateLimiterConfig{enabled: true, rps: 0.001, burst: 1}
+	_, proxy, _ := net.ParseCIDR("10.0.0.1/32")
+	app.config.trustedProxies = []net.IPNet{*proxy}
+
+	h := app.realIP(app.rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	request := func(remoteAddr, xff string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		return executeRequest(h, req)
+	}
+
+	if rr := request("10.0.0.1:1234", "203.0.113.1"); rr.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", rr.Code)
+	}
+
+	rr := request("10.0.0.1:1234", "203.0.113.1")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("429 without a Retry-After header")
+	}
+
+	// Behind the same proxy, another forwarded client has its own bucket.
+	if rr := request("10.0.0.1:1234", "203.0.113.2"); rr.Code != http.StatusOK {
+		t.Errorf("other forwarded client status = %d, want 200", rr.Code)
+	}
+
+	// An untrusted peer cannot borrow a fresh bucket by forging the header.
+	if rr := request("198.51.100.7:1234", ""); rr.Code != http.StatusOK {
+		t.Fatalf("direct client status = %d, want 200", rr.Code)
+	}
+	if rr := request("198.51.100.7:1234", "203.0.113.99"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("spoofed X-Forwarded-For status = %d, want 429", rr.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	app := newTestApplication(t, store.Storage{})
+	app.config.rateLimiter = rateLimiterConfig{enabled: false, rps: 0.001, burst: 1}
+
+	h := app.rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 5; i++ {
+		if rr := executeRequest(h, httptest.NewRequest(http.MethodGet, "/", nil)); rr.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200 with limiting off", i, rr.Code)
+		}
+	}
+}
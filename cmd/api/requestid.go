@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDCtxKey = contextKey("request_id")
+
+	// maxRequestIDLength bounds client-supplied IDs so they cannot bloat
+	// every log line for the request.
+	maxRequestIDLength = 128
+)
+
+// requestID reuses the caller's X-Request-ID when present, otherwise it
+// generates a UUID. The ID is echoed on the response and stored in the
+// request context for logging.
+func (app *application) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDCtxKey, id)))
+	})
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey).(string)
+	return id
+}
+
+// requestIDHandler decorates slog records with the request ID carried by the
+// context, so any *Context logging call made while serving a request is
+// correlated without threading the ID by hand.
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func newRequestIDHandler(h slog.Handler) *requestIDHandler {
+	return &requestIDHandler{Handler: h}
+}
+
+func (h *requestIDHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if id := requestIDFromContext(ctx); id != "" {
+		rec.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h *requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &requestIDHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *requestIDHandler) WithGroup(name string) slog.Handler {
+	return &requestIDHandler{Handler: h.Handler.WithGroup(name)}
+}
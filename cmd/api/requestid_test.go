@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		wantSame bool
+	}{
+		{name: "provided", header: "abc-123", wantSame: true},
+		{name: "missing"},
+		{name: "too long", header: strings.Repeat("x", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{})
+
+			var seen string
+			h := app.requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = requestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(requestIDHeader, tt.header)
+			}
+			rr := executeRequest(h, req)
+
+			echoed := rr.Header().Get(requestIDHeader)
+			if echoed != seen {
+				t.Errorf("response ID %q differs from context ID %q", echoed, seen)
+			}
+			if tt.wantSame {
+				if seen != tt.header {
+					t.Errorf("request ID = %q, want %q", seen, tt.header)
+				}
+				return
+			}
+			if _, err := uuid.Parse(seen); err != nil {
+				t.Errorf("generated request ID %q is not a UUID: %v", seen, err)
+			}
+		})
+	}
+}
+
+func TestRequestIDHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newRequestIDHandler(slog.NewJSONHandler(&buf, nil))).With("component", "test")
+
+	ctx := context.WithValue(context.Background(), requestIDCtxKey, "abc-123")
+	logger.InfoContext(ctx, "with id")
+	logger.InfoContext(context.Background(), "without id")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2", len(lines))
+	}
+
+	for i, want := range []string{"abc-123", ""} {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := entry["request_id"].(string)
+		if got != want {
+			t.Errorf("line %d request_id = %q, want %q", i, got, want)
+		}
+		if entry["component"] != "test" {
+			t.Errorf("line %d lost the logger's attributes: %s", i, lines[i])
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+type trendingTagsResponse struct {
+	Window string           `json:"window"`
+	Data   []store.TagCount `json:"data"`
+}
+
+// trendingTagsHandler lists the most used tags over the configured window.
+// The ranking changes slowly, so results are cached for a short while and
+// shared between all callers.
+func (app *application) trendingTagsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > 50 {
+			app.badRequestResponse(w, r, errors.New("limit must be an integer between 1 and 50"))
+			return
+		}
+		limit = n
+	}
+
+	window := app.config.tags.trendingWindow
+	key := "tags:trending:" + window.String() + ":" + strconv.Itoa(limit)
+
+	var tags []store.TagCount
+	if data, ok, err := app.cache.Get(r.Context(), key); err != nil {
+		app.logger.WarnContext(r.Context(), "trending tags cache read failed", "error", err)
+	} else if ok && json.Unmarshal(data, &tags) == nil {
+		app.writeTrendingTags(w, r, window, tags)
+		return
+	}
+
+	tags, err := app.store.Tags.Trending(r.Context(), time.Now().Add(-window), limit)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if data, err := json.Marshal(tags); err == nil {
+		if err := app.cache.Set(r.Context(), key, data, app.config.tags.cacheTTL); err != nil {
+			app.logger.WarnContext(r.Context(), "trending tags cache write failed", "error", err)
+		}
+	}
+
+	app.writeTrendingTags(w, r, window, tags)
+}
+
+func (app *application) writeTrendingTags(w http.ResponseWriter, r *http.Request, window time.Duration, tags []store.TagCount) {
+	if err := writeJSON(w, http.StatusOK, trendingTagsResponse{Window: window.String(), Data: tags}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
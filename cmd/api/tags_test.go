@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/cache"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+type fakeTagsStore struct {
+	calls int
+	since time.Time
+	limit int
+}
+
+func (f *fakeTagsStore) Trending(_ context.Context, since time.Time, limit int) ([]store.TagCount, error) {
+	f.calls++
+	f.since, f.limit = since, limit
+	return []store.TagCount{{Tag: "go", Count: 3}, {Tag: "sql", Count: 2}}, nil
+}
+
+func TestTrendingTagsHandler(t *testing.T) {
+	tags := &fakeTagsStore{}
+	app := newTestApplication(t, store.Storage{Tags: tags})
+	app.cache = cache.NewMemoryStore()
+	app.config.tags = tagsConfig{trendingWindow: 24 * time.Hour, cacheTTL: time.Minute}
+	mux := app.mount()
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		return executeRequest(mux, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rr := get(t, "/v1/tags/trending?limit=2")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	var resp trendingTagsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Window != "24h0m0s" || len(resp.Data) != 2 || resp.Data[0].Tag != "go" {
+		t.Errorf("response = %+v", resp)
+	}
+	if tags.limit != 2 || time.Since(tags.since) < 24*time.Hour-time.Minute {
+		t.Errorf("store queried with limit %d since %v, want 2 over the last day", tags.limit, tags.since)
+	}
+
+	get(t, "/v1/tags/trending?limit=2")
+	if tags.calls != 1 {
+		t.Errorf("store queried %d times, want the repeat served from cache", tags.calls)
+	}
+	get(t, "/v1/tags/trending")
+	if tags.calls != 2 || tags.limit != 10 {
+		t.Errorf("default limit: %d calls with limit %d, want a new query with limit 10", tags.calls, tags.limit)
+	}
+
+	for _, limit := range []string{"0", "51", "ten"} {
+		if rr := get(t, "/v1/tags/trending?limit="+limit); rr.Code != http.StatusBadRequest {
+			t.Errorf("limit=%s status = %d, want %d", limit, rr.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/auth"
+	"github.com/rissabekov-wes/social/internal/cache"
+	appconfig "github.com/rissabekov-wes/social/internal/config"
+	"github.com/rissabekov-wes/social/internal/mailer"
+	"github.com/rissabekov-wes/social/internal/moderation"
+	"github.com/rissabekov-wes/social/internal/store"
+	"github.com/rissabekov-wes/social/internal/webhook"
+	"github.com/rissabekov-wes/social/internal/worker"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testJWTSecret = "test-secret"
+
+// testPasswordCost keeps hashing cheap; the production cost only slows the
+// tests down.
+const testPasswordCost = bcrypt.MinCost
+
+// testConfig mirrors the defaults main reads from the environment, minus
+// anything that needs a network or the filesystem.
+func testConfig() config {
+	return config{
+		env:              appconfig.Environment{EnvName: "test"},
+		maxRequestBytes:  1_048_576,
+		maxCommentDepth:  1,
+		compressMinBytes: 1024,
+		requestTimeout:   5 * time.Second,
+		exportTimeout:    time.Minute,
+		idempotencyTTL:   time.Hour,
+		deleteMode:       store.DeleteAnonymize,
+		pagination:       paginationConfig{defaultLimit: 20, maxLimit: 100},
+		mail:             mailConfig{frontendURL: "http://localhost:5173", sendTimeout: time.Second},
+		blob:             blobConfig{maxAvatarBytes: 512 * 1024},
+		auth: authConfig{
+			token: tokenConfig{
+				secret:     testJWTSecret,
+				ttl:        15 * time.Minute,
+				refreshTTL: time.Hour,
+				iss:        "social",
+			},
+			bcryptCost:       testPasswordCost,
+			invitationTTL:    time.Hour,
+			resendInterval:   time.Minute,
+			passwordResetTTL: time.Hour,
+		},
+		webhooks: webhookConfig{attempts: 1, timeout: time.Second},
+	}
+}
+
+// newTestApplication returns an application wired to storage with quiet
+// logging, a no-op cache and mailer, and a worker pool that is drained when
+// the test ends. Tests adjust app.config before calling mount.
+func newTestApplication(t *testing.T, storage store.Storage) *application {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := testConfig()
+
+	dummyHash, err := newDummyPasswordHash(cfg.auth.bcryptCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workers := worker.NewPool(2, 16, logger)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = workers.Shutdown(ctx)
+	})
+
+	return &application{
+		config:        cfg,
+		store:         storage,
+		logger:        logger,
+		authenticator: auth.NewJWTAuthenticator(cfg.auth.token.secret, cfg.auth.token.iss, cfg.auth.token.iss),
+		metrics:       newMetrics(),
+		mailer:        mailer.NoopMailer{},
+		moderator:     moderation.NewWordFilter(nil),
+		workers:       workers,
+		cache:         cache.NoopStore{},
+		feeds:         newFeedCache(cache.NoopStore{}, 0, storage, logger),
+		webhooks:      webhook.NewDispatcher(webhook.Config{Attempts: 1, Timeout: time.Second}),
+		startedAt:     time.Now(),
+
+		dummyPasswordHash: dummyHash,
+	}
+}
+
+// executeRequest serves req through h and returns the recorded response.
+func executeRequest(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+// fakeUsersStore implements store.UsersStore with the methods a test sets;
+// calling any other method panics on the nil embedded interface.
+type fakeUsersStore struct {
+	store.UsersStore
+
+	createAndInvite func(ctx context.Context, user *store.User, token string, exp time.Duration) error
+	getByID         func(ctx context.Context, id int64) (*store.User, error)
+	getByEmail      func(ctx context.Context, email string) (*store.User, error)
+	getByUsername   func(ctx context.Context, username string) (*store.User, error)
+	getProfile      func(ctx context.Context, username string) (*store.UserProfile, error)
+	list            func(ctx context.Context, limit, offset int, sort string) ([]store.User, int, error)
+	activate        func(ctx context.Context, token string) error
+	setAvatarURL    func(ctx context.Context, id int64, url string) error
+
+	reissueInvitation   func(ctx context.Context, email, token string, exp, minInterval time.Duration) (*store.User, error)
+	createPasswordReset func(ctx context.Context, email, token string, exp time.Duration) (*store.User, error)
+	resetPassword       func(ctx context.Context, token, password string) error
+	delete              func(ctx context.Context, id int64, mode store.DeleteMode) error
+}
+
+func (f *fakeUsersStore) Delete(ctx context.Context, id int64, mode store.DeleteMode) error {
+	return f.delete(ctx, id, mode)
+}
+
+func (f *fakeUsersStore) CreatePasswordReset(ctx context.Context, email, token string, exp time.Duration) (*store.User, error) {
+	return f.createPasswordReset(ctx, email, token, exp)
+}
+
+func (f *fakeUsersStore) ResetPassword(ctx context.Context, token, password string) error {
+	return f.resetPassword(ctx, token, password)
+}
+
+func (f *fakeUsersStore) ReissueInvitation(ctx context.Context, email, token string, exp, minInterval time.Duration) (*store.User, error) {
+	return f.reissueInvitation(ctx, email, token, exp, minInterval)
+}
+
+func (f *fakeUsersStore) CreateAndInvite(ctx context.Context, user *store.User, token string, exp time.Duration) error {
+	return f.createAndInvite(ctx, user, token, exp)
+}
+
+func (f *fakeUsersStore) GetByID(ctx context.Context, id int64) (*store.User, error) {
+	return f.getByID(ctx, id)
+}
+
+// GetByIDPrimary serves getByID too; the fake has no replica to lag.
+func (f *fakeUsersStore) GetByIDPrimary(ctx context.Context, id int64) (*store.User, error) {
+	return f.getByID(ctx, id)
+}
+
+func (f *fakeUsersStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
+	return f.getByEmail(ctx, email)
+}
+
+func (f *fakeUsersStore) GetByUsername(ctx context.Context, username string) (*store.User, error) {
+	return f.getByUsername(ctx, username)
+}
+
+func (f *fakeUsersStore) GetProfile(ctx context.Context, username string) (*store.UserProfile, error) {
+	return f.getProfile(ctx, username)
+}
+
+func (f *fakeUsersStore) List(ctx context.Context, limit, offset int, sort string) ([]store.User, int, error) {
+	return f.list(ctx, limit, offset, sort)
+}
+
+func (f *fakeUsersStore) Activate(ctx context.Context, token string) error {
+	return f.activate(ctx, token)
+}
+
+func (f *fakeUsersStore) SetAvatarURL(ctx context.Context, id int64, url string) error {
+	return f.setAvatarURL(ctx, id, url)
+}
+
+// fakeRefreshTokensStore implements store.RefreshTokensStore with the
+// methods a test sets.
+type fakeRefreshTokensStore struct {
+	store.RefreshTokensStore
+
+	create func(ctx context.Context, userID int64, token string, ttl time.Duration) error
+	rotate func(ctx context.Context, token, newToken string, ttl time.Duration) (int64, error)
+	revoke func(ctx context.Context, token string) error
+}
+
+func (f *fakeRefreshTokensStore) Create(ctx context.Context, userID int64, token string, ttl time.Duration) error {
+	return f.create(ctx, userID, token, ttl)
+}
+
+func (f *fakeRefreshTokensStore) Rotate(ctx context.Context, token, newToken string, ttl time.Duration) (int64, error) {
+	return f.rotate(ctx, token, newToken, ttl)
+}
+
+func (f *fakeRefreshTokensStore) Revoke(ctx context.Context, token string) error {
+	return f.revoke(ctx, token)
+}
+
+// usersByID is a getByID implementation serving the given users.
+func usersByID(users ...*store.User) func(context.Context, int64) (*store.User, error) {
+	return func(_ context.Context, id int64) (*store.User, error) {
+		for _, u := range users {
+			if u.ID == id {
+				return u, nil
+			}
+		}
+		return nil, store.ErrNotFound
+	}
+}
+
+// authorize sets a Bearer access token for userID on req.
+func authorize(t *testing.T, app *application, req *http.Request, userID int64) {
+	t.Helper()
+
+	token, err := app.generateAccessToken(userID)
+	if err != nil {
+		t.Fatalf("generateAccessToken() error = %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+// fakePostsStore implements store.PostsStore with the methods a test sets.
+type fakePostsStore struct {
+	store.PostsStore
+
+	create         func(ctx context.Context, post *store.Post) error
+	getByID        func(ctx context.Context, id int64) (*store.Post, error)
+	getWithDetails func(ctx context.Context, id int64) (*store.PostDetails, error)
+	list           func(ctx context.Context, filter store.PostFilter) ([]store.Post, int, error)
+	delete         func(ctx context.Context, id int64) error
+	getUserFeed    func(ctx context.Context, userID int64, fq store.FeedQuery) ([]store.PostWithMetadata, error)
+	update         func(ctx context.Context, post *store.Post) error
+	patch          func(ctx context.Context, id int64, fields store.PostPatch) (*store.Post, error)
+	getByUser      func(ctx context.Context, userID int64, fq store.FeedQuery) ([]store.PostWithMetadata, error)
+}
+
+func (f *fakePostsStore) GetByUser(ctx context.Context, userID int64, fq store.FeedQuery) ([]store.PostWithMetadata, error) {
+	return f.getByUser(ctx, userID, fq)
+}
+
+func (f *fakePostsStore) Update(ctx context.Context, post *store.Post) error {
+	return f.update(ctx, post)
+}
+
+func (f *fakePostsStore) Patch(ctx context.Context, id int64, fields store.PostPatch) (*store.Post, error) {
+	return f.patch(ctx, id, fields)
+}
+
+func (f *fakePostsStore) GetUserFeed(ctx context.Context, userID int64, fq store.FeedQuery) ([]store.PostWithMetadata, error) {
+	return f.getUserFeed(ctx, userID, fq)
+}
+
+func (f *fakePostsStore) GetByID(ctx context.Context, id int64) (*store.Post, error) {
+	return f.getByID(ctx, id)
+}
+
+// GetByIDPrimary serves getByID too; the fake has no replica to lag.
+func (f *fakePostsStore) GetByIDPrimary(ctx context.Context, id int64) (*store.Post, error) {
+	return f.getByID(ctx, id)
+}
+
+func (f *fakePostsStore) Delete(ctx context.Context, id int64) error {
+	return f.delete(ctx, id)
+}
+
+func (f *fakePostsStore) GetWithDetails(ctx context.Context, id int64) (*store.PostDetails, error) {
+	return f.getWithDetails(ctx, id)
+}
+
+func (f *fakePostsStore) Create(ctx context.Context, post *store.Post) error {
+	return f.create(ctx, post)
+}
+
+func (f *fakePostsStore) List(ctx context.Context, filter store.PostFilter) ([]store.Post, int, error) {
+	return f.list(ctx, filter)
+}
+
+// fakeExportStore implements store.ExportStore with export.
+type fakeExportStore struct {
+	export func(ctx context.Context, userID int64, sink store.ExportSink) error
+}
+
+func (f *fakeExportStore) Export(ctx context.Context, userID int64, sink store.ExportSink) error {
+	return f.export(ctx, userID, sink)
+}
+
+// sentEmail is one Send call recorded by recordingMailer.
+type sentEmail struct {
+	to       string
+	template string
+	data     any
+}
+
+// recordingMailer delivers every email to a channel so tests can wait for
+// the background send.
+type recordingMailer struct {
+	sent chan sentEmail
+}
+
+func newRecordingMailer() *recordingMailer {
+	return &recordingMailer{sent: make(chan sentEmail, 16)}
+}
+
+func (m *recordingMailer) Send(to, templateName string, data any) error {
+	m.sent <- sentEmail{to: to, template: templateName, data: data}
+	return nil
+}
+
+// next waits for the next email, failing the test if none arrives.
+func (m *recordingMailer) next(t *testing.T) sentEmail {
+	t.Helper()
+
+	select {
+	case email := <-m.sent:
+		return email
+	case <-time.After(time.Second):
+		t.Fatal("no email was sent")
+		return sentEmail{}
+	}
+}
+
+// fakeWebhooksStore implements store.WebhooksStore with the methods a test
+// sets. A nil listForEvent means no webhooks are registered.
+type fakeWebhooksStore struct {
+	store.WebhooksStore
+
+	listForEvent  func(ctx context.Context, event string) ([]store.Webhook, error)
+	recordFailure func(ctx context.Context, f *store.WebhookFailure) error
+}
+
+func (f *fakeWebhooksStore) ListForEvent(ctx context.Context, event string) ([]store.Webhook, error) {
+	if f.listForEvent == nil {
+		return nil, nil
+	}
+	return f.listForEvent(ctx, event)
+}
+
+func (f *fakeWebhooksStore) RecordFailure(ctx context.Context, failure *store.WebhookFailure) error {
+	return f.recordFailure(ctx, failure)
+}
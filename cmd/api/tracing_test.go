@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rissabekov-wes/social/internal/store"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanExporter installs an in-memory exporter as the global tracer provider.
+// It is done once per process because tracers obtained before the first
+// SetTracerProvider call, like the store's, only follow that first provider.
+var spanExporter = sync.OnceValue(func() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	return exporter
+})
+
+func TestSetupTracingWithoutEndpoint(t *testing.T) {
+	shutdown, err := setupTracing(context.Background(), tracingConfig{serviceName: "social"})
+	if err != nil {
+		t.Fatalf("setupTracing() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+}
+
+func TestTraceSpansStoreQueries(t *testing.T) {
+	exporter := spanExporter()
+	exporter.Reset()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mock.ExpectQuery(`FROM posts p`).WillReturnError(sql.ErrNoRows)
+
+	app := newTestApplication(t, store.NewStorage(db, nil, time.Second, testPasswordCost))
+	rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/v1/posts/7", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	root, ok := spans["GET /v1/posts/{id}"]
+	if !ok {
+		t.Fatalf("no request span among %v", spanNames(exporter.GetSpans()))
+	}
+	if root.Parent.IsValid() {
+		t.Errorf("request span has parent %v, want none", root.Parent.SpanID())
+	}
+
+	child, ok := spans["store.Posts.GetWithDetails"]
+	if !ok {
+		t.Fatalf("no store span among %v", spanNames(exporter.GetSpans()))
+	}
+	if child.Parent.SpanID() != root.SpanContext.SpanID() {
+		t.Errorf("store span parent = %v, want request span %v", child.Parent.SpanID(), root.SpanContext.SpanID())
+	}
+	if child.SpanContext.TraceID() != root.SpanContext.TraceID() {
+		t.Errorf("store span trace = %v, want %v", child.SpanContext.TraceID(), root.SpanContext.TraceID())
+	}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	return names
+}
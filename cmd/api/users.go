@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rissabekov-wes/social/internal/mailer"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+type RegisterUserPayload struct {
+	Username string `json:"username" validate:"required,min=3,max=30,username"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RegisterUserPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	user := &store.User{
+		Username: payload.Username,
+		Email:    payload.Email,
+		Password: payload.Password,
+	}
+
+	// The plain token is only ever handed to the user; the store keeps a hash.
+	token := uuid.NewString()
+	if err := app.store.Users.CreateAndInvite(r.Context(), user, token, app.config.auth.invitationTTL); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	app.sendEmail(user.Email, mailer.UserInvitationTemplate, map[string]string{
+		"Username":      user.Username,
+		"ActivationURL": app.config.mail.frontendURL + "/confirm/" + token,
+	})
+
+	if err := writeJSON(w, http.StatusCreated, user); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	if err := app.store.Users.Activate(r.Context(), token); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type ResendActivationPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// resendActivationHandler issues a fresh activation token, invalidating any
+// earlier one, and emails it. The response is the same whether or not the
+// email belongs to an inactive account, and whether or not the account is
+// being throttled, so it cannot be used to discover registered addresses.
+func (app *application) resendActivationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResendActivationPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	token := uuid.NewString()
+	user, err := app.store.Users.ReissueInvitation(r.Context(), payload.Email, token, app.config.auth.invitationTTL, app.config.auth.resendInterval)
+	switch {
+	case err == nil:
+		app.sendEmail(user.Email, mailer.UserInvitationTemplate, map[string]string{
+			"Username":      user.Username,
+			"ActivationURL": app.config.mail.frontendURL + "/confirm/" + token,
+		})
+	case errors.Is(err, store.ErrNotFound):
+	case errors.Is(err, store.ErrInvitationThrottled):
+		app.logger.InfoContext(r.Context(), "activation resend throttled")
+	default:
+		app.handleError(w, r, err)
+		return
+	}
+
+	resp := map[string]string{"message": "if an inactive account exists for that email, an activation link has been sent"}
+	if err := writeJSON(w, http.StatusAccepted, resp); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deleteCurrentUserHandler closes the caller's account. DELETE_MODE decides
+// whether their content is removed with it or kept under "deleted user".
+// Refresh tokens are revoked with the account, and access tokens stop working
+// because authenticate no longer finds the user.
+func (app *application) deleteCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	// Followers are looked up now since the follow edges go with the
+	// account; a feed refilled in between expires with the cache TTL.
+	app.feeds.InvalidateAuthor(r.Context(), user.ID)
+
+	if err := app.store.Users.Delete(r.Context(), user.ID, app.config.deleteMode); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	app.logger.InfoContext(r.Context(), "account deleted", "user_id", user.ID, "mode", app.config.deleteMode)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) getUserProfileHandler(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+
+	profile, err := app.store.Users.GetProfile(r.Context(), username)
+	if err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, profile); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getCurrentUserHandler returns the user loaded by authenticate, so it costs
+// no extra query.
+func (app *application) getCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userFromContext(r)
+	if !ok {
+		app.unauthorizedResponse(w, r, errUnauthenticated)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, user); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/mailer"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestRegisterUserHandler(t *testing.T) {
+	created := func(ctx context.Context, user *store.User, token string, exp time.Duration) error {
+		user.ID = 1
+		user.Role = store.RoleUser
+		user.Password = "$2a$hash"
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		create     func(context.Context, *store.User, string, time.Duration) error
+		wantStatus int
+		wantFields []string
+	}{
+		{
+			name:       "created",
+			body:       `{"username":"alice","email":"alice@example.com","password":"correct horse"}`,
+			create:     created,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "empty fields",
+			body:       `{"username":"","email":"","password":""}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantFields: []string{"username", "email", "password"},
+		},
+		{
+			name:       "anonymized placeholder",
+			body:       `{"username":"deleted:1","email":"deleted:1","password":"correct horse"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantFields: []string{"username", "email"},
+		},
+		{
+			name:       "malformed JSON",
+			body:       `{"username":"alice",`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "duplicate email",
+			body: `{"username":"alice","email":"alice@example.com","password":"correct horse"}`,
+			create: func(context.Context, *store.User, string, time.Duration) error {
+				return store.ErrDuplicateEmail
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "duplicate username",
+			body: `{"username":"alice","email":"alice@example.com","password":"correct horse"}`,
+			create: func(context.Context, *store.User, string, time.Duration) error {
+				return store.ErrDuplicateUsername
+			},
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t, store.Storage{
+				Users: &fakeUsersStore{createAndInvite: tt.create},
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(tt.body))
+			rr := executeRequest(app.mount(), req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+
+			var body map[string]any
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+
+			if tt.wantStatus == http.StatusCreated {
+				if _, ok := body["password"]; ok {
+					t.Errorf("response exposes the password: %s", rr.Body)
+				}
+				if body["username"] != "alice" {
+					t.Errorf("username = %v, want alice", body["username"])
+				}
+			}
+
+			fields, _ := body["fields"].(map[string]any)
+			for _, field := range tt.wantFields {
+				if _, ok := fields[field]; !ok {
+					t.Errorf("no validation error for %q in %s", field, rr.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestGetUserProfileHandler(t *testing.T) {
+	profiles := func(_ context.Context, username string) (*store.UserProfile, error) {
+		if username != "alice" {
+			return nil, store.ErrNotFound
+		}
+		return &store.UserProfile{
+			ID:             7,
+			Username:       "alice",
+			CreatedAt:      "2024-01-01T00:00:00Z",
+			FollowersCount: 3,
+			FollowingCount: 1,
+			PostsCount:     2,
+		}, nil
+	}
+
+	t.Run("found", func(t *testing.T) {
+		app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{getProfile: profiles}})
+
+		rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/v1/users/alice", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+		}
+
+		var body map[string]any
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("response is not JSON: %v", err)
+		}
+		for field, want := range map[string]any{
+			"id":              float64(7),
+			"username":        "alice",
+			"followers_count": float64(3),
+			"following_count": float64(1),
+			"posts_count":     float64(2),
+		} {
+			if got := body[field]; got != want {
+				t.Errorf("%s = %v, want %v", field, got, want)
+			}
+		}
+		for _, field := range []string{"email", "password"} {
+			if _, ok := body[field]; ok {
+				t.Errorf("profile exposes %q", field)
+			}
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{getProfile: profiles}})
+
+		rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/v1/users/bob", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusNotFound)
+		}
+	})
+}
+
+func TestActivateUserHandler(t *testing.T) {
+	app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{
+		activate: func(_ context.Context, token string) error {
+			if token != "valid-token" {
+				return store.ErrNotFound
+			}
+			return nil
+		},
+	}})
+	mux := app.mount()
+
+	tests := []struct {
+		token      string
+		wantStatus int
+	}{
+		{token: "valid-token", wantStatus: http.StatusNoContent},
+		{token: "expired-or-unknown", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		rr := executeRequest(mux, httptest.NewRequest(http.MethodPut, "/v1/users/activate/"+tt.token, nil))
+		if rr.Code != tt.wantStatus {
+			t.Errorf("PUT activate/%s status = %d, want %d", tt.token, rr.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestRegisterUserHandlerSendsActivationEmail(t *testing.T) {
+	var token string
+	app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{
+		createAndInvite: func(_ context.Context, user *store.User, plain string, _ time.Duration) error {
+			user.ID = 1
+			token = plain
+			return nil
+		},
+	}})
+	sent := newRecordingMailer()
+	app.mailer = sent
+
+	body := `{"username":"alice","email":"alice@example.com","password":"correct horse"}`
+	rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusCreated)
+	}
+
+	email := sent.next(t)
+	if email.to != "alice@example.com" || email.template != mailer.UserInvitationTemplate {
+		t.Errorf("sent %q to %q, want the invitation to alice@example.com", email.template, email.to)
+	}
+	data, _ := email.data.(map[string]string)
+	if want := app.config.mail.frontendURL + "/confirm/" + token; token == "" || data["ActivationURL"] != want {
+		t.Errorf("ActivationURL = %q, want %q", data["ActivationURL"], want)
+	}
+}
+
+func TestGetCurrentUserHandler(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", Email: "alice@example.com", Password: "$2a$hash", Role: store.RoleUser, IsActive: true}
+	bob := &store.User{ID: 2, Username: "bob", Email: "bob@example.com", Password: "$2a$hash", Role: store.RoleModerator, IsActive: true}
+
+	lookups := 0
+	getByID := usersByID(alice, bob)
+	app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{
+		getByID: func(ctx context.Context, id int64) (*store.User, error) {
+			lookups++
+			return getByID(ctx, id)
+		},
+	}})
+	mux := app.mount()
+
+	t.Run("token subject", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/me", nil)
+		authorize(t, app, req, bob.ID)
+		rr := executeRequest(mux, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		var got map[string]any
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got["id"] != float64(bob.ID) || got["username"] != bob.Username || got["email"] != bob.Email || got["role"] != bob.Role {
+			t.Errorf("body = %v, want bob", got)
+		}
+		if _, ok := got["password"]; ok || strings.Contains(rr.Body.String(), bob.Password) {
+			t.Errorf("body exposes the password hash: %s", rr.Body)
+		}
+		// The user loaded by authenticate is reused.
+		if lookups != 1 {
+			t.Errorf("user looked up %d times, want once", lookups)
+		}
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		rr := executeRequest(mux, httptest.NewRequest(http.MethodGet, "/v1/users/me", nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusUnauthorized)
+		}
+	})
+}
+
+func TestResendActivationHandler(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", Email: "alice@example.com"}
+
+	// The fake keeps one pending token per account and throttles like the
+	// store does.
+	var tokens []string
+	lastSent := map[string]time.Time{}
+	app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{
+		reissueInvitation: func(_ context.Context, email, token string, _, minInterval time.Duration) (*store.User, error) {
+			if email != alice.Email {
+				return nil, store.ErrNotFound
+			}
+			if time.Since(lastSent[email]) < minInterval {
+				return nil, store.ErrInvitationThrottled
+			}
+			lastSent[email] = time.Now()
+			tokens = append(tokens, token)
+			return alice, nil
+		},
+	}})
+	sent := newRecordingMailer()
+	app.mailer = sent
+
+	resend := func(email string) (int, string) {
+		t.Helper()
+		body := `{"email":"` + email + `"}`
+		rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodPost, "/v1/users/activate/resend", strings.NewReader(body)))
+		return rr.Code, rr.Body.String()
+	}
+
+	status, first := resend(alice.Email)
+	if status != http.StatusAccepted {
+		t.Fatalf("first resend status = %d, want %d", status, http.StatusAccepted)
+	}
+
+	email := sent.next(t)
+	data, _ := email.data.(map[string]string)
+	if email.to != alice.Email || email.template != mailer.UserInvitationTemplate {
+		t.Errorf("sent %q to %q, want the invitation to %s", email.template, email.to, alice.Email)
+	}
+	if want := app.config.mail.frontendURL + "/confirm/" + tokens[0]; data["ActivationURL"] != want {
+		t.Errorf("ActivationURL = %q, want %q", data["ActivationURL"], want)
+	}
+
+	// Neither a throttled account nor an unknown email may be told apart
+	// from a successful resend.
+	for _, address := range []string{alice.Email, "nobody@example.com"} {
+		status, body := resend(address)
+		if status != http.StatusAccepted || body != first {
+			t.Errorf("resend to %s = %d %s, want the same response as the first resend", address, status, body)
+		}
+	}
+
+	if len(tokens) != 1 {
+		t.Errorf("tokens issued = %d, want 1 (the second attempt is throttled)", len(tokens))
+	}
+
+	// Draining the pool makes sure no email is still on its way.
+	if err := app.workers.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(sent.sent); n != 0 {
+		t.Errorf("%d more emails sent after the first, want none", n)
+	}
+}
+
+func TestDeleteCurrentUserHandler(t *testing.T) {
+	alice := &store.User{ID: 1, Username: "alice", IsActive: true}
+
+	for _, mode := range []store.DeleteMode{store.DeleteHard, store.DeleteAnonymize} {
+		t.Run(string(mode), func(t *testing.T) {
+			deleted := map[int64]store.DeleteMode{}
+			app := newTestApplication(t, store.Storage{Users: &fakeUsersStore{
+				getByID: func(ctx context.Context, id int64) (*store.User, error) {
+					if _, ok := deleted[id]; ok {
+						return nil, store.ErrNotFound
+					}
+					return usersByID(alice)(ctx, id)
+				},
+				delete: func(_ context.Context, id int64, mode store.DeleteMode) error {
+					deleted[id] = mode
+					return nil
+				},
+			}})
+			app.config.deleteMode = mode
+
+			req := httptest.NewRequest(http.MethodDelete, "/v1/users/me", nil)
+			authorize(t, app, req, alice.ID)
+			if rr := executeRequest(app.mount(), req); rr.Code != http.StatusNoContent {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusNoContent, rr.Body)
+			}
+			if got, ok := deleted[alice.ID]; !ok || got != mode {
+				t.Errorf("Delete() called with %v, want alice in mode %q", deleted, mode)
+			}
+
+			// The access token outlives the account but no longer works.
+			req = httptest.NewRequest(http.MethodGet, "/v1/users/me", nil)
+			authorize(t, app, req, alice.ID)
+			if rr := executeRequest(app.mount(), req); rr.Code != http.StatusUnauthorized {
+				t.Errorf("GET /v1/users/me after deletion status = %d, want %d", rr.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+
+	t.Run("anonymous", func(t *testing.T) {
+		app := newTestApplication(t, store.Storage{})
+		rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodDelete, "/v1/users/me", nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusUnauthorized)
+		}
+	})
+}
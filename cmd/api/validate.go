@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var Validate *validator.Validate
+
+// usernamePattern is the "username" rule. Anonymized accounts are renamed to
+// "deleted:<id>", which it keeps out of reach of registration.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func init() {
+	Validate = validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON name so clients see the keys they sent.
+	Validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	Validate.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernamePattern.MatchString(fl.Field().String())
+	})
+}
+
+func validationErrors(err error) map[string]string {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return map[string]string{"_": err.Error()}
+	}
+
+	fields := make(map[string]string, len(verrs))
+	for _, fe := range verrs {
+		fields[fe.Field()] = validationMessage(fe)
+	}
+	return fields
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "must be provided"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters long", fe.Param())
+	case "max":
+		return fmt.Sprintf("must not be more than %s characters long", fe.Param())
+	case "username":
+		return "may only contain letters, digits, '_', '.' and '-'"
+	default:
+		return fmt.Sprintf("failed on the %q rule", fe.Tag())
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidationErrors(t *testing.T) {
+	valid := RegisterUserPayload{Username: "alice", Email: "alice@example.com", Password: "correct horse"}
+
+	tests := []struct {
+		name    string
+		modify  func(*RegisterUserPayload)
+		field   string
+		message string
+	}{
+		{
+			name:    "required",
+			modify:  func(p *RegisterUserPayload) { p.Username = "" },
+			field:   "username",
+			message: "must be provided",
+		},
+		{
+			name:    "email",
+			modify:  func(p *RegisterUserPayload) { p.Email = "not-an-email" },
+			field:   "email",
+			message: "must be a valid email address",
+		},
+		{
+			name:    "min username",
+			modify:  func(p *RegisterUserPayload) { p.Username = "al" },
+			field:   "username",
+			message: "must be at least 3 characters long",
+		},
+		{
+			name:    "max username",
+			modify:  func(p *RegisterUserPayload) { p.Username = strings.Repeat("a", 31) },
+			field:   "username",
+			message: "must not be more than 30 characters long",
+		},
+		{
+			name:    "anonymized placeholder username",
+			modify:  func(p *RegisterUserPayload) { p.Username = "deleted:42" },
+			field:   "username",
+			message: "may only contain letters, digits, '_', '.' and '-'",
+		},
+		{
+			name:    "username with spaces",
+			modify:  func(p *RegisterUserPayload) { p.Username = "deleted user" },
+			field:   "username",
+			message: "may only contain letters, digits, '_', '.' and '-'",
+		},
+		{
+			name:    "anonymized placeholder email",
+			modify:  func(p *RegisterUserPayload) { p.Email = "deleted:42" },
+			field:   "email",
+			message: "must be a valid email address",
+		},
+		{
+			name:    "min password",
+			modify:  func(p *RegisterUserPayload) { p.Password = "short" },
+			field:   "password",
+			message: "must be at least 8 characters long",
+		},
+		{
+			name:    "max password",
+			modify:  func(p *RegisterUserPayload) { p.Password = strings.Repeat("a", 73) },
+			field:   "password",
+			message: "must not be more than 72 characters long",
+		},
+	}
+
+	if err := Validate.Struct(valid); err != nil {
+		t.Fatalf("Validate.Struct(valid) error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := valid
+			tt.modify(&payload)
+
+			err := Validate.Struct(payload)
+			if err == nil {
+				t.Fatal("Validate.Struct() error = nil")
+			}
+
+			fields := validationErrors(err)
+			if len(fields) != 1 {
+				t.Errorf("validationErrors() = %v, want only %q", fields, tt.field)
+			}
+			if got := fields[tt.field]; got != tt.message {
+				t.Errorf("fields[%q] = %q, want %q", tt.field, got, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsNonValidator(t *testing.T) {
+	fields := validationErrors(errors.New("boom"))
+	if fields["_"] != "boom" {
+		t.Errorf("validationErrors() = %v, want the error under %q", fields, "_")
+	}
+}
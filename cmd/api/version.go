@@ -0,0 +1,15 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/rissabekov-wes/social/internal/buildinfo"
+)
+
+// versionHandler reports what is running: the release version, the commit it
+// was built from, when it was built and with which Go toolchain.
+func (app *application) versionHandler(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSON(w, http.StatusOK, buildinfo.Get()); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
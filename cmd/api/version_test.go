@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/rissabekov-wes/social/internal/buildinfo"
+	"github.com/rissabekov-wes/social/internal/store"
+)
+
+func TestVersionHandler(t *testing.T) {
+	// Stand in for the values -ldflags would inject.
+	for v, val := range map[*string]string{
+		&buildinfo.Version:   "1.2.3",
+		&buildinfo.Commit:    "abc1234",
+		&buildinfo.BuildTime: "2026-01-02T03:04:05Z",
+	} {
+		old := *v
+		*v = val
+		t.Cleanup(func() { *v = old })
+	}
+
+	app := newTestApplication(t, store.Storage{})
+	rr := executeRequest(app.mount(), httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"version":    "1.2.3",
+		"commit":     "abc1234",
+		"build_time": "2026-01-02T03:04:05Z",
+		"go_version": runtime.Version(),
+	}
+	if len(got) != len(want) {
+		t.Errorf("body = %v, want exactly the fields %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
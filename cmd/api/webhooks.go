@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rissabekov-wes/social/internal/store"
+	"github.com/rissabekov-wes/social/internal/webhook"
+)
+
+// webhookEvent is the body POSTed to subscribers.
+type webhookEvent struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// dispatchWebhooks sends event to every webhook subscribed to it. Like
+// sendEmail it only queues work on the background pool, with each
+// subscriber delivered as its own task so a slow one does not hold up the
+// rest. Deliveries that still fail after the last retry are recorded.
+func (app *application) dispatchWebhooks(event string, data any) {
+	err := app.workers.Submit(func(ctx context.Context) {
+		hooks, err := app.store.Webhooks.ListForEvent(ctx, event)
+		if err != nil {
+			app.logger.Error("failed to load webhooks", "event", event, "error", err)
+			return
+		}
+		if len(hooks) == 0 {
+			return
+		}
+
+		deliveryID := uuid.NewString()
+		body, err := json.Marshal(webhookEvent{ID: deliveryID, Event: event, OccurredAt: time.Now().UTC(), Data: data})
+		if err != nil {
+			app.logger.Error("failed to encode webhook payload", "event", event, "error", err)
+			return
+		}
+
+		for _, hook := range hooks {
+			app.queueWebhookDelivery(hook, &webhook.Delivery{
+				URL:    hook.URL,
+				Secret: hook.Secret,
+				Event:  event,
+				ID:     deliveryID,
+				Body:   body,
+			})
+		}
+	})
+	if err != nil {
+		app.logger.Error("failed to queue webhooks", "event", event, "error", err)
+	}
+}
+
+// queueWebhookDelivery submits the next attempt of d to the pool. Once the
+// pool is shutting down nothing can be queued, and the delivery is recorded
+// as failed rather than dropped.
+func (app *application) queueWebhookDelivery(hook store.Webhook, d *webhook.Delivery) {
+	err := app.workers.Submit(func(ctx context.Context) { app.attemptWebhook(ctx, hook, d) })
+	if err != nil {
+		app.logger.Error("failed to queue webhook delivery", "webhook_id", hook.ID, "event", d.Event, "error", err)
+		app.recordWebhookFailure(hook, d, err)
+	}
+}
+
+// attemptWebhook makes one delivery attempt. A retry waits out its backoff
+// on a timer and is then queued again, so no worker is held in the
+// meantime.
+func (app *application) attemptWebhook(ctx context.Context, hook store.Webhook, d *webhook.Delivery) {
+	retry, wait, err := app.webhooks.Attempt(ctx, d)
+	if err == nil {
+		return
+	}
+
+	if retry {
+		app.webhookRetries.schedule(wait,
+			func() { app.queueWebhookDelivery(hook, d) },
+			func() { app.recordWebhookFailure(hook, d, err) },
+		)
+		return
+	}
+
+	app.logger.Warn("webhook delivery failed", "webhook_id", hook.ID, "event", d.Event, "attempts", d.Attempts, "status", d.StatusCode, "error", err)
+	app.recordWebhookFailure(hook, d, err)
+}
+
+// recordWebhookFailure stores d as undelivered. It runs outside any pool
+// task, or after the pool's context may have been cancelled, so it gets a
+// context of its own.
+func (app *application) recordWebhookFailure(hook store.Webhook, d *webhook.Delivery, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.webhooks.timeout)
+	defer cancel()
+
+	failure := &store.WebhookFailure{
+		WebhookID:  hook.ID,
+		Event:      d.Event,
+		Payload:    d.Body,
+		Attempts:   d.Attempts,
+		StatusCode: d.StatusCode,
+		Error:      cause.Error(),
+	}
+	if err := app.store.Webhooks.RecordFailure(ctx, failure); err != nil {
+		app.logger.Error("failed to record webhook failure", "webhook_id", hook.ID, "event", d.Event, "error", err)
+	}
+}
+
+// webhookRetries holds the deliveries waiting out their backoff. flush,
+// called at shutdown while the database is still open, gives up on them so
+// they are recorded as failed instead of vanishing with the process.
+type webhookRetries struct {
+	mu      sync.Mutex
+	flushed bool
+	pending map[*time.Timer]func()
+}
+
+// schedule runs retry after wait, unless flush comes first, in which case
+// giveUp runs instead. Exactly one of the two is called.
+func (q *webhookRetries) schedule(wait time.Duration, retry, giveUp func()) {
+	q.mu.Lock()
+	if q.flushed {
+		q.mu.Unlock()
+		giveUp()
+		return
+	}
+	if q.pending == nil {
+		q.pending = make(map[*time.Timer]func())
+	}
+
+	// The callback cannot take the lock before t is stored.
+	var t *time.Timer
+	t = time.AfterFunc(wait, func() {
+		q.mu.Lock()
+		_, ok := q.pending[t]
+		delete(q.pending, t)
+		q.mu.Unlock()
+
+		if ok {
+			retry()
+		}
+	})
+	q.pending[t] = giveUp
+	q.mu.Unlock()
+}
+
+// flush stops every pending retry and calls its giveUp.
+func (q *webhookRetries) flush() {
+	q.mu.Lock()
+	q.flushed = true
+	pending := q.pending
+	q.pending = nil
+	q.mu.Unlock()
+
+	for t, giveUp := range pending {
+		t.Stop()
+		giveUp()
+	}
+}
+
+type CreateWebhookPayload struct {
+	URL    string   `json:"url" validate:"required,http_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=post.created"`
+}
+
+// createWebhookResponse is the only time the signing secret is returned.
+type createWebhookResponse struct {
+	store.Webhook
+	Secret string `json:"secret"`
+}
+
+// adminCreateWebhookHandler registers a subscriber and generates the secret
+// its deliveries are signed with.
+func (app *application) adminCreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateWebhookPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	hook := &store.Webhook{
+		URL:    payload.URL,
+		Secret: hex.EncodeToString(secret),
+		Events: payload.Events,
+	}
+	if err := app.store.Webhooks.Create(r.Context(), hook); err != nil {
+		app.handleError(w, r, err)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, createWebhookResponse{Webhook: *hook, Secret: hook.Secret}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/store"
+	"github.com/rissabekov-wes/social/internal/webhook"
+	"github.com/rissabekov-wes/social/internal/worker"
+)
+
+// newWebhookTestApplication returns an application with a single webhook,
+// pointed at url, subscribed to post.created. Failures it records are sent
+// on the returned channel.
+func newWebhookTestApplication(t *testing.T, url string, cfg webhook.Config) (*application, <-chan *store.WebhookFailure) {
+	t.Helper()
+
+	failures := make(chan *store.WebhookFailure, 1)
+	app := newTestApplication(t, store.Storage{Webhooks: &fakeWebhooksStore{
+		listForEvent: func(ctx context.Context, event string) ([]store.Webhook, error) {
+			if event != webhook.EventPostCreated {
+				t.Errorf("ListForEvent(%q), want %q", event, webhook.EventPostCreated)
+			}
+			return []store.Webhook{{ID: 7, URL: url, Secret: "secret", Events: []string{event}, Active: true}}, nil
+		},
+		recordFailure: func(ctx context.Context, f *store.WebhookFailure) error {
+			failures <- f
+			return nil
+		},
+	}})
+	app.webhooks = webhook.NewDispatcher(cfg)
+	app.config.webhooks.attempts = cfg.Attempts
+
+	return app, failures
+}
+
+// drainWebhooks waits for the pool to finish, then gives up on any retries
+// still waiting, as shutdown does.
+func drainWebhooks(t *testing.T, app *application) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := app.workers.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	app.webhookRetries.flush()
+}
+
+func TestDispatchWebhooksRetriesUntilDelivered(t *testing.T) {
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	var hits atomic.Int32
+	delivered := make(chan delivery, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		delivered <- delivery{header: r.Header.Clone(), body: body}
+	}))
+	t.Cleanup(srv.Close)
+
+	app, failures := newWebhookTestApplication(t, srv.URL, webhook.Config{Attempts: 3, Backoff: time.Millisecond, Timeout: time.Second})
+
+	app.dispatchWebhooks(webhook.EventPostCreated, map[string]any{"id": 42, "title": "hello"})
+
+	var got delivery
+	select {
+	case got = <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	drainWebhooks(t, app)
+
+	select {
+	case f := <-failures:
+		t.Errorf("recorded failure %+v for a delivered webhook", f)
+	default:
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("server hit %d times, want 3", n)
+	}
+
+	if !webhook.Verify("secret", got.body, got.header.Get(webhook.SignatureHeader)) {
+		t.Errorf("signature %q does not verify", got.header.Get(webhook.SignatureHeader))
+	}
+
+	var event struct {
+		ID    string         `json:"id"`
+		Event string         `json:"event"`
+		Data  map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(got.body, &event); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	if event.Event != webhook.EventPostCreated || event.Data["title"] != "hello" {
+		t.Errorf("payload = %s", got.body)
+	}
+	if event.ID == "" || event.ID != got.header.Get(webhook.DeliveryHeader) {
+		t.Errorf("payload id %q, delivery header %q; want the same non-empty ID", event.ID, got.header.Get(webhook.DeliveryHeader))
+	}
+}
+
+func TestDispatchWebhooksRecordsFailureAfterLastAttempt(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	app, failures := newWebhookTestApplication(t, srv.URL, webhook.Config{Attempts: 3, Backoff: time.Millisecond, Timeout: time.Second})
+
+	app.dispatchWebhooks(webhook.EventPostCreated, map[string]any{"id": 42})
+
+	var f *store.WebhookFailure
+	select {
+	case f = <-failures:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no failure recorded")
+	}
+
+	if f.WebhookID != 7 || f.Event != webhook.EventPostCreated {
+		t.Errorf("failure for webhook %d, event %q", f.WebhookID, f.Event)
+	}
+	if f.Attempts != 3 || f.StatusCode != http.StatusInternalServerError {
+		t.Errorf("failure = %d attempts, status %d; want 3 and 500", f.Attempts, f.StatusCode)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("server hit %d times, want 3", n)
+	}
+	if !json.Valid(f.Payload) {
+		t.Errorf("payload %q is not JSON", f.Payload)
+	}
+}
+
+func TestDispatchWebhooksDoesNotRetryClientError(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	t.Cleanup(srv.Close)
+
+	app, failures := newWebhookTestApplication(t, srv.URL, webhook.Config{Attempts: 3, Backoff: time.Millisecond, Timeout: time.Second})
+
+	app.dispatchWebhooks(webhook.EventPostCreated, map[string]any{"id": 42})
+
+	select {
+	case f := <-failures:
+		if f.Attempts != 1 || f.StatusCode != http.StatusGone {
+			t.Errorf("failure = %d attempts, status %d; want 1 and 410", f.Attempts, f.StatusCode)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no failure recorded")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server hit %d times, want 1", n)
+	}
+}
+
+func TestWebhookRetryHoldsNoWorker(t *testing.T) {
+	attempted := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		attempted <- struct{}{}
+	}))
+	t.Cleanup(srv.Close)
+
+	// Long enough that the retry is still pending at shutdown.
+	app, failures := newWebhookTestApplication(t, srv.URL, webhook.Config{Attempts: 3, Backoff: time.Hour, Timeout: time.Second})
+
+	app.dispatchWebhooks(webhook.EventPostCreated, map[string]any{"id": 42})
+	select {
+	case <-attempted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not attempted")
+	}
+
+	// Shutdown waits for running tasks, so it would block for the hour if a
+	// worker were sleeping through the backoff.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		drainWebhooks(t, app)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown waited on a pending retry")
+	}
+
+	select {
+	case f := <-failures:
+		if f.Attempts != 1 || f.StatusCode != http.StatusInternalServerError {
+			t.Errorf("failure = %d attempts, status %d; want 1 and 500", f.Attempts, f.StatusCode)
+		}
+	default:
+		t.Fatal("pending retry was not recorded as failed at shutdown")
+	}
+}
+
+func TestQueueWebhookDeliveryAfterShutdown(t *testing.T) {
+	app, failures := newWebhookTestApplication(t, "http://127.0.0.1:0", webhook.Config{Attempts: 3, Timeout: time.Second})
+	drainWebhooks(t, app)
+
+	hook := store.Webhook{ID: 7}
+	app.queueWebhookDelivery(hook, &webhook.Delivery{Event: webhook.EventPostCreated, ID: "d-1", Body: []byte(`{}`)})
+
+	select {
+	case f := <-failures:
+		if f.Attempts != 0 || f.StatusCode != 0 {
+			t.Errorf("failure = %d attempts, status %d; want neither", f.Attempts, f.StatusCode)
+		}
+		if !strings.Contains(f.Error, worker.ErrClosed.Error()) {
+			t.Errorf("failure error = %q, want it to mention %q", f.Error, worker.ErrClosed)
+		}
+	default:
+		t.Fatal("unqueued delivery was not recorded as failed")
+	}
+}
+
+func TestWebhookRetriesFlush(t *testing.T) {
+	var q webhookRetries
+	var retried, gaveUp atomic.Int32
+
+	q.schedule(time.Hour, func() { retried.Add(1) }, func() { gaveUp.Add(1) })
+	q.flush()
+	if retried.Load() != 0 || gaveUp.Load() != 1 {
+		t.Fatalf("after flush: %d retried, %d gave up; want 0 and 1", retried.Load(), gaveUp.Load())
+	}
+
+	// Anything scheduled once flushed gives up at once.
+	q.schedule(time.Millisecond, func() { retried.Add(1) }, func() { gaveUp.Add(1) })
+	if gaveUp.Load() != 2 {
+		t.Errorf("schedule after flush did not give up")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if retried.Load() != 0 {
+		t.Errorf("retry ran after flush")
+	}
+}
+
+func TestWebhookRetriesRunsRetry(t *testing.T) {
+	var q webhookRetries
+	retried := make(chan struct{})
+
+	q.schedule(time.Millisecond, func() { close(retried) }, func() { t.Error("gave up on a retry that was due") })
+
+	select {
+	case <-retried:
+	case <-time.After(5 * time.Second):
+		t.Fatal("retry did not run")
+	}
+	// Already run, so nothing is left to give up on.
+	q.flush()
+}
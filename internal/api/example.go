@@ -10,7 +10,7 @@ import (
 
 const (
 	httpMethod = "GET"
-	httpPath   = "/example"
+	httpPath   = "/v1/example"
 )
 
 // type ApiHandlerExample struct{}
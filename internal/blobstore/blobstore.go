@@ -0,0 +1,27 @@
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Store persists opaque blobs under caller-chosen keys and knows how to turn
+// a key into a URL clients can fetch.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	URL(key string) string
+}
+
+// New returns the Store selected by kind. Only "local" is implemented; "s3"
+// is reserved for the hosted deployment.
+func New(kind, dir, baseURL string) (Store, error) {
+	switch kind {
+	case "local":
+		return NewLocalStore(dir, baseURL), nil
+	case "s3":
+		return nil, fmt.Errorf("blobstore %q is not implemented yet", kind)
+	default:
+		return nil, fmt.Errorf("unknown blobstore %q", kind)
+	}
+}
@@ -0,0 +1,36 @@
+package blobstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorePut(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStore(dir, "http://localhost/uploads/")
+
+	if err := s.Put(context.Background(), "avatars/1/a.png", strings.NewReader("png"), "image/png"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "avatars", "1", "a.png"))
+	if err != nil || string(got) != "png" {
+		t.Errorf("stored %q, %v; want %q", got, err, "png")
+	}
+	if url := s.URL("avatars/1/a.png"); url != "http://localhost/uploads/avatars/1/a.png" {
+		t.Errorf("URL() = %q", url)
+	}
+}
+
+func TestLocalStorePutRejectsEscapingKeys(t *testing.T) {
+	s := NewLocalStore(t.TempDir(), "http://localhost/uploads")
+
+	for _, key := range []string{"../outside", "avatars/../../outside", ""} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x"), "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded, want an error", key)
+		}
+	}
+}
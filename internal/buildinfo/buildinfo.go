@@ -0,0 +1,60 @@
+// Package buildinfo is the single source of truth for the version of the
+// binaries in this module. The variables are overridden at build time:
+//
+//	go build -ldflags "\
+//	  -X github.com/rissabekov-wes/social/internal/buildinfo.Version=1.2.0 \
+//	  -X github.com/rissabekov-wes/social/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/rissabekov-wes/social/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+//	  ./cmd/api
+//
+// When Commit is not set it falls back to the VCS revision the Go toolchain
+// embeds in module-aware builds.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "1.0.0"
+	Commit    = ""
+	BuildTime = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information of the running binary. Values that were
+// neither injected nor recorded by the toolchain are reported as "unknown".
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
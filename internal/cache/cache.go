@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Store is a key/value cache with per-entry expiry. Values are opaque bytes
+// so implementations can live in-process or behind the network. A miss is
+// reported as ok == false with a nil error.
+type Store interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// New returns the Store selected by kind: "memory" for an in-process cache,
+// "redis" for one shared through redisAddr, or "none" to disable caching.
+func New(kind, redisAddr string) (Store, error) {
+	switch kind {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		if redisAddr == "" {
+			return nil, fmt.Errorf("cache %q requires a redis address", kind)
+		}
+		return NewRedisCache(redisAddr), nil
+	case "none", "":
+		return NoopStore{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache %q", kind)
+	}
+}
+
+// NoopStore never holds anything; every Get is a miss.
+type NoopStore struct{}
+
+func (NoopStore) Get(context.Context, string) ([]byte, bool, error)        { return nil, false, nil }
+func (NoopStore) Set(context.Context, string, []byte, time.Duration) error { return nil }
+func (NoopStore) Delete(context.Context, ...string) error                  { return nil }
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	if _, ok, err := s.Get(ctx, "missing"); ok || err != nil {
+		t.Fatalf("Get(missing) = _, %v, %v; want a miss", ok, err)
+	}
+
+	if err := s.Set(ctx, "a", []byte("1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, "b", []byte("2"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := s.Get(ctx, "a"); !ok || err != nil || string(got) != "1" {
+		t.Errorf("Get(a) = %q, %v, %v; want 1", got, ok, err)
+	}
+
+	if err := s.Delete(ctx, "a", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get(ctx, "a"); ok {
+		t.Error("Get(a) hit after Delete")
+	}
+	if _, ok, _ := s.Get(ctx, "b"); !ok {
+		t.Error("Delete(a) removed b as well")
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	s.Set(ctx, "short", []byte("x"), 10*time.Millisecond)
+	s.Set(ctx, "none", []byte("x"), 0)
+
+	if _, ok, _ := s.Get(ctx, "none"); ok {
+		t.Error("an entry without a TTL was stored")
+	}
+	if _, ok, _ := s.Get(ctx, "short"); !ok {
+		t.Fatal("entry missing before its TTL")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := s.Get(ctx, "short"); ok {
+		t.Error("entry still served after its TTL")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	s.sweepSize = 4
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		s.Set(ctx, key, []byte("x"), time.Nanosecond)
+	}
+	time.Sleep(time.Millisecond)
+	s.Set(ctx, "live", []byte("x"), time.Minute)
+
+	if n := len(s.entries); n != 1 {
+		t.Errorf("%d entries after the sweep, want only the live one", n)
+	}
+}
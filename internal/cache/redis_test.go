@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedisCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	c := NewRedisCache(mr.Addr())
+	t.Cleanup(func() { c.Close() })
+	return c, mr
+}
+
+func TestRedisCacheSetGet(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestRedisCache(t)
+
+	if _, ok, err := c.Get(ctx, "feed:1:gen"); ok || err != nil {
+		t.Fatalf("Get() on an empty cache = _, %v, %v; want a miss", ok, err)
+	}
+
+	if err := c.Set(ctx, "feed:1:gen", []byte(`{"id":1}`), time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, ok, err := c.Get(ctx, "feed:1:gen")
+	if err != nil || !ok || string(got) != `{"id":1}` {
+		t.Errorf("Get() = %q, %v, %v; want the stored value", got, ok, err)
+	}
+	if ttl := mr.TTL("feed:1:gen"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want %v", ttl, time.Minute)
+	}
+
+	if err := c.Delete(ctx, "feed:1:gen", "missing"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if mr.Exists("feed:1:gen") {
+		t.Error("key still present after Delete")
+	}
+}
+
+func TestRedisCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestRedisCache(t)
+
+	c.Set(ctx, "short", []byte("x"), 10*time.Second)
+	mr.FastForward(11 * time.Second)
+
+	if _, ok, err := c.Get(ctx, "short"); ok || err != nil {
+		t.Errorf("Get() after the TTL = _, %v, %v; want a miss", ok, err)
+	}
+
+	// Without a TTL nothing is written, so no key can outlive its data.
+	if err := c.Set(ctx, "forever", []byte("x"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("forever") {
+		t.Error("Set() with no TTL created a key")
+	}
+}
+
+func TestRedisCacheUnavailable(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestRedisCache(t)
+	mr.Close()
+
+	if _, ok, err := c.Get(ctx, "k"); ok || err == nil {
+		t.Errorf("Get() with redis down = _, %v, %v; want an error and no hit", ok, err)
+	}
+	if err := c.Set(ctx, "k", []byte("x"), time.Minute); err == nil {
+		t.Error("Set() with redis down succeeded")
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		kind, addr string
+		want       string
+		wantErr    bool
+	}{
+		{kind: "", want: "cache.NoopStore"},
+		{kind: "none", want: "cache.NoopStore"},
+		{kind: "memory", want: "*cache.MemoryStore"},
+		{kind: "redis", addr: "localhost:6379", want: "*cache.RedisCache"},
+		{kind: "redis", wantErr: true},
+		{kind: "memcached", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		s, err := New(tt.kind, tt.addr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%q, %q) error = %v, wantErr %v", tt.kind, tt.addr, err, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			if got := fmt.Sprintf("%T", s); got != tt.want {
+				t.Errorf("New(%q, %q) = %s, want %s", tt.kind, tt.addr, got, tt.want)
+			}
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package config
 
-var (
-	Version = "0.0.1"
+import (
+	"fmt"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/env"
 )
 
 type ApplicationConfig struct {
@@ -10,6 +13,11 @@ type ApplicationConfig struct {
 
 func NewApplicationConfig() *ApplicationConfig {
 	envValues := NewEnvironmentConfig()
+	envValues.ServiceName = env.MustGetString("SERVICE_NAME")
+
+	if err := envValues.Validate(); err != nil {
+		panic(fmt.Sprintf("invalid configuration:\n%v", err))
+	}
 
 	return &ApplicationConfig{
 		envValues: envValues,
@@ -20,6 +28,30 @@ func (cfg *ApplicationConfig) ServiceName() string {
 	return cfg.envValues.ServiceName
 }
 
+func (cfg *ApplicationConfig) EnvName() string {
+	return cfg.envValues.EnvName
+}
+
+func (cfg *ApplicationConfig) IsProduction() bool {
+	return cfg.envValues.IsProduction()
+}
+
 func (cfg *ApplicationConfig) ServerPort() int {
 	return cfg.envValues.ServerPort
 }
+
+func (cfg *ApplicationConfig) ReadTimeout() time.Duration {
+	return time.Duration(cfg.envValues.ReadTimeout) * time.Second
+}
+
+func (cfg *ApplicationConfig) WriteTimeout() time.Duration {
+	return time.Duration(cfg.envValues.WriteTimeout) * time.Second
+}
+
+func (cfg *ApplicationConfig) IdleTimeout() time.Duration {
+	return time.Duration(cfg.envValues.IdleTimeout) * time.Second
+}
+
+func (cfg *ApplicationConfig) ReadHeaderTimeout() time.Duration {
+	return time.Duration(cfg.envValues.ReadHeaderTimeout) * time.Second
+}
@@ -3,19 +3,110 @@ package db
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"log/slog"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
-func New(addr string, maxOpenConns, maxIdleConns int, maxIdleTime string) (*sql.DB, error) {
-	// Implementation for creating and configuring a new database connection goes here
-	db, err := sql.Open("postgres", addr)
+// Option configures New.
+type Option func(*options)
+
+type options struct {
+	slowQueryThreshold time.Duration
+	logger             *slog.Logger
+}
+
+// WithSlowQueryLog logs every statement that takes threshold or longer to
+// logger, named after the store method that ran it. A threshold of zero or
+// less turns the log off.
+func WithSlowQueryLog(threshold time.Duration, logger *slog.Logger) Option {
+	return func(o *options) {
+		o.slowQueryThreshold = threshold
+		o.logger = logger
+	}
+}
+
+func New(addr string, maxOpenConns, maxIdleConns int, maxIdleTime time.Duration, opts ...Option) (*sql.DB, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	connector, err := pq.NewConnector(addr)
 	if err != nil {
 		return nil, err
 	}
 
+	var c driver.Connector = connector
+	if o.slowQueryThreshold > 0 && o.logger != nil {
+		c = &slowQueryConnector{Connector: connector, threshold: o.slowQueryThreshold, logger: o.logger}
+	}
+	db := sql.OpenDB(c)
+
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxIdleTime(maxIdleTime)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	db.PingContext(ctx)
-	return nil, nil
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// kvPassword matches the password of a lib/pq key/value connection string,
+// quoted or not.
+var kvPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// RedactAddr masks the password in a connection string so it can be logged.
+// Both URLs and lib/pq key/value strings such as "host=db password=secret"
+// are understood, as is a password passed as a URL query parameter.
+func RedactAddr(addr string) string {
+	if !strings.Contains(addr, "://") {
+		return kvPassword.ReplaceAllString(addr, "${1}****")
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "****"
+	}
+
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			if key, _, ok := strings.Cut(param, "="); ok && strings.EqualFold(key, "password") {
+				params[i] = key + "=****"
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+
+	// URL.Redacted masks with "xxxxx"; use the same mask as other secrets.
+	return strings.Replace(u.Redacted(), ":xxxxx@", ":****@", 1)
+}
+
+// BuildAddr assembles a postgres connection URL from its parts, escaping the
+// credentials so passwords may contain any character.
+func BuildAddr(host string, port int, user, password, name, sslMode string) string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(user, password),
+		Host:   net.JoinHostPort(host, strconv.Itoa(port)),
+		Path:   "/" + name,
+	}
+	if sslMode != "" {
+		u.RawQuery = url.Values{"sslmode": {sslMode}}.Encode()
+	}
+	return u.String()
 }
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/rissabekov-wes/social/migrations"
+)
+
+// NewMigrator returns a migrate instance over the embedded migrations. It
+// holds a dedicated connection from db, so closing the migrator releases that
+// connection without closing the pool.
+func NewMigrator(ctx context.Context, db *sql.DB) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("iofs", source, "postgres", driver)
+}
+
+// MigrateUp applies every pending up migration.
+func MigrateUp(ctx context.Context, db *sql.DB) error {
+	m, err := NewMigrator(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
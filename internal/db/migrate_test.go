@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/rissabekov-wes/social/migrations"
+)
+
+// latestMigration returns the highest version among the embedded migrations,
+// failing the test unless each up migration has a matching down migration.
+func latestMigration(t *testing.T) uint {
+	t.Helper()
+
+	ups, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ups) == 0 {
+		t.Fatal("no up migrations embedded")
+	}
+
+	var latest uint
+	for _, up := range ups {
+		down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+		if _, err := fs.Stat(migrations.FS, down); err != nil {
+			t.Errorf("%s has no down migration", up)
+		}
+
+		version, err := strconv.ParseUint(up[:strings.IndexByte(up, '_')], 10, 64)
+		if err != nil {
+			t.Fatalf("%s: bad version prefix: %v", up, err)
+		}
+		latest = max(latest, uint(version))
+	}
+
+	return latest
+}
+
+func TestMigrationsArePaired(t *testing.T) {
+	latestMigration(t)
+}
+
+func TestMigrateUpDownIntegration(t *testing.T) {
+	addr := os.Getenv("TEST_DATABASE_URL")
+	if addr == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	admin, err := New(addr, 2, 2, time.Minute)
+	if err != nil {
+		t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	var suffix [6]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		t.Fatal(err)
+	}
+	schema := "test_" + hex.EncodeToString(suffix[:])
+	if _, err := admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)); err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(fmt.Sprintf("DROP SCHEMA %s CASCADE", schema)); err != nil {
+			t.Errorf("dropping schema: %v", err)
+		}
+	})
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		t.Fatalf("TEST_DATABASE_URL must be a URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	conn, err := New(u.String(), 2, 2, time.Minute)
+	if err != nil {
+		t.Fatalf("connecting to test schema: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	m, err := NewMigrator(ctx, conn)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	defer m.Close()
+
+	// Tables in the schema other than migrate's own bookkeeping.
+	countTables := func() int {
+		t.Helper()
+		var n int
+		err := admin.QueryRow(`
+			SELECT COUNT(*) FROM information_schema.tables
+			WHERE table_schema = $1 AND table_name <> 'schema_migrations'
+		`, schema).Scan(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	version, dirty, err := m.Version()
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if want := latestMigration(t); version != want || dirty {
+		t.Errorf("Version() = %d (dirty %v), want %d", version, dirty, want)
+	}
+	if countTables() == 0 {
+		t.Fatal("Up() created no tables")
+	}
+
+	if err := m.Down(); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if _, _, err := m.Version(); !errors.Is(err, migrate.ErrNilVersion) {
+		t.Errorf("Version() after Down() error = %v, want ErrNilVersion", err)
+	}
+	if n := countTables(); n != 0 {
+		t.Errorf("%d tables left after Down(), want 0", n)
+	}
+
+	// The down migrations must leave nothing behind that breaks a re-run.
+	if err := m.Up(); err != nil {
+		t.Errorf("Up() after Down() error = %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type queryNameKey struct{}
+
+// WithQueryName labels the queries run with ctx in the slow query log.
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+func queryName(ctx context.Context) string {
+	if name, ok := ctx.Value(queryNameKey{}).(string); ok {
+		return name
+	}
+	return "unnamed"
+}
+
+// maxLoggedStatement caps the SQL text included in a slow query log line.
+const maxLoggedStatement = 200
+
+// slowQueryConnector times every statement run on its connections and logs
+// those that take threshold or longer. It wraps the driver rather than
+// *sql.DB so that queries inside transactions, and any store method added
+// later, are covered without further changes.
+type slowQueryConnector struct {
+	driver.Connector
+	threshold time.Duration
+	logger    *slog.Logger
+}
+
+func (c *slowQueryConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &slowQueryConn{Conn: conn, connector: c}, nil
+}
+
+func (c *slowQueryConnector) observe(ctx context.Context, query string, start time.Time) {
+	elapsed := time.Since(start)
+	if elapsed < c.threshold {
+		return
+	}
+
+	statement := strings.Join(strings.Fields(query), " ")
+	if len(statement) > maxLoggedStatement {
+		statement = statement[:maxLoggedStatement] + "..."
+	}
+
+	c.logger.WarnContext(ctx, "slow query",
+		"query", queryName(ctx),
+		"elapsed", elapsed,
+		"threshold", c.threshold,
+		"statement", statement,
+	)
+}
+
+// slowQueryConn forwards to the driver's connection, timing Exec and Query.
+// The optional driver interfaces are forwarded when the underlying
+// connection has them, and otherwise fall back the way database/sql would.
+type slowQueryConn struct {
+	driver.Conn
+	connector *slowQueryConnector
+}
+
+var (
+	_ driver.ExecerContext      = (*slowQueryConn)(nil)
+	_ driver.QueryerContext     = (*slowQueryConn)(nil)
+	_ driver.ConnPrepareContext = (*slowQueryConn)(nil)
+	_ driver.ConnBeginTx        = (*slowQueryConn)(nil)
+	_ driver.Pinger             = (*slowQueryConn)(nil)
+	_ driver.SessionResetter    = (*slowQueryConn)(nil)
+	_ driver.Validator          = (*slowQueryConn)(nil)
+)
+
+func (c *slowQueryConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	defer c.connector.observe(ctx, query, time.Now())
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *slowQueryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	defer c.connector.observe(ctx, query, time.Now())
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *slowQueryConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *slowQueryConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) || opts.ReadOnly {
+		return nil, errors.New("db: driver does not support non-default transaction options")
+	}
+	return c.Conn.Begin() //nolint:staticcheck // the fallback database/sql itself uses
+}
+
+func (c *slowQueryConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *slowQueryConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *slowQueryConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
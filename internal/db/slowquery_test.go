@@ -0,0 +1,193 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sleepConnector hands out connections whose statements take delay, to stand
+// in for a slow database.
+type sleepConnector struct {
+	delay time.Duration
+}
+
+func (c *sleepConnector) Connect(context.Context) (driver.Conn, error) {
+	return &sleepConn{delay: c.delay}, nil
+}
+
+func (c *sleepConnector) Driver() driver.Driver { return sleepDriver{} }
+
+type sleepDriver struct{}
+
+func (sleepDriver) Open(string) (driver.Conn, error) { return nil, driver.ErrSkip }
+
+type sleepConn struct {
+	delay time.Duration
+}
+
+func (c *sleepConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *sleepConn) Close() error                        { return nil }
+func (c *sleepConn) Begin() (driver.Tx, error)           { return sleepTx{}, nil }
+
+func (c *sleepConn) ExecContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	time.Sleep(c.delay)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *sleepConn) QueryContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	time.Sleep(c.delay)
+	return emptyRows{}, nil
+}
+
+type sleepTx struct{}
+
+func (sleepTx) Commit() error   { return nil }
+func (sleepTx) Rollback() error { return nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return []string{"id"} }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+// slowQueryLine is a slow query log line as written by slog's JSON handler,
+// which encodes durations in nanoseconds.
+type slowQueryLine struct {
+	Level     string        `json:"level"`
+	Msg       string        `json:"msg"`
+	Query     string        `json:"query"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Threshold time.Duration `json:"threshold"`
+	Statement string        `json:"statement"`
+}
+
+// openSlowDB returns a database whose statements take delay, logged when
+// they reach threshold, and the buffer the log is written to.
+func openSlowDB(t *testing.T, delay, threshold time.Duration) (*sql.DB, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	db := sql.OpenDB(&slowQueryConnector{
+		Connector: &sleepConnector{delay: delay},
+		threshold: threshold,
+		logger:    slog.New(slog.NewJSONHandler(&buf, nil)),
+	})
+	t.Cleanup(func() { db.Close() })
+
+	return db, &buf
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []slowQueryLine {
+	t.Helper()
+
+	var lines []slowQueryLine
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var line slowQueryLine
+		if err := dec.Decode(&line); err != nil {
+			t.Fatalf("decoding log line: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestSlowQueryLogged(t *testing.T) {
+	const threshold = 20 * time.Millisecond
+	db, buf := openSlowDB(t, 30*time.Millisecond, threshold)
+
+	ctx := WithQueryName(context.Background(), "Posts.GetByID")
+	query := `
+		SELECT id, title
+		FROM posts
+		WHERE id = $1
+	`
+	rows, err := db.QueryContext(ctx, query, 1)
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	rows.Close()
+
+	lines := logLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d log lines, want 1", len(lines))
+	}
+	line := lines[0]
+
+	if line.Level != "WARN" || line.Msg != "slow query" {
+		t.Errorf("log line = %s %q, want WARN \"slow query\"", line.Level, line.Msg)
+	}
+	if line.Query != "Posts.GetByID" {
+		t.Errorf("query = %q, want Posts.GetByID", line.Query)
+	}
+	if line.Threshold != threshold || line.Elapsed < threshold {
+		t.Errorf("elapsed %v, threshold %v; want elapsed at least %v", line.Elapsed, line.Threshold, threshold)
+	}
+	if want := "SELECT id, title FROM posts WHERE id = $1"; line.Statement != want {
+		t.Errorf("statement = %q, want %q", line.Statement, want)
+	}
+}
+
+func TestSlowQueryLoggedInTransaction(t *testing.T) {
+	db, buf := openSlowDB(t, 30*time.Millisecond, 20*time.Millisecond)
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if _, err := tx.ExecContext(context.Background(), `UPDATE posts SET title = $1`, "t"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	lines := logLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d log lines, want 1", len(lines))
+	}
+	if lines[0].Query != "unnamed" || lines[0].Statement != "UPDATE posts SET title = $1" {
+		t.Errorf("log line = %+v, want the unnamed UPDATE", lines[0])
+	}
+}
+
+func TestFastQueryNotLogged(t *testing.T) {
+	db, buf := openSlowDB(t, 0, time.Second)
+
+	if _, err := db.ExecContext(context.Background(), `DELETE FROM posts WHERE id = $1`, 1); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("logged a query below the threshold: %s", buf)
+	}
+}
+
+func TestSlowQueryStatementTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	c := &slowQueryConnector{threshold: time.Millisecond, logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+
+	query := "SELECT\n\t" + strings.Repeat("col, ", 100) + "id FROM posts"
+	c.observe(context.Background(), query, time.Now().Add(-time.Second))
+
+	lines := logLines(t, &buf)
+	if len(lines) != 1 {
+		t.Fatalf("got %d log lines, want 1", len(lines))
+	}
+
+	statement := lines[0].Statement
+	if want := maxLoggedStatement + len("..."); len(statement) != want {
+		t.Errorf("statement is %d bytes, want %d", len(statement), want)
+	}
+	if !strings.HasPrefix(statement, "SELECT col, col,") || !strings.HasSuffix(statement, "...") {
+		t.Errorf("statement = %q, want the collapsed query cut short with ...", statement)
+	}
+}
@@ -1,8 +1,11 @@
 package env
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func GetString(key, defaultValue string) string {
@@ -13,6 +16,14 @@ func GetString(key, defaultValue string) string {
 	return val
 }
 
+func MustGetString(key string) string {
+	val, ok := os.LookupEnv(key)
+	if !ok || val == "" {
+		panic(fmt.Sprintf("required env var %s not set", key))
+	}
+	return val
+}
+
 func GetInt(key string, defaultValue int) int {
 	val, ok := os.LookupEnv(key)
 	if !ok {
@@ -24,3 +35,81 @@ func GetInt(key string, defaultValue int) int {
 	}
 	return valInt
 }
+
+func MustGetInt(key string) int {
+	val := MustGetString(key)
+	valInt, err := strconv.Atoi(val)
+	if err != nil {
+		panic(fmt.Sprintf("required env var %s is not a valid integer: %q", key, val))
+	}
+	return valInt
+}
+
+func GetInt64(key string, defaultValue int64) int64 {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	valInt, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return valInt
+}
+
+func GetFloat64(key string, defaultValue float64) float64 {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	valFloat, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return valFloat
+}
+
+func GetBool(key string, defaultValue bool) bool {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	val = strings.ToLower(strings.TrimSpace(val))
+	valBool, err := strconv.ParseBool(val)
+	if err == nil {
+		return valBool
+	}
+	switch val {
+	case "yes", "y", "on":
+		return true
+	case "no", "n", "off":
+		return false
+	}
+	return defaultValue
+}
+
+func GetDuration(key string, defaultValue time.Duration) time.Duration {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	valDuration, err := time.ParseDuration(val)
+	if err != nil {
+		return defaultValue
+	}
+	return valDuration
+}
+
+func GetStringSlice(key, sep string, defaultValue []string) []string {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	var out []string
+	for _, part := range strings.Split(val, sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,236 @@
+package env
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+const testKey = "SOCIAL_ENV_TEST"
+
+// setTestEnv sets testKey to val for the duration of the test, or unsets it
+// when set is false. Either way the previous value is restored afterwards.
+func setTestEnv(t *testing.T, val string, set bool) {
+	t.Helper()
+
+	t.Setenv(testKey, val)
+	if !set {
+		os.Unsetenv(testKey)
+	}
+}
+
+func TestGetBool(t *testing.T) {
+	tests := []struct {
+		name         string
+		val          string
+		set          bool
+		defaultValue bool
+		want         bool
+	}{
+		{name: "unset returns default true", defaultValue: true, want: true},
+		{name: "unset returns default false", defaultValue: false, want: false},
+		{name: "1", val: "1", set: true, want: true},
+		{name: "0", val: "0", set: true, defaultValue: true, want: false},
+		{name: "true", val: "true", set: true, want: true},
+		{name: "TRUE", val: "TRUE", set: true, want: true},
+		{name: "t", val: "t", set: true, want: true},
+		{name: "false", val: "false", set: true, defaultValue: true, want: false},
+		{name: "F", val: "F", set: true, defaultValue: true, want: false},
+		{name: "yes", val: "yes", set: true, want: true},
+		{name: "Yes padded", val: "  Yes ", set: true, want: true},
+		{name: "no", val: "no", set: true, defaultValue: true, want: false},
+		{name: "on", val: "on", set: true, want: true},
+		{name: "OFF", val: "OFF", set: true, defaultValue: true, want: false},
+		{name: "empty returns default", val: "", set: true, defaultValue: true, want: true},
+		{name: "garbage returns default true", val: "maybe", set: true, defaultValue: true, want: true},
+		{name: "garbage returns default false", val: "2", set: true, defaultValue: false, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestEnv(t, tt.val, tt.set)
+
+			if got := GetBool(testKey, tt.defaultValue); got != tt.want {
+				t.Errorf("GetBool(%q, %v) = %v, want %v", tt.val, tt.defaultValue, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDuration(t *testing.T) {
+	const defaultValue = 5 * time.Second
+
+	tests := []struct {
+		name string
+		val  string
+		set  bool
+		want time.Duration
+	}{
+		{name: "unset", want: defaultValue},
+		{name: "minutes", val: "15m", set: true, want: 15 * time.Minute},
+		{name: "compound", val: "1h30m", set: true, want: 90 * time.Minute},
+		{name: "empty", val: "", set: true, want: defaultValue},
+		{name: "invalid", val: "abc", set: true, want: defaultValue},
+		{name: "missing unit", val: "15", set: true, want: defaultValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestEnv(t, tt.val, tt.set)
+
+			if got := GetDuration(testKey, defaultValue); got != tt.want {
+				t.Errorf("GetDuration(%q) = %v, want %v", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+// recoverPanic runs fn and returns the value it panicked with, or nil.
+func recoverPanic(fn func()) (recovered any) {
+	defer func() { recovered = recover() }()
+	fn()
+	return nil
+}
+
+func TestMustGetString(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		setTestEnv(t, "social", true)
+
+		if got := MustGetString(testKey); got != "social" {
+			t.Errorf("MustGetString() = %q, want %q", got, "social")
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		set  bool
+	}{
+		{name: "unset", set: false},
+		{name: "empty", set: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestEnv(t, "", tt.set)
+
+			got := recoverPanic(func() { MustGetString(testKey) })
+			if want := "required env var " + testKey + " not set"; got != want {
+				t.Errorf("panic = %v, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestMustGetInt(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		setTestEnv(t, "42", true)
+
+		if got := MustGetInt(testKey); got != 42 {
+			t.Errorf("MustGetInt() = %d, want 42", got)
+		}
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		setTestEnv(t, "", false)
+
+		got := recoverPanic(func() { MustGetInt(testKey) })
+		if want := "required env var " + testKey + " not set"; got != want {
+			t.Errorf("panic = %v, want %q", got, want)
+		}
+	})
+
+	t.Run("not an integer", func(t *testing.T) {
+		setTestEnv(t, "forty", true)
+
+		got := recoverPanic(func() { MustGetInt(testKey) })
+		if want := `required env var ` + testKey + ` is not a valid integer: "forty"`; got != want {
+			t.Errorf("panic = %v, want %q", got, want)
+		}
+	})
+}
+
+func TestGetInt64(t *testing.T) {
+	const defaultValue int64 = 1_048_576
+
+	tests := []struct {
+		name string
+		val  string
+		set  bool
+		want int64
+	}{
+		{name: "unset", want: defaultValue},
+		{name: "small", val: "42", set: true, want: 42},
+		{name: "beyond int32", val: "10737418240", set: true, want: 10_737_418_240},
+		{name: "max int64", val: "9223372036854775807", set: true, want: 9223372036854775807},
+		{name: "negative", val: "-3000000000", set: true, want: -3_000_000_000},
+		{name: "empty", val: "", set: true, want: defaultValue},
+		{name: "invalid", val: "1MB", set: true, want: defaultValue},
+		{name: "overflow", val: "9223372036854775808", set: true, want: defaultValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestEnv(t, tt.val, tt.set)
+
+			if got := GetInt64(testKey, defaultValue); got != tt.want {
+				t.Errorf("GetInt64(%q) = %d, want %d", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStringSlice(t *testing.T) {
+	defaultValue := []string{"fallback"}
+
+	tests := []struct {
+		name string
+		val  string
+		set  bool
+		want []string
+	}{
+		{name: "unset", want: defaultValue},
+		{name: "comma separated", val: "a,b,c", set: true, want: []string{"a", "b", "c"}},
+		{name: "whitespace padded", val: " a , b ,c ", set: true, want: []string{"a", "b", "c"}},
+		{name: "empty entries dropped", val: "a,,b,", set: true, want: []string{"a", "b"}},
+		{name: "empty", val: "", set: true, want: nil},
+		{name: "only separators", val: " , ,", set: true, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestEnv(t, tt.val, tt.set)
+
+			if got := GetStringSlice(testKey, ",", defaultValue); !slices.Equal(got, tt.want) {
+				t.Errorf("GetStringSlice(%q) = %q, want %q", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFloat64(t *testing.T) {
+	const defaultValue = 0.5
+
+	tests := []struct {
+		name string
+		val  string
+		set  bool
+		want float64
+	}{
+		{name: "unset", want: defaultValue},
+		{name: "decimal", val: "0.25", set: true, want: 0.25},
+		{name: "integer", val: "20", set: true, want: 20},
+		{name: "negative", val: "-1.5", set: true, want: -1.5},
+		{name: "empty", val: "", set: true, want: defaultValue},
+		{name: "malformed", val: "1.2.3", set: true, want: defaultValue},
+		{name: "text", val: "fast", set: true, want: defaultValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestEnv(t, tt.val, tt.set)
+
+			if got := GetFloat64(testKey, defaultValue); got != tt.want {
+				t.Errorf("GetFloat64(%q) = %v, want %v", tt.val, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package mailer
+
+import "log/slog"
+
+// LogMailer renders emails and writes them to the log instead of sending
+// them. It is intended for local development.
+type LogMailer struct {
+	logger *slog.Logger
+}
+
+func NewLogMailer(logger *slog.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+func (m *LogMailer) Send(to, templateName string, data any) error {
+	subject, body, err := render(templateName, data)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("email sent to log", "to", to, "subject", subject, "body", body)
+	return nil
+}
+
+// NoopMailer discards every email.
+type NoopMailer struct{}
+
+func (NoopMailer) Send(to, templateName string, data any) error {
+	return nil
+}
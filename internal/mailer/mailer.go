@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"log/slog"
+	"text/template"
+)
+
+const (
+	UserInvitationTemplate = "user_invitation.tmpl"
+	PasswordResetTemplate  = "password_reset.tmpl"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Client delivers rendered emails. Implementations must be safe for
+// concurrent use.
+type Client interface {
+	Send(to, templateName string, data any) error
+}
+
+// New returns the Client selected by kind. An empty kind disables email.
+func New(kind string, logger *slog.Logger) (Client, error) {
+	switch kind {
+	case "", "noop":
+		return NoopMailer{}, nil
+	case "log":
+		return NewLogMailer(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", kind)
+	}
+}
+
+// render executes the "subject" and "body" blocks of templateName.
+func render(templateName string, data any) (subject, body string, err error) {
+	tmpl, err := template.ParseFS(templateFS, "templates/"+templateName)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", err
+	}
+	subject = buf.String()
+
+	buf.Reset()
+	if err := tmpl.ExecuteTemplate(&buf, "body", data); err != nil {
+		return "", "", err
+	}
+
+	return subject, buf.String(), nil
+}
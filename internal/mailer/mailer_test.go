@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		kind     string
+		wantType string
+		wantErr  bool
+	}{
+		{kind: "", wantType: "mailer.NoopMailer"},
+		{kind: "noop", wantType: "mailer.NoopMailer"},
+		{kind: "log", wantType: "*mailer.LogMailer"},
+		{kind: "smtp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			got, err := New(tt.kind, logger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New(%q) error = %v, wantErr %v", tt.kind, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotType := fmt.Sprintf("%T", got); gotType != tt.wantType {
+				t.Errorf("New(%q) = %s, want %s", tt.kind, gotType, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestLogMailerSend(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLogMailer(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	err := m.Send("alice@example.com", UserInvitationTemplate, map[string]string{
+		"Username":      "alice",
+		"ActivationURL": "http://localhost:4000/confirm/token",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	var entry struct {
+		To      string `json:"to"`
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.To != "alice@example.com" || entry.Subject != "Finish setting up your account" {
+		t.Errorf("logged %+v", entry)
+	}
+	if !strings.Contains(entry.Body, "Hi alice,") || !strings.Contains(entry.Body, "http://localhost:4000/confirm/token") {
+		t.Errorf("body does not contain the rendered fields: %q", entry.Body)
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, _, err := render("missing.tmpl", nil); err == nil {
+		t.Error("render() of a missing template succeeded")
+	}
+}
@@ -0,0 +1,47 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Filter decides whether user-supplied text may be published. When ok is
+// false, reason explains the rejection and is safe to show to the author.
+type Filter interface {
+	Check(ctx context.Context, text string) (ok bool, reason string, err error)
+}
+
+// WordFilter rejects text containing any of a fixed set of banned words.
+// Matching is case-insensitive and on whole words only.
+type WordFilter struct {
+	banned map[string]struct{}
+}
+
+func NewWordFilter(words []string) *WordFilter {
+	banned := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			banned[w] = struct{}{}
+		}
+	}
+	return &WordFilter{banned: banned}
+}
+
+func (f *WordFilter) Check(_ context.Context, text string) (bool, string, error) {
+	if len(f.banned) == 0 {
+		return true, "", nil
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, w := range words {
+		if _, ok := f.banned[w]; ok {
+			return false, fmt.Sprintf("content contains a banned word: %q", w), nil
+		}
+	}
+
+	return true, "", nil
+}
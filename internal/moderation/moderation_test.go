@@ -0,0 +1,45 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWordFilter(t *testing.T) {
+	f := NewWordFilter([]string{" Spam ", "scam", ""})
+
+	tests := []struct {
+		name   string
+		text   string
+		wantOK bool
+	}{
+		{name: "clean", text: "A perfectly normal post", wantOK: true},
+		{name: "banned word", text: "buy my spam now", wantOK: false},
+		{name: "case insensitive", text: "This is a SCAM", wantOK: false},
+		{name: "punctuation", text: "total scam!", wantOK: false},
+		{name: "substring is allowed", text: "spammer and scampi", wantOK: true},
+		{name: "empty", text: "", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason, err := f.Check(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("Check(%q) = %v, want %v", tt.text, ok, tt.wantOK)
+			}
+			if ok != (reason == "") {
+				t.Errorf("Check(%q) reason = %q with ok = %v", tt.text, reason, ok)
+			}
+		})
+	}
+}
+
+func TestWordFilterEmptyList(t *testing.T) {
+	ok, _, err := NewWordFilter(nil).Check(context.Background(), "spam scam")
+	if err != nil || !ok {
+		t.Errorf("Check() = %v, %v; want everything allowed", ok, err)
+	}
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type BlocksStorage struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// Block hides blockedID's content from blockerID. Any follow between the two
+// users, in either direction, is removed in the same transaction so a block
+// never leaves a follow edge behind. Blocking is idempotent; blocking a user
+// that does not exist returns ErrNotFound.
+func (s *BlocksStorage) Block(ctx context.Context, blockerID, blockedID int64) error {
+	if blockerID == blockedID {
+		return ErrSelfBlock
+	}
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		return s.BlockTx(ctx, tx, blockerID, blockedID)
+	})
+}
+
+func (s *BlocksStorage) BlockTx(ctx context.Context, q Querier, blockerID, blockedID int64) error {
+	ctx, span := startSpan(ctx, "Blocks.Block")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if blockerID == blockedID {
+		return ErrSelfBlock
+	}
+
+	query := `
+		INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2)
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+	`
+
+	if _, err := q.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pgForeignKeyViolation {
+			return ErrNotFound
+		}
+		return ctxErr(ctx, err)
+	}
+
+	query = `
+		DELETE FROM followers
+		WHERE (user_id = $1 AND follower_id = $2)
+		   OR (user_id = $2 AND follower_id = $1)
+	`
+
+	_, err := q.ExecContext(ctx, query, blockerID, blockedID)
+	return ctxErr(ctx, err)
+}
+
+func (s *BlocksStorage) Unblock(ctx context.Context, blockerID, blockedID int64) error {
+	ctx, span := startSpan(ctx, "Blocks.Unblock")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2`
+
+	_, err := s.db.ExecContext(ctx, query, blockerID, blockedID)
+	return ctxErr(ctx, err)
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestBlockSelf(t *testing.T) {
+	s, _ := newMockStorage(t)
+
+	// No transaction is expected: the mock fails the test if one starts.
+	if err := s.Blocks.Block(context.Background(), 1, 1); !errors.Is(err, ErrSelfBlock) {
+		t.Errorf("Block(1, 1) error = %v, want ErrSelfBlock", err)
+	}
+}
+
+func TestBlockRemovesFollowsInTransaction(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO blocks`).WithArgs(int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM followers`).WithArgs(int64(1), int64(2)).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	if err := s.Blocks.Block(context.Background(), 1, 2); err != nil {
+		t.Errorf("Block() error = %v", err)
+	}
+}
+
+func TestBlockUnknownUser(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO blocks`).WillReturnError(&pq.Error{Code: pgForeignKeyViolation})
+	mock.ExpectRollback()
+
+	if err := s.Blocks.Block(context.Background(), 1, 99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Block() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestBlockIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+	carol := createTestUser(t, s, "carol")
+
+	for _, f := range [][2]int64{{alice.ID, bob.ID}, {bob.ID, alice.ID}, {alice.ID, carol.ID}} {
+		if err := s.Followers.Follow(ctx, f[0], f[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fromBob := createTestPost(t, s, bob, "bob's post")
+	fromCarol := createTestPost(t, s, carol, "carol's post")
+
+	feedIDs := func() []int64 {
+		t.Helper()
+		feed, err := s.Posts.GetUserFeed(ctx, alice.ID, FeedQuery{Limit: 20, Sort: "desc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		for _, p := range feed {
+			ids = append(ids, p.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	if got := feedIDs(); !slices.Equal(got, []int64{fromBob.ID, fromCarol.ID}) {
+		t.Fatalf("feed before the block = %v, want bob's and carol's posts", got)
+	}
+
+	if err := s.Blocks.Block(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatalf("Block() error = %v", err)
+	}
+	if err := s.Blocks.Block(ctx, alice.ID, bob.ID); err != nil {
+		t.Errorf("Block() again error = %v, want it to be idempotent", err)
+	}
+
+	if got := feedIDs(); !slices.Equal(got, []int64{fromCarol.ID}) {
+		t.Errorf("feed after the block = %v, want only carol's post", got)
+	}
+	between := `SELECT COUNT(*) FROM followers WHERE (user_id = $1 AND follower_id = $2) OR (user_id = $2 AND follower_id = $1)`
+	if n := countRows(t, s, between, alice.ID, bob.ID); n != 0 {
+		t.Errorf("%d follow edges left between alice and bob, want none", n)
+	}
+	if n := countRows(t, s, between, alice.ID, carol.ID); n != 1 {
+		t.Errorf("alice's follow of carol was removed too")
+	}
+
+	// Neither side can follow the other while the block stands.
+	if err := s.Followers.Follow(ctx, alice.ID, bob.ID); !errors.Is(err, ErrBlocked) {
+		t.Errorf("blocker Follow() error = %v, want ErrBlocked", err)
+	}
+	if err := s.Followers.Follow(ctx, bob.ID, alice.ID); !errors.Is(err, ErrBlocked) {
+		t.Errorf("blocked Follow() error = %v, want ErrBlocked", err)
+	}
+	if _, err := s.Followers.FollowMany(ctx, bob.ID, []int64{alice.ID, carol.ID}); !errors.Is(err, ErrBlocked) {
+		t.Errorf("FollowMany() error = %v, want ErrBlocked", err)
+	}
+	if n := countRows(t, s, `SELECT COUNT(*) FROM followers WHERE follower_id = $1`, bob.ID); n != 0 {
+		t.Errorf("bob follows %d users after a rejected batch, want none", n)
+	}
+
+	if err := s.Blocks.Unblock(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatalf("Unblock() error = %v", err)
+	}
+	if err := s.Followers.Follow(ctx, bob.ID, alice.ID); err != nil {
+		t.Errorf("Follow() after Unblock error = %v", err)
+	}
+}
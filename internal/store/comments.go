@@ -0,0 +1,241 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Comment struct {
+	ID        int64  `json:"id"`
+	PostID    int64  `json:"post_id"`
+	UserID    int64  `json:"user_id"`
+	ParentID  *int64 `json:"parent_id"`
+	Depth     int    `json:"depth"`
+	Username  string `json:"username,omitempty"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CommentThread is a comment together with its replies, oldest first.
+type CommentThread struct {
+	Comment
+	Replies []*CommentThread `json:"replies"`
+}
+
+type CommentsStorage struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func (s *CommentsStorage) Create(ctx context.Context, comment *Comment) error {
+	return s.CreateTx(ctx, s.db, comment)
+}
+
+func (s *CommentsStorage) CreateTx(ctx context.Context, q Querier, comment *Comment) error {
+	ctx, span := startSpan(ctx, "Comments.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO comments (post_id, user_id, parent_id, depth, content)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at
+	`
+
+	err := q.QueryRowContext(
+		ctx,
+		query,
+		comment.PostID,
+		comment.UserID,
+		comment.ParentID,
+		comment.Depth,
+		comment.Content,
+	).Scan(
+		&comment.ID,
+		&comment.CreatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pgForeignKeyViolation {
+			return ErrNotFound
+		}
+		return ctxErr(ctx, err)
+	}
+
+	return nil
+}
+
+func (s *CommentsStorage) GetByID(ctx context.Context, id int64) (*Comment, error) {
+	ctx, span := startSpan(ctx, "Comments.GetByID")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.depth,
+			CASE WHEN u.deleted_at IS NULL THEN u.username ELSE 'deleted user' END AS username,
+			c.content, c.created_at
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1
+	`
+
+	comment := &Comment{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.PostID,
+		&comment.UserID,
+		&comment.ParentID,
+		&comment.Depth,
+		&comment.Username,
+		&comment.Content,
+		&comment.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxErr(ctx, err)
+		}
+	}
+
+	return comment, nil
+}
+
+func (s *CommentsStorage) Delete(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "Comments.Delete")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `DELETE FROM comments WHERE id = $1`
+
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *CommentsStorage) GetByPost(ctx context.Context, postID int64) ([]Comment, error) {
+	ctx, span := startSpan(ctx, "Comments.GetByPost")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.depth,
+			CASE WHEN u.deleted_at IS NULL THEN u.username ELSE 'deleted user' END AS username,
+			c.content, c.created_at
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.post_id = $1
+		ORDER BY c.created_at DESC, c.id DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	comments := []Comment{}
+	for rows.Next() {
+		var c Comment
+		err := rows.Scan(
+			&c.ID,
+			&c.PostID,
+			&c.UserID,
+			&c.ParentID,
+			&c.Depth,
+			&c.Username,
+			&c.Content,
+			&c.CreatedAt,
+		)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+
+		comments = append(comments, c)
+	}
+
+	return comments, ctxErr(ctx, rows.Err())
+}
+
+// GetThread returns the comments of postID as a tree: top-level comments
+// with their replies nested under them, every level ordered oldest first so
+// a conversation reads top to bottom.
+func (s *CommentsStorage) GetThread(ctx context.Context, postID int64) ([]*CommentThread, error) {
+	ctx, span := startSpan(ctx, "Comments.GetThread")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.depth,
+			CASE WHEN u.deleted_at IS NULL THEN u.username ELSE 'deleted user' END AS username,
+			c.content, c.created_at
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.post_id = $1
+		ORDER BY c.created_at, c.id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	roots := []*CommentThread{}
+	byID := make(map[int64]*CommentThread)
+	for rows.Next() {
+		t := &CommentThread{Replies: []*CommentThread{}}
+		err := rows.Scan(
+			&t.ID,
+			&t.PostID,
+			&t.UserID,
+			&t.ParentID,
+			&t.Depth,
+			&t.Username,
+			&t.Content,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		byID[t.ID] = t
+
+		// A reply is never older than its parent, so the parent has
+		// already been seen.
+		if t.ParentID != nil {
+			if parent, ok := byID[*t.ParentID]; ok {
+				parent.Replies = append(parent.Replies, t)
+				continue
+			}
+		}
+		roots = append(roots, t)
+	}
+
+	return roots, ctxErr(ctx, rows.Err())
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCommentsCreateAndListIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+	post := createTestPost(t, s, alice, "hello")
+	other := createTestPost(t, s, alice, "elsewhere")
+
+	first := &Comment{PostID: post.ID, UserID: bob.ID, Content: "first"}
+	second := &Comment{PostID: post.ID, UserID: alice.ID, Content: "second"}
+	for _, c := range []*Comment{first, second, {PostID: other.ID, UserID: bob.ID, Content: "unrelated"}} {
+		if err := s.Comments.Create(ctx, c); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if c.ID == 0 || c.CreatedAt == "" {
+			t.Fatalf("Create() did not fill in the generated fields: %+v", c)
+		}
+	}
+
+	comments, err := s.Comments.GetByPost(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("GetByPost() error = %v", err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("GetByPost() returned %d comments, want 2", len(comments))
+	}
+
+	// Newest first, each with the commenter's username.
+	if comments[0].ID != second.ID || comments[0].Username != "alice" {
+		t.Errorf("comments[0] = %+v, want the second comment by alice", comments[0])
+	}
+	if comments[1].ID != first.ID || comments[1].Username != "bob" {
+		t.Errorf("comments[1] = %+v, want the first comment by bob", comments[1])
+	}
+}
+
+func TestCommentsCreateOnMissingPostIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	alice := createTestUser(t, s, "alice")
+
+	err := s.Comments.Create(context.Background(), &Comment{PostID: 999999, UserID: alice.ID, Content: "lost"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Create() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCommentsGetThread(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(`WHERE c.post_id = \$1\s+ORDER BY c.created_at, c.id`).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "user_id", "parent_id", "depth", "username", "content", "created_at"}).
+			AddRow(1, 5, 2, nil, 0, "bob", "root", "2024-01-01T00:00:00Z").
+			AddRow(2, 5, 1, 1, 1, "alice", "reply", "2024-01-02T00:00:00Z").
+			AddRow(3, 5, 2, 2, 2, "bob", "reply to reply", "2024-01-03T00:00:00Z").
+			AddRow(4, 5, 1, nil, 0, "alice", "second root", "2024-01-04T00:00:00Z").
+			AddRow(5, 5, 2, 1, 1, "bob", "late reply", "2024-01-05T00:00:00Z"))
+
+	thread, err := s.Comments.GetThread(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("GetThread() error = %v", err)
+	}
+
+	// ids flattens a level of the tree to its comment IDs.
+	ids := func(level []*CommentThread) []int64 {
+		out := []int64{}
+		for _, c := range level {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	if got := ids(thread); !slices.Equal(got, []int64{1, 4}) {
+		t.Fatalf("top level = %v, want [1 4]", got)
+	}
+	if got := ids(thread[0].Replies); !slices.Equal(got, []int64{2, 5}) {
+		t.Errorf("replies to 1 = %v, want [2 5]", got)
+	}
+	if got := ids(thread[0].Replies[0].Replies); !slices.Equal(got, []int64{3}) {
+		t.Errorf("replies to 2 = %v, want [3]", got)
+	}
+	if thread[1].Replies == nil || len(thread[1].Replies) != 0 {
+		t.Errorf("replies to 4 = %v, want an empty list", thread[1].Replies)
+	}
+}
+
+func TestCommentsThreadIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+	post := createTestPost(t, s, alice, "hello")
+
+	root := &Comment{PostID: post.ID, UserID: bob.ID, Content: "root"}
+	if err := s.Comments.Create(ctx, root); err != nil {
+		t.Fatal(err)
+	}
+	reply := &Comment{PostID: post.ID, UserID: alice.ID, ParentID: &root.ID, Depth: 1, Content: "reply"}
+	if err := s.Comments.Create(ctx, reply); err != nil {
+		t.Fatalf("Create(reply) error = %v", err)
+	}
+
+	stored, err := s.Comments.GetByID(ctx, reply.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ParentID == nil || *stored.ParentID != root.ID || stored.Depth != 1 {
+		t.Errorf("stored reply = %+v, want parent %d at depth 1", stored, root.ID)
+	}
+
+	thread, err := s.Comments.GetThread(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("GetThread() error = %v", err)
+	}
+	if len(thread) != 1 || thread[0].ID != root.ID || len(thread[0].Replies) != 1 || thread[0].Replies[0].ID != reply.ID {
+		t.Errorf("thread = %+v, want the reply nested under the root", thread)
+	}
+
+	missing := int64(999999)
+	err = s.Comments.Create(ctx, &Comment{PostID: post.ID, UserID: alice.ID, ParentID: &missing, Depth: 1, Content: "orphan"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Create() under a missing parent error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ctxErr makes cancellation visible to callers. When ctx is done the driver
+// typically reports its own error (for Postgres, "canceling statement due to
+// user request") rather than the context error, so the result is wrapped so
+// that errors.Is(err, context.Canceled) and errors.Is(err,
+// context.DeadlineExceeded) hold. Other errors are returned unchanged.
+func ctxErr(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	cerr := ctx.Err()
+	if cerr == nil || errors.Is(err, cerr) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", cerr, err)
+}
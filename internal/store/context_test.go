@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCtxErr(t *testing.T) {
+	driverErr := errors.New("pq: canceling statement due to user request")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := ctxErr(context.Background(), driverErr); err != driverErr {
+		t.Errorf("live context: ctxErr() = %v, want the driver error unchanged", err)
+	}
+	if err := ctxErr(cancelled, nil); err != nil {
+		t.Errorf("ctxErr(nil) = %v, want nil", err)
+	}
+	if err := ctxErr(cancelled, context.Canceled); err != context.Canceled {
+		t.Errorf("ctxErr(context.Canceled) = %v, want it unchanged", err)
+	}
+
+	err := ctxErr(cancelled, driverErr)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, driverErr) {
+		t.Errorf("cancelled context: ctxErr() = %v, want it to wrap context.Canceled and the driver error", err)
+	}
+}
+
+// TestCancelledContext checks that store methods called after the client
+// went away report context.Canceled instead of a generic database error.
+func TestCancelledContext(t *testing.T) {
+	calls := map[string]func(context.Context, Storage) error{
+		"Users.GetByID": func(ctx context.Context, s Storage) error {
+			_, err := s.Users.GetByID(ctx, 1)
+			return err
+		},
+		"Users.List": func(ctx context.Context, s Storage) error {
+			_, _, err := s.Users.List(ctx, 20, 0, "-created_at")
+			return err
+		},
+		"Posts.List": func(ctx context.Context, s Storage) error {
+			_, _, err := s.Posts.List(ctx, PostFilter{Limit: 20})
+			return err
+		},
+		"Posts.Delete": func(ctx context.Context, s Storage) error {
+			return s.Posts.Delete(ctx, 1)
+		},
+		"RefreshTokens.Revoke": func(ctx context.Context, s Storage) error {
+			return s.RefreshTokens.Revoke(ctx, "token")
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			// No expectations: the call must give up before reaching the
+			// database.
+			s, _ := newMockStorage(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			if err := call(ctx, s); !errors.Is(err, context.Canceled) {
+				t.Errorf("error = %v, want it to wrap context.Canceled", err)
+			}
+		})
+	}
+}
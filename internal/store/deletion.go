@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DeleteMode selects what UsersStorage.Delete does with an account.
+type DeleteMode string
+
+const (
+	// DeleteHard removes the user row; posts, comments, follows, likes and
+	// everything else owned by the user go with it through ON DELETE CASCADE.
+	DeleteHard DeleteMode = "hard"
+	// DeleteAnonymize scrubs the user's personal data but keeps their posts
+	// and comments, which are then shown as written by "deleted user".
+	DeleteAnonymize DeleteMode = "anonymize"
+)
+
+// ParseDeleteMode validates a DeleteMode read from configuration.
+func ParseDeleteMode(s string) (DeleteMode, error) {
+	switch mode := DeleteMode(s); mode {
+	case DeleteHard, DeleteAnonymize:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown delete mode %q, want %q or %q", s, DeleteHard, DeleteAnonymize)
+	}
+}
+
+// Delete closes the account of user id in a single transaction, either
+// removing it outright or anonymizing it according to mode. Either way the
+// user's refresh tokens are gone afterwards, so no session outlives the
+// account. It returns ErrNotFound when no live user has that ID.
+func (s *UsersStorage) Delete(ctx context.Context, id int64, mode DeleteMode) error {
+	if _, err := ParseDeleteMode(string(mode)); err != nil {
+		return err
+	}
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, span := startSpan(ctx, "Users.Delete")
+		defer span.End()
+
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+
+		if mode == DeleteHard {
+			return execOne(ctx, tx, `DELETE FROM users WHERE id = $1 AND deleted_at IS NULL`, id)
+		}
+
+		// The placeholders are unique per ID so the constraints hold, and
+		// none can be registered: usernames may not contain ':' and an email
+		// needs an '@'. Otherwise anyone could claim the placeholder for an
+		// ID and leave that account impossible to close. An empty password
+		// hash matches no password.
+		query := `
+			UPDATE users SET
+				username = 'deleted:' || id,
+				email = 'deleted:' || id,
+				password = '',
+				avatar_url = '',
+				role = 'user',
+				is_active = FALSE,
+				deleted_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+		`
+		if err := execOne(ctx, tx, query, id); err != nil {
+			return err
+		}
+
+		// Content is kept; the user's ties to other people are not.
+		cleanup := []string{
+			`DELETE FROM followers WHERE user_id = $1 OR follower_id = $1`,
+			`DELETE FROM blocks WHERE blocker_id = $1 OR blocked_id = $1`,
+			`DELETE FROM likes WHERE user_id = $1`,
+			`DELETE FROM notifications WHERE user_id = $1 OR actor_id = $1`,
+			`DELETE FROM idempotency_keys WHERE user_id = $1`,
+			`DELETE FROM user_invitations WHERE user_id = $1`,
+			`DELETE FROM password_resets WHERE user_id = $1`,
+			`DELETE FROM refresh_tokens WHERE user_id = $1`,
+		}
+		for _, query := range cleanup {
+			if _, err := tx.ExecContext(ctx, query, id); err != nil {
+				return ctxErr(ctx, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// execOne runs query and returns ErrNotFound unless it affected a row.
+func execOne(ctx context.Context, q Querier, query string, args ...any) error {
+	res, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParseDeleteMode(t *testing.T) {
+	for _, s := range []string{"hard", "anonymize"} {
+		if mode, err := ParseDeleteMode(s); err != nil || string(mode) != s {
+			t.Errorf("ParseDeleteMode(%q) = %q, %v", s, mode, err)
+		}
+	}
+	for _, s := range []string{"", "soft", "HARD"} {
+		if _, err := ParseDeleteMode(s); err == nil {
+			t.Errorf("ParseDeleteMode(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestUsersDelete(t *testing.T) {
+	t.Run("hard", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM users WHERE id = \$1 AND deleted_at IS NULL`).
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := s.Users.Delete(context.Background(), 7, DeleteHard); err != nil {
+			t.Errorf("Delete() error = %v", err)
+		}
+	})
+
+	t.Run("anonymize", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET\s+username = 'deleted:' \|\| id,\s+email = 'deleted:' \|\| id,(.|\n)+deleted_at = NOW\(\)\s+WHERE id = \$1 AND deleted_at IS NULL`).
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		for _, table := range []string{"followers", "blocks", "likes", "notifications", "idempotency_keys", "user_invitations", "password_resets", "refresh_tokens"} {
+			mock.ExpectExec(`DELETE FROM ` + table + ` WHERE`).
+				WithArgs(int64(7)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectCommit()
+
+		if err := s.Users.Delete(context.Background(), 7, DeleteAnonymize); err != nil {
+			t.Errorf("Delete() error = %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET`).
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		if err := s.Users.Delete(context.Background(), 7, DeleteAnonymize); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		s, _ := newMockStorage(t)
+
+		if err := s.Users.Delete(context.Background(), 7, DeleteMode("soft")); err == nil {
+			t.Error("Delete() with an unknown mode succeeded")
+		}
+	})
+}
+
+func TestUsersDeleteIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	// account sets up a user with a post, a comment on someone else's post,
+	// a like, a follow in each direction and a live refresh token.
+	account := func(name string, other *User) (*User, *Post) {
+		t.Helper()
+
+		user := createTestUser(t, s, name)
+		post := createTestPost(t, s, user, name+" writes")
+		otherPost := createTestPost(t, s, other, other.Username+" writes for "+name)
+		if err := s.Comments.Create(ctx, &Comment{PostID: otherPost.ID, UserID: user.ID, Content: "hi"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Likes.Like(ctx, user.ID, otherPost.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Followers.Follow(ctx, user.ID, other.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Followers.Follow(ctx, other.ID, user.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RefreshTokens.Create(ctx, user.ID, name+"-refresh", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		return user, post
+	}
+	count := func(query string, id int64) int {
+		t.Helper()
+		return countRows(t, s, query, id)
+	}
+
+	bob := createTestUser(t, s, "bob")
+
+	t.Run("hard", func(t *testing.T) {
+		alice, _ := account("alice", bob)
+
+		if err := s.Users.Delete(ctx, alice.ID, DeleteHard); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+
+		for _, query := range []string{
+			`SELECT COUNT(*) FROM users WHERE id = $1`,
+			`SELECT COUNT(*) FROM posts WHERE user_id = $1`,
+			`SELECT COUNT(*) FROM comments WHERE user_id = $1`,
+			`SELECT COUNT(*) FROM likes WHERE user_id = $1`,
+			`SELECT COUNT(*) FROM followers WHERE user_id = $1 OR follower_id = $1`,
+			`SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1`,
+		} {
+			if n := count(query, alice.ID); n != 0 {
+				t.Errorf("%s = %d, want 0", query, n)
+			}
+		}
+		if n := count(`SELECT COUNT(*) FROM posts WHERE user_id = $1`, bob.ID); n == 0 {
+			t.Error("bob's posts went with alice's account")
+		}
+
+		if err := s.Users.Delete(ctx, alice.ID, DeleteHard); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second Delete() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("anonymize", func(t *testing.T) {
+		carol, post := account("carol", bob)
+
+		if err := s.Users.Delete(ctx, carol.ID, DeleteAnonymize); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+
+		// The row stays, scrubbed, and can no longer sign in.
+		var username, email, password string
+		var active bool
+		err := s.db.QueryRow(`SELECT username, email, password, is_active FROM users WHERE id = $1 AND deleted_at IS NOT NULL`, carol.ID).
+			Scan(&username, &email, &password, &active)
+		if err != nil {
+			t.Fatalf("reading the anonymized user: %v", err)
+		}
+		if username == carol.Username || email == carol.Email || password != "" || active {
+			t.Errorf("anonymized user = %q, %q, password %q, active %v; want the personal data gone", username, email, password, active)
+		}
+		if _, err := s.Users.GetByID(ctx, carol.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByID() error = %v, want ErrNotFound", err)
+		}
+
+		// Content is kept under "deleted user"; ties to people are not.
+		if n := count(`SELECT COUNT(*) FROM posts WHERE user_id = $1`, carol.ID); n != 1 {
+			t.Errorf("posts kept = %d, want 1", n)
+		}
+		if n := count(`SELECT COUNT(*) FROM comments WHERE user_id = $1`, carol.ID); n != 1 {
+			t.Errorf("comments kept = %d, want 1", n)
+		}
+		details, err := s.Posts.GetWithDetails(ctx, post.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Username != "deleted user" {
+			t.Errorf("post author = %q, want %q", details.Username, "deleted user")
+		}
+		for _, query := range []string{
+			`SELECT COUNT(*) FROM likes WHERE user_id = $1`,
+			`SELECT COUNT(*) FROM followers WHERE user_id = $1 OR follower_id = $1`,
+			`SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1`,
+		} {
+			if n := count(query, carol.ID); n != 0 {
+				t.Errorf("%s = %d, want 0", query, n)
+			}
+		}
+
+		if err := s.Users.Delete(ctx, carol.ID, DeleteAnonymize); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second Delete() error = %v, want ErrNotFound", err)
+		}
+	})
+	t.Run("placeholder taken", func(t *testing.T) {
+		dave, _ := account("dave", bob)
+
+		// Squat every name an anonymized dave could plausibly be given that
+		// registration would still accept.
+		squatter := &User{
+			Username: fmt.Sprintf("deleted-%d", dave.ID),
+			Email:    fmt.Sprintf("deleted-%d@deleted.invalid", dave.ID),
+			Password: "correct horse",
+			IsActive: true,
+		}
+		if err := s.Users.Create(ctx, squatter); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Users.Delete(ctx, dave.ID, DeleteAnonymize); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+
+		var username string
+		if err := s.db.QueryRow(`SELECT username FROM users WHERE id = $1`, dave.ID).Scan(&username); err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("deleted:%d", dave.ID); username != want {
+			t.Errorf("anonymized username = %q, want %q", username, want)
+		}
+	})
+}
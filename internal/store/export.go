@@ -0,0 +1,222 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Export sections, in the order ExportStorage.Export produces them.
+const (
+	ExportSectionPosts     = "posts"
+	ExportSectionComments  = "comments"
+	ExportSectionFollowing = "following"
+	ExportSectionFollowers = "followers"
+)
+
+// Follow is one edge of the follow graph as seen from the exported user:
+// the other user and when the follow was made.
+type Follow struct {
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ExportSink receives a user's data as it is read. Export calls Profile
+// once, then Section before the rows of each section, every section being
+// announced even when it is empty. Returning an error stops the export.
+type ExportSink interface {
+	Profile(*User) error
+	Section(name string) error
+	Post(*Post) error
+	Comment(*Comment) error
+	Follow(*Follow) error
+}
+
+type ExportStorage struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// Export streams everything stored about userID into sink from a single
+// read-only snapshot, so the sections agree with each other. Rows are handed
+// over one at a time rather than collected, keeping memory flat however much
+// the user has written. It returns ErrNotFound when the user does not exist.
+//
+// Only the profile lookup is bound by the query timeout. The section queries
+// stay open for as long as sink takes to write their rows to the client, so
+// they run until ctx is done instead.
+func (s *ExportStorage) Export(ctx context.Context, userID int64, sink ExportSink) error {
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+	return withTxOptions(ctx, s.db, opts, func(tx *sql.Tx) error {
+		ctx, span := startSpan(ctx, "Export.Export")
+		defer span.End()
+
+		if err := s.exportProfile(ctx, tx, userID, sink); err != nil {
+			return err
+		}
+		if err := s.exportPosts(ctx, tx, userID, sink); err != nil {
+			return err
+		}
+		if err := s.exportComments(ctx, tx, userID, sink); err != nil {
+			return err
+		}
+
+		following := `
+			SELECT u.id, u.username, f.created_at
+			FROM followers f
+			JOIN users u ON u.id = f.user_id
+			WHERE f.follower_id = $1
+			ORDER BY f.created_at, u.id
+		`
+		if err := s.exportFollows(ctx, tx, ExportSectionFollowing, following, userID, sink); err != nil {
+			return err
+		}
+
+		followers := `
+			SELECT u.id, u.username, f.created_at
+			FROM followers f
+			JOIN users u ON u.id = f.follower_id
+			WHERE f.user_id = $1
+			ORDER BY f.created_at, u.id
+		`
+		return s.exportFollows(ctx, tx, ExportSectionFollowers, followers, userID, sink)
+	})
+}
+
+func (s *ExportStorage) exportProfile(ctx context.Context, q Querier, userID int64, sink ExportSink) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, username, email, role, is_active, avatar_url, created_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	user := &User{}
+	err := q.QueryRowContext(ctx, query, userID).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Role,
+		&user.IsActive,
+		&user.AvatarURL,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNotFound
+		default:
+			return ctxErr(ctx, err)
+		}
+	}
+
+	return sink.Profile(user)
+}
+
+func (s *ExportStorage) exportPosts(ctx context.Context, q Querier, userID int64, sink ExportSink) error {
+	if err := sink.Section(ExportSectionPosts); err != nil {
+		return err
+	}
+
+	query := `
+		SELECT p.id, p.user_id, p.title, p.content, p.tags, p.version, p.created_at, p.updated_at,
+			(SELECT COUNT(*) FROM likes l WHERE l.post_id = p.id) AS likes_count
+		FROM posts p
+		WHERE p.user_id = $1
+		ORDER BY p.created_at, p.id
+	`
+
+	rows, err := q.QueryContext(ctx, query, userID)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p Post
+		err := rows.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.Title,
+			&p.Content,
+			pq.Array(&p.Tags),
+			&p.Version,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+			&p.LikesCount,
+		)
+		if err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		if err := sink.Post(&p); err != nil {
+			return err
+		}
+	}
+
+	return ctxErr(ctx, rows.Err())
+}
+
+func (s *ExportStorage) exportComments(ctx context.Context, q Querier, userID int64, sink ExportSink) error {
+	if err := sink.Section(ExportSectionComments); err != nil {
+		return err
+	}
+
+	query := `
+		SELECT id, post_id, user_id, parent_id, depth, content, created_at
+		FROM comments
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := q.QueryContext(ctx, query, userID)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c Comment
+		if err := rows.Scan(&c.ID, &c.PostID, &c.UserID, &c.ParentID, &c.Depth, &c.Content, &c.CreatedAt); err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		if err := sink.Comment(&c); err != nil {
+			return err
+		}
+	}
+
+	return ctxErr(ctx, rows.Err())
+}
+
+func (s *ExportStorage) exportFollows(ctx context.Context, q Querier, section, query string, userID int64, sink ExportSink) error {
+	if err := sink.Section(section); err != nil {
+		return err
+	}
+
+	rows, err := q.QueryContext(ctx, query, userID)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f Follow
+		if err := rows.Scan(&f.UserID, &f.Username, &f.CreatedAt); err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		if err := sink.Follow(&f); err != nil {
+			return err
+		}
+	}
+
+	return ctxErr(ctx, rows.Err())
+}
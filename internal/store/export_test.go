@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// recordingSink notes every call Export makes, in order.
+type recordingSink struct {
+	calls []string
+	// postDelay stands in for a slow client on the other end of the stream.
+	postDelay time.Duration
+}
+
+func (s *recordingSink) Profile(u *User) error {
+	s.calls = append(s.calls, "profile "+u.Username)
+	return nil
+}
+
+func (s *recordingSink) Section(name string) error {
+	s.calls = append(s.calls, "section "+name)
+	return nil
+}
+
+func (s *recordingSink) Post(p *Post) error {
+	time.Sleep(s.postDelay)
+	s.calls = append(s.calls, "post "+p.Title)
+	return nil
+}
+
+func (s *recordingSink) Comment(c *Comment) error {
+	s.calls = append(s.calls, "comment "+c.Content)
+	return nil
+}
+
+func (s *recordingSink) Follow(f *Follow) error {
+	s.calls = append(s.calls, "follow "+f.Username)
+	return nil
+}
+
+// expectExport sets up the queries of an export of user 7 with the given
+// posts and nothing else.
+func expectExport(mock sqlmock.Sqlmock, posts ...string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM users\s+WHERE id = \$1 AND deleted_at IS NULL`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(7, "alice", "alice@example.com", RoleUser, true, "", "2024-01-01T00:00:00Z"))
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "title", "content", "tags", "version", "created_at", "updated_at", "likes_count"})
+	for i, title := range posts {
+		rows.AddRow(i+1, 7, title, "content", "{}", 1, "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", 0)
+	}
+	mock.ExpectQuery(`FROM posts p\s+WHERE p.user_id = \$1`).WithArgs(int64(7)).WillReturnRows(rows)
+	mock.ExpectQuery(`FROM comments\s+WHERE user_id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "user_id", "parent_id", "depth", "content", "created_at"}))
+	for range 2 {
+		mock.ExpectQuery(`FROM followers f`).
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "created_at"}))
+	}
+	mock.ExpectCommit()
+}
+
+func TestExportSectionsInOrder(t *testing.T) {
+	s, mock := newMockStorage(t)
+	expectExport(mock, "first", "second")
+
+	sink := &recordingSink{}
+	if err := s.Export.Export(context.Background(), 7, sink); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	want := []string{
+		"profile alice",
+		"section " + ExportSectionPosts, "post first", "post second",
+		"section " + ExportSectionComments,
+		"section " + ExportSectionFollowing,
+		"section " + ExportSectionFollowers,
+	}
+	if !slices.Equal(sink.calls, want) {
+		t.Errorf("sink calls = %q, want %q", sink.calls, want)
+	}
+}
+
+// TestExportOutlivesQueryTimeout checks that a section taking longer to
+// stream than the query timeout is not cut off by it.
+func TestExportOutlivesQueryTimeout(t *testing.T) {
+	db, mock := newMockDB(t)
+	s := NewStorage(db, nil, 20*time.Millisecond, testPasswordCost)
+
+	var titles []string
+	for i := range 5 {
+		titles = append(titles, fmt.Sprintf("post %d", i))
+	}
+	expectExport(mock, titles...)
+
+	sink := &recordingSink{postDelay: 10 * time.Millisecond}
+	if err := s.Export.Export(context.Background(), 7, sink); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	var exported int
+	for _, call := range sink.calls {
+		if strings.HasPrefix(call, "post ") {
+			exported++
+		}
+	}
+	if exported != len(titles) {
+		t.Errorf("exported %d posts, want %d", exported, len(titles))
+	}
+}
+
+func TestExportIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+
+	post := createTestPost(t, s, alice, "alice writes")
+	createTestPost(t, s, bob, "bob writes")
+	if err := s.Comments.Create(ctx, &Comment{PostID: post.ID, UserID: alice.ID, Content: "own reply"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Followers.Follow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &recordingSink{}
+	if err := s.Export.Export(ctx, alice.ID, sink); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	want := []string{
+		"profile alice",
+		"section " + ExportSectionPosts, "post alice writes",
+		"section " + ExportSectionComments, "comment own reply",
+		"section " + ExportSectionFollowing, "follow bob",
+		"section " + ExportSectionFollowers,
+	}
+	if !slices.Equal(sink.calls, want) {
+		t.Errorf("sink calls = %q, want %q", sink.calls, want)
+	}
+
+	if err := s.Export.Export(ctx, 999999, &recordingSink{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Export(unknown) error = %v, want ErrNotFound", err)
+	}
+}
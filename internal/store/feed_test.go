@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetUserFeedOnlyFollowedAuthorsIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+	carol := createTestUser(t, s, "carol")
+
+	own := createTestPost(t, s, alice, "alice writes")
+	followed := createTestPost(t, s, bob, "bob writes")
+	createTestPost(t, s, carol, "carol writes")
+
+	if err := s.Followers.Follow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := s.Posts.GetUserFeed(ctx, alice.ID, FeedQuery{Limit: 10, Sort: "desc"})
+	if err != nil {
+		t.Fatalf("GetUserFeed() error = %v", err)
+	}
+
+	got := make(map[int64]string, len(feed))
+	for _, p := range feed {
+		got[p.ID] = p.Username
+	}
+	if len(got) != 2 || got[own.ID] != "alice" || got[followed.ID] != "bob" {
+		t.Errorf("feed = %v, want alice's and bob's posts only", got)
+	}
+}
+
+func TestGetUserFeedPaginationIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	var want []int64
+	for i := 0; i < 5; i++ {
+		want = append(want, createTestPost(t, s, alice, fmt.Sprintf("post %d", i)).ID)
+	}
+
+	for _, sort := range []string{"desc", "asc"} {
+		t.Run(sort, func(t *testing.T) {
+			var (
+				got    []int64
+				cursor string
+			)
+			for page := 0; page < 3; page++ {
+				feed, err := s.Posts.GetUserFeed(ctx, alice.ID, FeedQuery{Limit: 2, Sort: sort, Cursor: cursor})
+				if err != nil {
+					t.Fatalf("page %d: GetUserFeed() error = %v", page, err)
+				}
+				if len(feed) == 0 {
+					t.Fatalf("page %d is empty", page)
+				}
+				for _, p := range feed {
+					got = append(got, p.ID)
+				}
+				last := feed[len(feed)-1]
+				cursor = EncodeCursor(FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+			}
+
+			// Posts created in one go can share a timestamp, so the
+			// expected order is by ID, the keyset tie-breaker.
+			expected := slices.Clone(want)
+			if sort == "desc" {
+				slices.Reverse(expected)
+			}
+			if !slices.Equal(got, expected) {
+				t.Errorf("paged IDs = %v, want %v with no gaps or repeats", got, expected)
+			}
+		})
+	}
+}
+
+func TestPostsGetByUser(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(`WHERE p.user_id = \$1(.|\n)+ORDER BY p.created_at DESC, p.id DESC\s+LIMIT \$4`).
+		WithArgs(int64(3), nil, nil, 2).
+		WillReturnRows(sqlmock.NewRows(feedColumns).
+			AddRow(12, 3, "Newer", "b", "{}", 2, "2024-01-02T00:00:00Z", "2024-01-02T00:00:00Z", "alice", 4, 7).
+			AddRow(11, 3, "Older", "a", "{go}", 1, "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", "alice", 0, 1))
+
+	posts, err := s.Posts.GetByUser(context.Background(), 3, FeedQuery{Limit: 2, Sort: "desc"})
+	if err != nil {
+		t.Fatalf("GetByUser() error = %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != 12 || posts[1].ID != 11 {
+		t.Fatalf("GetByUser() = %+v, want posts 12 and 11", posts)
+	}
+	if p := posts[0]; p.Username != "alice" || p.Version != 2 || p.CommentsCount != 4 || p.LikesCount != 7 {
+		t.Errorf("first post = %+v, want alice's version 2 with 4 comments and 7 likes", p)
+	}
+}
+
+// feedColumns are the columns GetUserFeed and GetByUser scan, in order.
+var feedColumns = []string{"id", "user_id", "title", "content", "tags", "version", "created_at", "updated_at", "username", "comments_count", "likes_count"}
+
+func TestGetUserFeed(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(`SELECT\s+p.id, p.user_id, p.title, p.content, p.tags, p.version,(.|\n)+follower_id = \$1`).
+		WithArgs(int64(3), nil, nil, 10).
+		WillReturnRows(sqlmock.NewRows(feedColumns).
+			AddRow(12, 4, "Edited", "b", "{}", 5, "2024-01-02T00:00:00Z", "2024-01-03T00:00:00Z", "bob", 1, 2))
+
+	feed, err := s.Posts.GetUserFeed(context.Background(), 3, FeedQuery{Limit: 10, Sort: "desc"})
+	if err != nil {
+		t.Fatalf("GetUserFeed() error = %v", err)
+	}
+	if len(feed) != 1 || feed[0].ID != 12 || feed[0].Username != "bob" {
+		t.Fatalf("GetUserFeed() = %+v, want bob's post 12", feed)
+	}
+	// The version is what a client sends back to edit the post.
+	if feed[0].Version != 5 {
+		t.Errorf("Version = %d, want 5", feed[0].Version)
+	}
+}
+
+func TestPostsGetByUserIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+
+	older := createTestPost(t, s, alice, "older")
+	newer := createTestPost(t, s, alice, "newer")
+	createTestPost(t, s, bob, "bob writes")
+
+	if err := s.Likes.Like(ctx, bob.ID, newer.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"first", "second"} {
+		if err := s.Comments.Create(ctx, &Comment{PostID: newer.ID, UserID: bob.ID, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Edited, so a version of zero would mean the column was not read.
+	newer.Title = "newer, edited"
+	if err := s.Posts.Update(ctx, newer); err != nil {
+		t.Fatal(err)
+	}
+
+	posts, err := s.Posts.GetByUser(ctx, alice.ID, FeedQuery{Limit: 10, Sort: "desc"})
+	if err != nil {
+		t.Fatalf("GetByUser() error = %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != newer.ID || posts[1].ID != older.ID {
+		t.Fatalf("GetByUser() returned %d posts, want alice's two, newest first", len(posts))
+	}
+	if p := posts[0]; p.CommentsCount != 2 || p.LikesCount != 1 {
+		t.Errorf("counts = %d comments, %d likes; want 2 and 1", p.CommentsCount, p.LikesCount)
+	}
+	if p := posts[0]; p.Version != newer.Version {
+		t.Errorf("Version = %d, want the stored %d", p.Version, newer.Version)
+	}
+	if p := posts[1]; p.CommentsCount != 0 || p.LikesCount != 0 {
+		t.Errorf("counts of the older post = %d comments, %d likes; want none", p.CommentsCount, p.LikesCount)
+	}
+
+	none, err := s.Posts.GetByUser(ctx, 999999, FeedQuery{Limit: 10, Sort: "desc"})
+	if err != nil || len(none) != 0 {
+		t.Errorf("GetByUser(unknown) = %v, %v; want no posts", none, err)
+	}
+}
+
+func TestEmptyPostListsEncodeAsEmptyArrays(t *testing.T) {
+	for name, list := range map[string]func(Storage) ([]PostWithMetadata, error){
+		"GetUserFeed": func(s Storage) ([]PostWithMetadata, error) {
+			return s.Posts.GetUserFeed(context.Background(), 3, FeedQuery{Limit: 10, Sort: "desc"})
+		},
+		"GetByUser": func(s Storage) ([]PostWithMetadata, error) {
+			return s.Posts.GetByUser(context.Background(), 3, FeedQuery{Limit: 10, Sort: "desc"})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, mock := newMockStorage(t)
+			mock.ExpectQuery(`FROM posts p`).WillReturnRows(sqlmock.NewRows(feedColumns))
+
+			posts, err := list(s)
+			if err != nil {
+				t.Fatalf("%s() error = %v", name, err)
+			}
+			// Clients get "data": [] from both endpoints, never null.
+			if b, _ := json.Marshal(posts); string(b) != "[]" {
+				t.Errorf("%s() encodes as %s, want []", name, b)
+			}
+		})
+	}
+}
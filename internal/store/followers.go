@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Follower struct {
+	UserID     int64  `json:"user_id"`
+	FollowerID int64  `json:"follower_id"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type FollowersStorage struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func (s *FollowersStorage) Follow(ctx context.Context, followerID, followedID int64) error {
+	return s.FollowTx(ctx, s.db, followerID, followedID)
+}
+
+func (s *FollowersStorage) FollowTx(ctx context.Context, q Querier, followerID, followedID int64) error {
+	ctx, span := startSpan(ctx, "Followers.Follow")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if followerID == followedID {
+		return ErrSelfFollow
+	}
+
+	// The block check is part of the insert so a block committed before the
+	// statement starts can never be raced past; the outer SELECT only tells
+	// a blocked follow apart from one that already existed.
+	query := `
+		WITH inserted AS (
+			INSERT INTO followers (user_id, follower_id)
+			SELECT $1::bigint, $2::bigint
+			WHERE NOT EXISTS (
+				SELECT 1 FROM blocks
+				WHERE (blocker_id = $1 AND blocked_id = $2)
+				   OR (blocker_id = $2 AND blocked_id = $1)
+			)
+			ON CONFLICT (user_id, follower_id) DO NOTHING
+		)
+		SELECT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2)
+			   OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`
+
+	var blocked bool
+	if err := q.QueryRowContext(ctx, query, followedID, followerID).Scan(&blocked); err != nil {
+		return ctxErr(ctx, err)
+	}
+	if blocked {
+		return ErrBlocked
+	}
+
+	return nil
+}
+
+func (s *FollowersStorage) FollowMany(ctx context.Context, followerID int64, followedIDs []int64) ([]int64, error) {
+	return s.FollowManyTx(ctx, s.db, followerID, followedIDs)
+}
+
+// FollowManyTx follows every existing user in followedIDs with one statement
+// and returns the IDs that were newly followed. Self-follows, unknown users
+// and existing follows are skipped silently. If a block stands between the
+// follower and any of the users, nobody is followed and ErrBlocked is
+// returned.
+func (s *FollowersStorage) FollowManyTx(ctx context.Context, q Querier, followerID int64, followedIDs []int64) ([]int64, error) {
+	if len(followedIDs) == 0 {
+		return []int64{}, nil
+	}
+
+	ctx, span := startSpan(ctx, "Followers.FollowMany")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// Blocked targets come back flagged instead of followed; the insert
+	// skips every target as soon as one of them is blocked.
+	query := `
+		WITH targets AS (
+			SELECT DISTINCT u.id
+			FROM users u
+			WHERE u.id = ANY($2) AND u.id <> $1 AND u.deleted_at IS NULL
+		), blocked AS (
+			SELECT t.id FROM targets t
+			WHERE EXISTS (
+				SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = t.id AND b.blocked_id = $1)
+				   OR (b.blocker_id = $1 AND b.blocked_id = t.id)
+			)
+		), inserted AS (
+			INSERT INTO followers (user_id, follower_id)
+			SELECT t.id, $1::bigint FROM targets t
+			WHERE NOT EXISTS (SELECT 1 FROM blocked)
+			ON CONFLICT (user_id, follower_id) DO NOTHING
+			RETURNING user_id
+		)
+		SELECT user_id, FALSE FROM inserted
+		UNION ALL
+		SELECT id, TRUE FROM blocked
+	`
+
+	rows, err := q.QueryContext(ctx, query, followerID, pq.Array(followedIDs))
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	followed := []int64{}
+	var blocked bool
+	for rows.Next() {
+		var (
+			id        int64
+			isBlocked bool
+		)
+		if err := rows.Scan(&id, &isBlocked); err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		if isBlocked {
+			blocked = true
+			continue
+		}
+		followed = append(followed, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	if blocked {
+		return nil, ErrBlocked
+	}
+
+	return followed, nil
+}
+
+func (s *FollowersStorage) Unfollow(ctx context.Context, followerID, followedID int64) error {
+	return s.UnfollowTx(ctx, s.db, followerID, followedID)
+}
+
+func (s *FollowersStorage) UnfollowTx(ctx context.Context, q Querier, followerID, followedID int64) error {
+	ctx, span := startSpan(ctx, "Followers.Unfollow")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM followers
+		WHERE user_id = $1 AND follower_id = $2
+	`
+
+	_, err := q.ExecContext(ctx, query, followedID, followerID)
+	return ctxErr(ctx, err)
+}
+
+// FollowerIDs returns the IDs of every user following userID.
+func (s *FollowersStorage) FollowerIDs(ctx context.Context, userID int64) ([]int64, error) {
+	ctx, span := startSpan(ctx, "Followers.FollowerIDs")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT follower_id FROM followers
+		WHERE user_id = $1
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, ctxErr(ctx, rows.Err())
+}
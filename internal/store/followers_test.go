@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestFollowSelf(t *testing.T) {
+	s, _ := newMockStorage(t)
+
+	// No query is expected: the mock fails the test if one runs.
+	if err := s.Followers.Follow(context.Background(), 1, 1); !errors.Is(err, ErrSelfFollow) {
+		t.Errorf("Follow(1, 1) error = %v, want ErrSelfFollow", err)
+	}
+}
+
+func TestFollowIgnoresExistingFollow(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(`INSERT INTO followers .* ON CONFLICT \(user_id, follower_id\) DO NOTHING`).
+		WithArgs(int64(2), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	if err := s.Followers.Follow(context.Background(), 1, 2); err != nil {
+		t.Errorf("Follow() error = %v", err)
+	}
+}
+
+func TestFollowBlocked(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(`INSERT INTO followers .* WHERE NOT EXISTS \(\s*SELECT 1 FROM blocks`).
+		WithArgs(int64(2), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	if err := s.Followers.Follow(context.Background(), 1, 2); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Follow() error = %v, want ErrBlocked", err)
+	}
+}
+
+func TestFollowManyBlocked(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(`INSERT INTO followers`).
+		WithArgs(int64(1), "{2,3}").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "bool"}).AddRow(3, true))
+
+	followed, err := s.Followers.FollowMany(context.Background(), 1, []int64{2, 3})
+	if !errors.Is(err, ErrBlocked) || followed != nil {
+		t.Errorf("FollowMany() = %v, %v; want nothing and ErrBlocked", followed, err)
+	}
+}
+
+func TestFollowUnfollowIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+
+	following := func() int {
+		return countRows(t, s, `SELECT COUNT(*) FROM followers WHERE user_id = $1 AND follower_id = $2`, bob.ID, alice.ID)
+	}
+
+	if err := s.Followers.Follow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatalf("Follow() error = %v", err)
+	}
+	if err := s.Followers.Follow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatalf("second Follow() error = %v, want it to be a no-op", err)
+	}
+	if n := following(); n != 1 {
+		t.Fatalf("follow rows = %d, want 1", n)
+	}
+
+	if err := s.Followers.Follow(ctx, alice.ID, alice.ID); !errors.Is(err, ErrSelfFollow) {
+		t.Errorf("self Follow() error = %v, want ErrSelfFollow", err)
+	}
+
+	if err := s.Followers.Unfollow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatalf("Unfollow() error = %v", err)
+	}
+	if n := following(); n != 0 {
+		t.Errorf("follow rows after Unfollow = %d, want 0", n)
+	}
+}
+
+func TestFollowCountsIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+
+	counts := func(username string) (followers, following int) {
+		t.Helper()
+		profile, err := s.Users.GetProfile(ctx, username)
+		if err != nil {
+			t.Fatalf("GetProfile(%q) error = %v", username, err)
+		}
+		return profile.FollowersCount, profile.FollowingCount
+	}
+
+	if err := s.Followers.Follow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatalf("Follow() error = %v", err)
+	}
+	if followers, following := counts("alice"); followers != 0 || following != 1 {
+		t.Errorf("alice after follow: followers %d, following %d; want 0, 1", followers, following)
+	}
+	if followers, following := counts("bob"); followers != 1 || following != 0 {
+		t.Errorf("bob after follow: followers %d, following %d; want 1, 0", followers, following)
+	}
+
+	if err := s.Followers.Unfollow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatalf("Unfollow() error = %v", err)
+	}
+	for _, username := range []string{"alice", "bob"} {
+		if followers, following := counts(username); followers != 0 || following != 0 {
+			t.Errorf("%s after unfollow: followers %d, following %d; want 0, 0", username, followers, following)
+		}
+	}
+}
+
+func TestFollowManyIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+	carol := createTestUser(t, s, "carol")
+
+	if err := s.Followers.Follow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// bob is already followed, carol is listed twice, alice is the follower
+	// herself and the last ID belongs to nobody.
+	ids := []int64{bob.ID, carol.ID, carol.ID, alice.ID, carol.ID + 1000}
+	followed, err := s.Followers.FollowMany(ctx, alice.ID, ids)
+	if err != nil {
+		t.Fatalf("FollowMany() error = %v", err)
+	}
+	if !slices.Equal(followed, []int64{carol.ID}) {
+		t.Errorf("FollowMany() = %v, want only carol (%d)", followed, carol.ID)
+	}
+
+	if n := countRows(t, s, `SELECT COUNT(*) FROM followers WHERE follower_id = $1`, alice.ID); n != 2 {
+		t.Errorf("alice follows %d users, want 2", n)
+	}
+
+	followed, err = s.Followers.FollowMany(ctx, alice.ID, nil)
+	if err != nil || len(followed) != 0 {
+		t.Errorf("FollowMany(nil) = %v, %v; want nothing", followed, err)
+	}
+}
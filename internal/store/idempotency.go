@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// IdempotentResponse is the stored first response to a keyed request.
+// Location is kept alongside Content-Type so that a replayed 201 still points
+// at what was created; other headers are not replayed.
+type IdempotentResponse struct {
+	UserID      int64
+	Key         string
+	StatusCode  int
+	ContentType string
+	Location    string
+	Body        []byte
+}
+
+type IdempotencyStorage struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// Get returns the stored response for (userID, key), or ErrNotFound when none
+// exists or it has expired.
+func (s *IdempotencyStorage) Get(ctx context.Context, userID int64, key string) (*IdempotentResponse, error) {
+	ctx, span := startSpan(ctx, "Idempotency.Get")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT user_id, key, status_code, content_type, location, body
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2 AND expires_at > NOW()
+	`
+
+	resp := &IdempotentResponse{}
+	err := s.db.QueryRowContext(ctx, query, userID, key).Scan(
+		&resp.UserID,
+		&resp.Key,
+		&resp.StatusCode,
+		&resp.ContentType,
+		&resp.Location,
+		&resp.Body,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxErr(ctx, err)
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *IdempotencyStorage) Save(ctx context.Context, resp *IdempotentResponse, ttl time.Duration) error {
+	ctx, span := startSpan(ctx, "Idempotency.Save")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, status_code, content_type, location, body, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW() + $7 * INTERVAL '1 second')
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET status_code = EXCLUDED.status_code,
+			content_type = EXCLUDED.content_type,
+			location = EXCLUDED.location,
+			body = EXCLUDED.body,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+	`
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		resp.UserID,
+		resp.Key,
+		resp.StatusCode,
+		resp.ContentType,
+		resp.Location,
+		resp.Body,
+		int64(ttl.Seconds()),
+	)
+	return ctxErr(ctx, err)
+}
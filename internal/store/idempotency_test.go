@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestIdempotencyLocation(t *testing.T) {
+	s, mock := newMockStorage(t)
+	ctx := context.Background()
+
+	mock.ExpectExec(`INSERT INTO idempotency_keys \(user_id, key, status_code, content_type, location, body, expires_at\)(.|\n)+location = EXCLUDED.location`).
+		WithArgs(int64(1), "key-1", 201, "application/json", "/v1/posts/42", []byte(`{"id":42}`), int64(3600)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT user_id, key, status_code, content_type, location, body`).
+		WithArgs(int64(1), "key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "key", "status_code", "content_type", "location", "body"}).
+			AddRow(1, "key-1", 201, "application/json", "/v1/posts/42", []byte(`{"id":42}`)))
+
+	resp := &IdempotentResponse{UserID: 1, Key: "key-1", StatusCode: 201, ContentType: "application/json", Location: "/v1/posts/42", Body: []byte(`{"id":42}`)}
+	if err := s.Idempotency.Save(ctx, resp, time.Hour); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := s.Idempotency.Get(ctx, 1, "key-1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Location != "/v1/posts/42" {
+		t.Errorf("Location = %q, want /v1/posts/42", got.Location)
+	}
+}
+
+func TestIdempotencySaveAndGetIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+
+	resp := &IdempotentResponse{
+		UserID:      alice.ID,
+		Key:         "key-1",
+		StatusCode:  201,
+		ContentType: "application/json",
+		Location:    "/v1/posts/1",
+		Body:        []byte(`{"id":1}`),
+	}
+	if err := s.Idempotency.Save(ctx, resp, time.Hour); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := s.Idempotency.Get(ctx, alice.ID, "key-1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.StatusCode != resp.StatusCode || got.ContentType != resp.ContentType || got.Location != resp.Location || string(got.Body) != string(resp.Body) {
+		t.Errorf("Get() = %+v, want %+v", got, resp)
+	}
+
+	expired := &IdempotentResponse{UserID: alice.ID, Key: "key-2", StatusCode: 201, Body: []byte("{}")}
+	if err := s.Idempotency.Save(ctx, expired, -time.Second); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := s.Idempotency.Get(ctx, alice.ID, "key-2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of expired key error = %v, want ErrNotFound", err)
+	}
+}
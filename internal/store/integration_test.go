@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rissabekov-wes/social/internal/db"
+)
+
+// newTestDB connects to the Postgres server in TEST_DATABASE_URL and returns
+// a pool whose search_path is a fresh schema with every migration applied.
+// The schema is dropped when the test ends. Tests that need real Postgres
+// semantics skip when TEST_DATABASE_URL is not set.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	addr := os.Getenv("TEST_DATABASE_URL")
+	if addr == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	admin, err := db.New(addr, 2, 2, time.Minute)
+	if err != nil {
+		t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	var suffix [6]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		t.Fatal(err)
+	}
+	schema := "test_" + hex.EncodeToString(suffix[:])
+
+	if _, err := admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)); err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(fmt.Sprintf("DROP SCHEMA %s CASCADE", schema)); err != nil {
+			t.Errorf("dropping schema: %v", err)
+		}
+	})
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		t.Fatalf("TEST_DATABASE_URL must be a URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	conn, err := db.New(u.String(), 10, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("connecting to test schema: %v", err)
+	}
+	// Registered after the schema cleanup so it runs first.
+	t.Cleanup(func() { conn.Close() })
+
+	if err := db.MigrateUp(context.Background(), conn); err != nil {
+		t.Fatalf("migrating test schema: %v", err)
+	}
+
+	return conn
+}
+
+// newTestStorage returns a Storage over newTestDB.
+func newTestStorage(t *testing.T) Storage {
+	t.Helper()
+	return NewStorage(newTestDB(t), nil, 5*time.Second, testPasswordCost)
+}
+
+// createTestUser stores an active user with the given username.
+func createTestUser(t *testing.T, s Storage, username string) *User {
+	t.Helper()
+
+	user := &User{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "correct horse",
+		IsActive: true,
+	}
+	if err := s.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("creating user %s: %v", username, err)
+	}
+	return user
+}
+
+// createTestPost stores a post by author.
+func createTestPost(t *testing.T, s Storage, author *User, title string, tags ...string) *Post {
+	t.Helper()
+
+	post := &Post{UserID: author.ID, Title: title, Content: title + " content", Tags: tags}
+	if err := s.Posts.Create(context.Background(), post); err != nil {
+		t.Fatalf("creating post %q: %v", title, err)
+	}
+	return post
+}
+
+// countRows runs a COUNT query on the test database behind s.
+func countRows(t *testing.T, s Storage, query string, args ...any) int {
+	t.Helper()
+
+	var n int
+	if err := s.db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// CreateAndInvite creates user as inactive and stores an activation token
+// valid for exp, in a single transaction. Only the SHA-256 hash of token is
+// persisted.
+func (s *UsersStorage) CreateAndInvite(ctx context.Context, user *User, token string, exp time.Duration) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.CreateTx(ctx, tx, user); err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		return s.createInvitation(ctx, tx, user.ID, token, exp)
+	})
+}
+
+func (s *UsersStorage) createInvitation(ctx context.Context, q Querier, userID int64, token string, exp time.Duration) error {
+	ctx, span := startSpan(ctx, "Users.CreateInvitation")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO user_invitations (token, user_id, expiry) VALUES ($1, $2, $3)`
+
+	hash := hashToken(token)
+	_, err := q.ExecContext(ctx, query, hash[:], userID, time.Now().Add(exp))
+	return ctxErr(ctx, err)
+}
+
+// Activate marks the user owning token as active and removes their pending
+// invitations. Unknown and expired tokens return ErrNotFound.
+func (s *UsersStorage) Activate(ctx context.Context, token string) error {
+	return withSerializableTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, span := startSpan(ctx, "Users.Activate")
+		defer span.End()
+
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+
+		query := `
+			UPDATE users SET is_active = TRUE
+			WHERE id = (
+				SELECT user_id FROM user_invitations
+				WHERE token = $1 AND expiry > NOW()
+			)
+			RETURNING id
+		`
+
+		hash := hashToken(token)
+
+		var userID int64
+		err := tx.QueryRowContext(ctx, query, hash[:]).Scan(&userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return ctxErr(ctx, err)
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM user_invitations WHERE user_id = $1`, userID)
+		return ctxErr(ctx, err)
+	})
+}
+
+// ReissueInvitation replaces the pending activation tokens of the inactive
+// user registered with email by token, valid for exp, and returns that user.
+// It returns ErrNotFound when there is no such inactive user and
+// ErrInvitationThrottled when the previous invitation was issued less than
+// minInterval ago.
+func (s *UsersStorage) ReissueInvitation(ctx context.Context, email, token string, exp, minInterval time.Duration) (*User, error) {
+	var user *User
+	err := withSerializableTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, span := startSpan(ctx, "Users.ReissueInvitation")
+		defer span.End()
+
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+
+		query := `
+			SELECT u.id, u.username, u.email,
+				(SELECT MAX(i.created_at) FROM user_invitations i WHERE i.user_id = u.id)
+			FROM users u
+			WHERE u.email = $1 AND NOT u.is_active AND u.deleted_at IS NULL
+			FOR UPDATE
+		`
+
+		u := &User{}
+		var lastSent sql.NullTime
+		err := tx.QueryRowContext(ctx, query, normalizeEmail(email)).Scan(&u.ID, &u.Username, &u.Email, &lastSent)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return ctxErr(ctx, err)
+			}
+		}
+
+		if lastSent.Valid && time.Since(lastSent.Time) < minInterval {
+			return ErrInvitationThrottled
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_invitations WHERE user_id = $1`, u.ID); err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		if err := s.createInvitation(ctx, tx, u.ID, token, exp); err != nil {
+			return err
+		}
+
+		user = u
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func hashToken(token string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(token))
+}
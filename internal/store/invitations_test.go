@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestActivateLooksUpTokenHash(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	hash := hashToken("plain-token")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE users SET is_active = TRUE`).
+		WithArgs(hash[:]).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec(`DELETE FROM user_invitations WHERE user_id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := s.Users.Activate(context.Background(), "plain-token"); err != nil {
+		t.Errorf("Activate() error = %v", err)
+	}
+}
+
+func TestActivateIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	invite := func(username string, exp time.Duration) *User {
+		t.Helper()
+		user := &User{Username: username, Email: username + "@example.com", Password: "correct horse"}
+		if err := s.Users.CreateAndInvite(ctx, user, username+"-token", exp); err != nil {
+			t.Fatalf("CreateAndInvite() error = %v", err)
+		}
+		return user
+	}
+	isActive := func(id int64) bool {
+		t.Helper()
+		return countRows(t, s, `SELECT COUNT(*) FROM users WHERE id = $1 AND is_active`, id) == 1
+	}
+
+	t.Run("activates once", func(t *testing.T) {
+		user := invite("alice", time.Hour)
+		if isActive(user.ID) {
+			t.Fatal("invited user is already active")
+		}
+
+		hash := hashToken("alice-token")
+		if n := countRows(t, s, `SELECT COUNT(*) FROM user_invitations WHERE token = $1`, hash[:]); n != 1 {
+			t.Errorf("invitations stored under the token hash = %d, want 1", n)
+		}
+		if n := countRows(t, s, `SELECT COUNT(*) FROM user_invitations WHERE token = $1`, []byte("alice-token")); n != 0 {
+			t.Errorf("invitations stored under the plain token = %d, want 0", n)
+		}
+
+		if err := s.Users.Activate(ctx, "wrong-token"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Activate(unknown) error = %v, want ErrNotFound", err)
+		}
+		if err := s.Users.Activate(ctx, "alice-token"); err != nil {
+			t.Fatalf("Activate() error = %v", err)
+		}
+		if !isActive(user.ID) {
+			t.Error("user is not active after Activate()")
+		}
+		if n := countRows(t, s, `SELECT COUNT(*) FROM user_invitations WHERE user_id = $1`, user.ID); n != 0 {
+			t.Errorf("invitations left after Activate() = %d, want 0", n)
+		}
+		if err := s.Users.Activate(ctx, "alice-token"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second Activate() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		user := invite("bob", -time.Minute)
+
+		if err := s.Users.Activate(ctx, "bob-token"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Activate() error = %v, want ErrNotFound", err)
+		}
+		if isActive(user.ID) {
+			t.Error("expired token activated the user")
+		}
+	})
+}
+
+func TestReissueInvitation(t *testing.T) {
+	columns := []string{"id", "username", "email", "max"}
+
+	t.Run("reissued", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		hash := hashToken("fresh-token")
+		mock.ExpectBegin()
+		mock.ExpectQuery(`WHERE u.email = \$1 AND NOT u.is_active`).
+			WithArgs("alice@example.com").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, "alice", "alice@example.com", time.Now().Add(-time.Hour)))
+		mock.ExpectExec(`DELETE FROM user_invitations WHERE user_id = \$1`).
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO user_invitations`).
+			WithArgs(hash[:], int64(7), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		user, err := s.Users.ReissueInvitation(context.Background(), "Alice@Example.com", "fresh-token", time.Hour, 5*time.Minute)
+		if err != nil {
+			t.Fatalf("ReissueInvitation() error = %v", err)
+		}
+		if user.ID != 7 || user.Username != "alice" {
+			t.Errorf("ReissueInvitation() = %+v, want alice", user)
+		}
+	})
+
+	t.Run("throttled", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`WHERE u.email = \$1 AND NOT u.is_active`).
+			WithArgs("alice@example.com").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, "alice", "alice@example.com", time.Now().Add(-time.Minute)))
+		mock.ExpectRollback()
+
+		_, err := s.Users.ReissueInvitation(context.Background(), "alice@example.com", "fresh-token", time.Hour, 5*time.Minute)
+		if !errors.Is(err, ErrInvitationThrottled) {
+			t.Errorf("ReissueInvitation() error = %v, want ErrInvitationThrottled", err)
+		}
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`WHERE u.email = \$1 AND NOT u.is_active`).
+			WithArgs("nobody@example.com").
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectRollback()
+
+		_, err := s.Users.ReissueInvitation(context.Background(), "nobody@example.com", "fresh-token", time.Hour, 5*time.Minute)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("ReissueInvitation() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestReissueInvitationIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	user := &User{Username: "alice", Email: "alice@example.com", Password: "correct horse"}
+	if err := s.Users.CreateAndInvite(ctx, user, "old-token", time.Hour); err != nil {
+		t.Fatalf("CreateAndInvite() error = %v", err)
+	}
+
+	if _, err := s.Users.ReissueInvitation(ctx, user.Email, "new-token", time.Hour, time.Hour); !errors.Is(err, ErrInvitationThrottled) {
+		t.Fatalf("ReissueInvitation() right after registering error = %v, want ErrInvitationThrottled", err)
+	}
+
+	got, err := s.Users.ReissueInvitation(ctx, user.Email, "new-token", time.Hour, 0)
+	if err != nil {
+		t.Fatalf("ReissueInvitation() error = %v", err)
+	}
+	if got.ID != user.ID {
+		t.Errorf("ReissueInvitation() user = %d, want %d", got.ID, user.ID)
+	}
+
+	if _, err := s.Users.ReissueInvitation(ctx, user.Email, "newer-token", time.Hour, time.Hour); !errors.Is(err, ErrInvitationThrottled) {
+		t.Errorf("second ReissueInvitation() error = %v, want ErrInvitationThrottled", err)
+	}
+
+	if err := s.Users.Activate(ctx, "old-token"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Activate(old token) error = %v, want ErrNotFound", err)
+	}
+	if err := s.Users.Activate(ctx, "new-token"); err != nil {
+		t.Errorf("Activate(new token) error = %v", err)
+	}
+
+	if _, err := s.Users.ReissueInvitation(ctx, user.Email, "late-token", time.Hour, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ReissueInvitation() for an active user error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const pgForeignKeyViolation = "23503"
+
+type LikesStorage struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func (s *LikesStorage) Like(ctx context.Context, userID, postID int64) error {
+	return s.LikeTx(ctx, s.db, userID, postID)
+}
+
+// LikeTx is idempotent: liking an already liked post is a no-op. Liking a
+// post that does not exist returns ErrNotFound.
+func (s *LikesStorage) LikeTx(ctx context.Context, q Querier, userID, postID int64) error {
+	ctx, span := startSpan(ctx, "Likes.Like")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO likes (user_id, post_id) VALUES ($1, $2)
+		ON CONFLICT (user_id, post_id) DO NOTHING
+	`
+
+	_, err := q.ExecContext(ctx, query, userID, postID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pgForeignKeyViolation {
+			return ErrNotFound
+		}
+		return ctxErr(ctx, err)
+	}
+
+	return nil
+}
+
+func (s *LikesStorage) Unlike(ctx context.Context, userID, postID int64) error {
+	return s.UnlikeTx(ctx, s.db, userID, postID)
+}
+
+func (s *LikesStorage) UnlikeTx(ctx context.Context, q Querier, userID, postID int64) error {
+	ctx, span := startSpan(ctx, "Likes.Unlike")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `DELETE FROM likes WHERE user_id = $1 AND post_id = $2`
+
+	_, err := q.ExecContext(ctx, query, userID, postID)
+	return ctxErr(ctx, err)
+}
+
+// Count returns the number of likes on postID, or ErrNotFound if the post
+// does not exist.
+func (s *LikesStorage) Count(ctx context.Context, postID int64) (int64, error) {
+	ctx, span := startSpan(ctx, "Likes.Count")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT (SELECT COUNT(*) FROM likes l WHERE l.post_id = p.id)
+		FROM posts p
+		WHERE p.id = $1
+	`
+
+	var count int64
+	err := s.db.QueryRowContext(ctx, query, postID).Scan(&count)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, ErrNotFound
+		default:
+			return 0, ctxErr(ctx, err)
+		}
+	}
+
+	return count, nil
+}
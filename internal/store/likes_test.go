@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestLikeMissingPost(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectExec(`INSERT INTO likes \(user_id, post_id\) VALUES \(\$1, \$2\)\s+ON CONFLICT \(user_id, post_id\) DO NOTHING`).
+		WithArgs(int64(1), int64(99)).
+		WillReturnError(&pq.Error{Code: pgForeignKeyViolation, Constraint: "likes_post_id_fkey"})
+
+	if err := s.Likes.Like(context.Background(), 1, 99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Like() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUnlikeNotLiked(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectExec(`DELETE FROM likes WHERE user_id = \$1 AND post_id = \$2`).
+		WithArgs(int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := s.Likes.Unlike(context.Background(), 1, 2); err != nil {
+		t.Errorf("Unlike() error = %v, want nil", err)
+	}
+}
+
+func TestLikesIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+	post := createTestPost(t, s, alice, "Hello")
+
+	count := func() int64 {
+		t.Helper()
+		n, err := s.Likes.Count(ctx, post.ID)
+		if err != nil {
+			t.Fatalf("Count() error = %v", err)
+		}
+		return n
+	}
+
+	if err := s.Likes.Unlike(ctx, bob.ID, post.ID); err != nil {
+		t.Errorf("Unlike() of a post not liked error = %v", err)
+	}
+	if n := count(); n != 0 {
+		t.Errorf("count = %d, want 0", n)
+	}
+
+	for range 2 {
+		if err := s.Likes.Like(ctx, bob.ID, post.ID); err != nil {
+			t.Fatalf("Like() error = %v", err)
+		}
+	}
+	if n := count(); n != 1 {
+		t.Errorf("count after liking twice = %d, want 1", n)
+	}
+
+	if err := s.Likes.Unlike(ctx, bob.ID, post.ID); err != nil {
+		t.Fatalf("Unlike() error = %v", err)
+	}
+	if n := count(); n != 0 {
+		t.Errorf("count after unlike = %d, want 0", n)
+	}
+
+	if err := s.Likes.Like(ctx, bob.ID, post.ID+1000); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Like() of a missing post error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Likes.Count(ctx, post.ID+1000); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Count() of a missing post error = %v, want ErrNotFound", err)
+	}
+}
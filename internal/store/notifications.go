@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type NotificationType string
+
+const (
+	NotificationFollow  NotificationType = "follow"
+	NotificationComment NotificationType = "comment"
+	NotificationLike    NotificationType = "like"
+)
+
+// Notification tells UserID that ActorID did something. EntityID identifies
+// the subject of the event: the follower for follows, the comment for
+// comments and the post for likes.
+type Notification struct {
+	ID        int64            `json:"id"`
+	Type      NotificationType `json:"type"`
+	UserID    int64            `json:"user_id"`
+	ActorID   int64            `json:"actor_id"`
+	EntityID  int64            `json:"entity_id"`
+	Read      bool             `json:"read"`
+	CreatedAt string           `json:"created_at"`
+}
+
+type NotificationsStorage struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func (s *NotificationsStorage) Create(ctx context.Context, n *Notification) error {
+	return s.CreateTx(ctx, s.db, n)
+}
+
+// CreateTx records n. Duplicate follow notifications are silently dropped, in
+// which case n.ID is left as zero.
+func (s *NotificationsStorage) CreateTx(ctx context.Context, q Querier, n *Notification) error {
+	ctx, span := startSpan(ctx, "Notifications.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO notifications (type, user_id, actor_id, entity_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := q.QueryRowContext(
+		ctx,
+		query,
+		n.Type,
+		n.UserID,
+		n.ActorID,
+		n.EntityID,
+	).Scan(
+		&n.ID,
+		&n.CreatedAt,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ctxErr(ctx, err)
+	}
+
+	return nil
+}
+
+func (s *NotificationsStorage) ListForUser(ctx context.Context, userID int64, onlyUnread bool, fq FeedQuery) ([]Notification, error) {
+	ctx, span := startSpan(ctx, "Notifications.ListForUser")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var cursorCreatedAt, cursorID any
+	if fq.Cursor != "" {
+		cursor, err := DecodeCursor(fq.Cursor)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		cursorCreatedAt, cursorID = cursor.CreatedAt, cursor.ID
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, type, user_id, actor_id, entity_id, read, created_at
+		FROM notifications
+		WHERE user_id = $1
+		AND (NOT $2 OR read = FALSE)
+		AND ($3::timestamptz IS NULL OR (created_at, id) %[2]s ($3::timestamptz, $4::bigint))
+		ORDER BY created_at %[1]s, id %[1]s
+		LIMIT $5
+	`, fq.sortDirection(), fq.keysetOperator())
+
+	rows, err := s.db.QueryContext(ctx, query, userID, onlyUnread, cursorCreatedAt, cursorID, fq.Limit)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		var n Notification
+		err := rows.Scan(
+			&n.ID,
+			&n.Type,
+			&n.UserID,
+			&n.ActorID,
+			&n.EntityID,
+			&n.Read,
+			&n.CreatedAt,
+		)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	return notifications, ctxErr(ctx, rows.Err())
+}
+
+// MarkRead flags the notification as read. It returns ErrNotFound when the
+// notification does not exist or belongs to another user.
+func (s *NotificationsStorage) MarkRead(ctx context.Context, userID, notificationID int64) error {
+	ctx, span := startSpan(ctx, "Notifications.MarkRead")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `UPDATE notifications SET read = TRUE WHERE id = $1 AND user_id = $2`
+
+	res, err := s.db.ExecContext(ctx, query, notificationID, userID)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
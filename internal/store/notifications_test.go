@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestFollowNotificationIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+
+	follow := func() {
+		t.Helper()
+		err := s.WithTx(ctx, func(tx *sql.Tx) error {
+			if err := s.Followers.FollowTx(ctx, tx, alice.ID, bob.ID); err != nil {
+				return err
+			}
+			return s.Notifications.CreateTx(ctx, tx, &Notification{
+				Type:     NotificationFollow,
+				UserID:   bob.ID,
+				ActorID:  alice.ID,
+				EntityID: alice.ID,
+			})
+		})
+		if err != nil {
+			t.Fatalf("follow transaction error = %v", err)
+		}
+	}
+
+	follow()
+	// A repeated follow must not notify twice.
+	follow()
+
+	notifications, err := s.Notifications.ListForUser(ctx, bob.ID, true, FeedQuery{Limit: 10, Sort: "desc"})
+	if err != nil {
+		t.Fatalf("ListForUser() error = %v", err)
+	}
+	if len(notifications) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(notifications))
+	}
+	n := notifications[0]
+	if n.Type != NotificationFollow || n.ActorID != alice.ID || n.EntityID != alice.ID || n.Read {
+		t.Errorf("notification = %+v, want an unread follow from alice", n)
+	}
+
+	others, err := s.Notifications.ListForUser(ctx, alice.ID, false, FeedQuery{Limit: 10, Sort: "desc"})
+	if err != nil {
+		t.Fatalf("ListForUser() error = %v", err)
+	}
+	if len(others) != 0 {
+		t.Errorf("the follower got %d notifications, want 0", len(others))
+	}
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+type FeedQuery struct {
+	Limit  int    `json:"limit" validate:"gte=1"`
+	Cursor string `json:"cursor"`
+	Sort   string `json:"sort" validate:"oneof=asc desc"`
+}
+
+func (q FeedQuery) sortDirection() string {
+	if q.Sort == "asc" {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+// keysetOperator returns the row comparison that selects rows after the
+// cursor for the query's sort direction.
+func (q FeedQuery) keysetOperator() string {
+	if q.Sort == "asc" {
+		return ">"
+	}
+	return "<"
+}
+
+type FeedCursor struct {
+	CreatedAt string `json:"created_at"`
+	ID        int64  `json:"id"`
+}
+
+func EncodeCursor(c FeedCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (FeedCursor, error) {
+	var c FeedCursor
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.CreatedAt == "" || c.ID <= 0 {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
@@ -0,0 +1,31 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := FeedCursor{CreatedAt: "2024-01-02T03:04:05.123456Z", ID: 42}
+
+	got, err := DecodeCursor(EncodeCursor(want))
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("DecodeCursor() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, cursor := range []string{
+		"not base64!",
+		EncodeCursor(FeedCursor{ID: 1}),
+		EncodeCursor(FeedCursor{CreatedAt: "2024-01-01T00:00:00Z"}),
+		"bm90IGpzb24", // "not json"
+	} {
+		if _, err := DecodeCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// CreatePasswordReset stores a reset token valid for exp for the active user
+// registered with email, replacing any earlier one, and returns that user.
+// Only the SHA-256 hash of token is persisted. It returns ErrNotFound when no
+// active user has that email.
+func (s *UsersStorage) CreatePasswordReset(ctx context.Context, email, token string, exp time.Duration) (*User, error) {
+	var user *User
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, span := startSpan(ctx, "Users.CreatePasswordReset")
+		defer span.End()
+
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+
+		query := `
+			SELECT id, username, email FROM users
+			WHERE email = $1 AND is_active AND deleted_at IS NULL
+		`
+
+		u := &User{}
+		err := tx.QueryRowContext(ctx, query, normalizeEmail(email)).Scan(&u.ID, &u.Username, &u.Email)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return ctxErr(ctx, err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = $1`, u.ID); err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		hash := hashToken(token)
+		query = `INSERT INTO password_resets (token, user_id, expiry) VALUES ($1, $2, $3)`
+		if _, err := tx.ExecContext(ctx, query, hash[:], u.ID, time.Now().Add(exp)); err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		user = u
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ResetPassword sets the password of the user owning token and removes all
+// of their reset tokens, so each token works once. Their refresh tokens are
+// revoked as well, signing out every other session. Unknown and expired
+// tokens return ErrNotFound.
+func (s *UsersStorage) ResetPassword(ctx context.Context, token, password string) error {
+	hash, err := HashPassword(password, s.passwordCost)
+	if err != nil {
+		return err
+	}
+
+	return withSerializableTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, span := startSpan(ctx, "Users.ResetPassword")
+		defer span.End()
+
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+
+		query := `
+			UPDATE users SET password = $1
+			WHERE id = (
+				SELECT user_id FROM password_resets
+				WHERE token = $2 AND expiry > NOW()
+			)
+			RETURNING id
+		`
+
+		tokenHash := hashToken(token)
+
+		var userID int64
+		err := tx.QueryRowContext(ctx, query, hash, tokenHash[:]).Scan(&userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return ctxErr(ctx, err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = $1`, userID); err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		return revokeUserTokens(ctx, tx, userID)
+	})
+}
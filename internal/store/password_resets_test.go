@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreatePasswordResetStoresTokenHash(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	hash := hashToken("plain-token")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, username, email FROM users\s+WHERE email = \$1 AND is_active`).
+		WithArgs("alice@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).AddRow(7, "alice", "alice@example.com"))
+	mock.ExpectExec(`DELETE FROM password_resets WHERE user_id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO password_resets \(token, user_id, expiry\)`).
+		WithArgs(hash[:], int64(7), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	user, err := s.Users.CreatePasswordReset(context.Background(), "alice@example.com", "plain-token", time.Hour)
+	if err != nil {
+		t.Fatalf("CreatePasswordReset() error = %v", err)
+	}
+	if user.ID != 7 {
+		t.Errorf("CreatePasswordReset() user = %+v, want ID 7", user)
+	}
+}
+
+func TestResetPasswordUnknownToken(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	hash := hashToken("expired-token")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE users SET password = \$1(.|\n)+WHERE token = \$2 AND expiry > NOW\(\)`).
+		WithArgs(sqlmock.AnyArg(), hash[:]).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	if err := s.Users.ResetPassword(context.Background(), "expired-token", "new battery staple"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResetPassword() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPasswordResetIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	userByEmail := func(email string) *User {
+		t.Helper()
+		user, err := s.Users.GetByEmail(ctx, email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+
+	t.Run("resets once", func(t *testing.T) {
+		alice := createTestUser(t, s, "alice")
+		if err := s.RefreshTokens.Create(ctx, alice.ID, "alice-refresh", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.Users.CreatePasswordReset(ctx, alice.Email, "alice-reset", time.Hour); err != nil {
+			t.Fatalf("CreatePasswordReset() error = %v", err)
+		}
+		if err := s.Users.ResetPassword(ctx, "alice-reset", "new battery staple"); err != nil {
+			t.Fatalf("ResetPassword() error = %v", err)
+		}
+
+		user := userByEmail(alice.Email)
+		if err := user.ComparePassword("new battery staple"); err != nil {
+			t.Errorf("new password does not match: %v", err)
+		}
+		if err := user.ComparePassword("correct horse"); err == nil {
+			t.Error("old password still matches")
+		}
+
+		if err := s.Users.ResetPassword(ctx, "alice-reset", "another password"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second ResetPassword() error = %v, want ErrNotFound", err)
+		}
+		if n := countRows(t, s, `SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1 AND revoked_at IS NULL`, alice.ID); n != 0 {
+			t.Errorf("live refresh tokens after the reset = %d, want 0", n)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		bob := createTestUser(t, s, "bob")
+
+		if _, err := s.Users.CreatePasswordReset(ctx, bob.Email, "bob-reset", -time.Minute); err != nil {
+			t.Fatalf("CreatePasswordReset() error = %v", err)
+		}
+		if err := s.Users.ResetPassword(ctx, "bob-reset", "new battery staple"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("ResetPassword() error = %v, want ErrNotFound", err)
+		}
+		if err := userByEmail(bob.Email).ComparePassword("correct horse"); err != nil {
+			t.Errorf("password changed by an expired token: %v", err)
+		}
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		if _, err := s.Users.CreatePasswordReset(ctx, "nobody@example.com", "nobody-reset", time.Hour); !errors.Is(err, ErrNotFound) {
+			t.Errorf("CreatePasswordReset() error = %v, want ErrNotFound", err)
+		}
+	})
+}
@@ -3,33 +3,74 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/lib/pq"
 )
 
 type Post struct {
-	ID        int64    `json:"id"`
-	Content   string   `json:"content"`
-	Title     string   `json:"title"`
-	UserID    int64    `json:"user_id"`
-	Tags      []string `json:"tags"`
-	CreatedAt string   `json:"created_at"`
-	UpdatedAt string   `json:"updated_at"`
+	ID         int64    `json:"id"`
+	Content    string   `json:"content"`
+	Title      string   `json:"title"`
+	UserID     int64    `json:"user_id"`
+	Tags       []string `json:"tags"`
+	Version    int      `json:"version"`
+	LikesCount int64    `json:"likes_count"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+type PostWithMetadata struct {
+	Post
+	Username      string `json:"username"`
+	CommentsCount int    `json:"comments_count"`
+}
+
+// PostDetails is a post with its author, counters and the newest comments.
+type PostDetails struct {
+	PostWithMetadata
+	Comments        []Comment `json:"comments"`
+	HasMoreComments bool      `json:"has_more_comments"`
+}
+
+// postDetailsCommentLimit caps how many comments GetWithDetails embeds.
+const postDetailsCommentLimit = 10
+
+type PostFilter struct {
+	Tag    string
+	Search string
+	Since  *time.Time
+	Limit  int
+	Offset int
 }
 
 type PostsStorage struct {
-	// Define fields for post storage, e.g., database connection
-	db *sql.DB
+	db      *sql.DB
+	replica *sql.DB
+	timeout time.Duration
 }
 
 func (s *PostsStorage) Create(ctx context.Context, post *Post) error {
-	// Implement post creation logic
+	return s.CreateTx(ctx, s.db, post)
+}
+
+func (s *PostsStorage) CreateTx(ctx context.Context, q Querier, post *Post) error {
+	ctx, span := startSpan(ctx, "Posts.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO posts (content, title, user_id, tags,) VALUES (?, ?, ?, ?)
-		VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at
+		INSERT INTO posts (content, title, user_id, tags)
+		VALUES ($1, $2, $3, $4) RETURNING id, version, created_at, updated_at
 	`
 
-	err := s.db.QueryRowContext(
+	err := q.QueryRowContext(
 		ctx,
 		query,
 		post.Content,
@@ -38,12 +79,554 @@ func (s *PostsStorage) Create(ctx context.Context, post *Post) error {
 		pq.Array(post.Tags),
 	).Scan(
 		&post.ID,
+		&post.Version,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+	)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	return nil
+}
+
+func (s *PostsStorage) GetByID(ctx context.Context, id int64) (*Post, error) {
+	return s.getByID(ctx, s.replica, "Posts.GetByID", id)
+}
+
+// GetByIDPrimary is GetByID read from the primary. Use it when the post
+// decides a write, such as an ownership check or the version an update is
+// guarded by, so replica lag cannot make it stale.
+func (s *PostsStorage) GetByIDPrimary(ctx context.Context, id int64) (*Post, error) {
+	return s.getByID(ctx, s.db, "Posts.GetByIDPrimary", id)
+}
+
+func (s *PostsStorage) getByID(ctx context.Context, q Querier, spanName string, id int64) (*Post, error) {
+	ctx, span := startSpan(ctx, spanName)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, title, content, tags, version,
+			(SELECT COUNT(*) FROM likes l WHERE l.post_id = posts.id) AS likes_count,
+			created_at, updated_at
+		FROM posts
+		WHERE id = $1
+	`
+
+	post := &Post{}
+	err := q.QueryRowContext(ctx, query, id).Scan(
+		&post.ID,
+		&post.UserID,
+		&post.Title,
+		&post.Content,
+		pq.Array(&post.Tags),
+		&post.Version,
+		&post.LikesCount,
 		&post.CreatedAt,
 		&post.UpdatedAt,
 	)
 	if err != nil {
-		return err
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxErr(ctx, err)
+		}
+	}
+
+	return post, nil
+}
+
+// GetWithDetails loads the post, its author, like and comment counts and the
+// newest comments in a single round trip. Comments are aggregated to JSON in
+// the database and decoded here.
+func (s *PostsStorage) GetWithDetails(ctx context.Context, id int64) (*PostDetails, error) {
+	ctx, span := startSpan(ctx, "Posts.GetWithDetails")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			p.id, p.user_id, p.title, p.content, p.tags, p.version, p.created_at, p.updated_at,
+			CASE WHEN u.deleted_at IS NULL THEN u.username ELSE 'deleted user' END AS username,
+			(SELECT COUNT(*) FROM likes l WHERE l.post_id = p.id) AS likes_count,
+			(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comments_count,
+			COALESCE((
+				SELECT json_agg(c ORDER BY c.created_at DESC, c.id DESC)
+				FROM (
+					SELECT c.id, c.post_id, c.user_id, c.parent_id, c.depth,
+						CASE WHEN cu.deleted_at IS NULL THEN cu.username ELSE 'deleted user' END AS username,
+						c.content, c.created_at
+					FROM comments c
+					JOIN users cu ON cu.id = c.user_id
+					WHERE c.post_id = p.id
+					ORDER BY c.created_at DESC, c.id DESC
+					LIMIT $2
+				) c
+			), '[]') AS comments
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.id = $1
+	`
+
+	var (
+		details  PostDetails
+		comments []byte
+	)
+	err := s.replica.QueryRowContext(ctx, query, id, postDetailsCommentLimit).Scan(
+		&details.ID,
+		&details.UserID,
+		&details.Title,
+		&details.Content,
+		pq.Array(&details.Tags),
+		&details.Version,
+		&details.CreatedAt,
+		&details.UpdatedAt,
+		&details.Username,
+		&details.LikesCount,
+		&details.CommentsCount,
+		&comments,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxErr(ctx, err)
+		}
+	}
+
+	if err := json.Unmarshal(comments, &details.Comments); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	details.HasMoreComments = details.CommentsCount > len(details.Comments)
+
+	return &details, nil
+}
+
+func (s *PostsStorage) GetUserFeed(ctx context.Context, userID int64, fq FeedQuery) ([]PostWithMetadata, error) {
+	ctx, span := startSpan(ctx, "Posts.GetUserFeed")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var cursorCreatedAt, cursorID any
+	if fq.Cursor != "" {
+		cursor, err := DecodeCursor(fq.Cursor)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		cursorCreatedAt, cursorID = cursor.CreatedAt, cursor.ID
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			p.id, p.user_id, p.title, p.content, p.tags, p.version, p.created_at, p.updated_at,
+			CASE WHEN u.deleted_at IS NULL THEN u.username ELSE 'deleted user' END AS username,
+			COUNT(c.id) AS comments_count,
+			(SELECT COUNT(*) FROM likes l WHERE l.post_id = p.id) AS likes_count
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		LEFT JOIN comments c ON c.post_id = p.id
+		WHERE (
+			p.user_id = $1
+			OR p.user_id IN (SELECT user_id FROM followers WHERE follower_id = $1)
+		)
+		AND NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = $1 AND b.blocked_id = p.user_id)
+		AND ($2::timestamptz IS NULL OR (p.created_at, p.id) %[2]s ($2::timestamptz, $3::bigint))
+		GROUP BY p.id, u.id
+		ORDER BY p.created_at %[1]s, p.id %[1]s
+		LIMIT $4
+	`, fq.sortDirection(), fq.keysetOperator())
+
+	rows, err := s.replica.QueryContext(ctx, query, userID, cursorCreatedAt, cursorID, fq.Limit)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	feed := []PostWithMetadata{}
+	for rows.Next() {
+		var p PostWithMetadata
+		err := rows.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.Title,
+			&p.Content,
+			pq.Array(&p.Tags),
+			&p.Version,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+			&p.Username,
+			&p.CommentsCount,
+			&p.LikesCount,
+		)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+
+		feed = append(feed, p)
+	}
+
+	return feed, ctxErr(ctx, rows.Err())
+}
+
+// GetByUser lists the posts written by userID, keyset-paginated like the
+// feed and newest first by default.
+func (s *PostsStorage) GetByUser(ctx context.Context, userID int64, fq FeedQuery) ([]PostWithMetadata, error) {
+	ctx, span := startSpan(ctx, "Posts.GetByUser")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var cursorCreatedAt, cursorID any
+	if fq.Cursor != "" {
+		cursor, err := DecodeCursor(fq.Cursor)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		cursorCreatedAt, cursorID = cursor.CreatedAt, cursor.ID
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			p.id, p.user_id, p.title, p.content, p.tags, p.version, p.created_at, p.updated_at,
+			CASE WHEN u.deleted_at IS NULL THEN u.username ELSE 'deleted user' END AS username,
+			COUNT(c.id) AS comments_count,
+			(SELECT COUNT(*) FROM likes l WHERE l.post_id = p.id) AS likes_count
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		LEFT JOIN comments c ON c.post_id = p.id
+		WHERE p.user_id = $1
+		AND ($2::timestamptz IS NULL OR (p.created_at, p.id) %[2]s ($2::timestamptz, $3::bigint))
+		GROUP BY p.id, u.id
+		ORDER BY p.created_at %[1]s, p.id %[1]s
+		LIMIT $4
+	`, fq.sortDirection(), fq.keysetOperator())
+
+	rows, err := s.replica.QueryContext(ctx, query, userID, cursorCreatedAt, cursorID, fq.Limit)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	posts := []PostWithMetadata{}
+	for rows.Next() {
+		var p PostWithMetadata
+		err := rows.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.Title,
+			&p.Content,
+			pq.Array(&p.Tags),
+			&p.Version,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+			&p.Username,
+			&p.CommentsCount,
+			&p.LikesCount,
+		)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+
+		posts = append(posts, p)
+	}
+
+	return posts, ctxErr(ctx, rows.Err())
+}
+
+func (s *PostsStorage) Delete(ctx context.Context, postID int64) error {
+	return s.DeleteTx(ctx, s.db, postID)
+}
+
+func (s *PostsStorage) DeleteTx(ctx context.Context, q Querier, postID int64) error {
+	ctx, span := startSpan(ctx, "Posts.Delete")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `DELETE FROM posts WHERE id = $1`
+
+	res, err := q.ExecContext(ctx, query, postID)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *PostsStorage) Update(ctx context.Context, post *Post) error {
+	ctx, span := startSpan(ctx, "Posts.Update")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE posts
+		SET title = $1, content = $2, tags = $3, updated_at = NOW(), version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING version, updated_at
+	`
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		post.Title,
+		post.Content,
+		pq.Array(post.Tags),
+		post.ID,
+		post.Version,
+	).Scan(
+		&post.Version,
+		&post.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrConflict
+		default:
+			return ctxErr(ctx, err)
+		}
 	}
 
 	return nil
 }
+
+// PostPatch holds the fields of a partial post update. Nil fields are left
+// untouched. A non-zero Version makes the update conditional on the post
+// still being at that version.
+type PostPatch struct {
+	Title   *string
+	Content *string
+	Tags    *[]string
+	Version int
+}
+
+func (p PostPatch) empty() bool {
+	return p.Title == nil && p.Content == nil && p.Tags == nil
+}
+
+// Patch applies the non-nil fields of fields to the post and returns the
+// updated post. It returns ErrConflict when fields.Version no longer matches
+// and ErrNotFound when the post does not exist. An empty patch returns the
+// post unchanged.
+func (s *PostsStorage) Patch(ctx context.Context, id int64, fields PostPatch) (*Post, error) {
+	if fields.empty() {
+		return s.GetByID(ctx, id)
+	}
+
+	ctx, span := startSpan(ctx, "Posts.Patch")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var (
+		set  []string
+		args []any
+	)
+	if fields.Title != nil {
+		args = append(args, *fields.Title)
+		set = append(set, fmt.Sprintf("title = $%d", len(args)))
+	}
+	if fields.Content != nil {
+		args = append(args, *fields.Content)
+		set = append(set, fmt.Sprintf("content = $%d", len(args)))
+	}
+	if fields.Tags != nil {
+		args = append(args, pq.Array(*fields.Tags))
+		set = append(set, fmt.Sprintf("tags = $%d", len(args)))
+	}
+
+	args = append(args, id)
+	where := fmt.Sprintf("id = $%d", len(args))
+	if fields.Version != 0 {
+		args = append(args, fields.Version)
+		where += fmt.Sprintf(" AND version = $%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE posts
+		SET %s, updated_at = NOW(), version = version + 1
+		WHERE %s
+		RETURNING id, user_id, title, content, tags, version,
+			(SELECT COUNT(*) FROM likes l WHERE l.post_id = posts.id) AS likes_count,
+			created_at, updated_at
+	`, strings.Join(set, ", "), where)
+
+	post := &Post{}
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&post.ID,
+		&post.UserID,
+		&post.Title,
+		&post.Content,
+		pq.Array(&post.Tags),
+		&post.Version,
+		&post.LikesCount,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows) && fields.Version != 0:
+			return nil, ErrConflict
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxErr(ctx, err)
+		}
+	}
+
+	return post, nil
+}
+
+// likeEscaper escapes the LIKE wildcards, and the escape character itself, so
+// that a search matches them literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+func (s *PostsStorage) List(ctx context.Context, filter PostFilter) ([]Post, int, error) {
+	ctx, span := startSpan(ctx, "Posts.List")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var (
+		conditions []string
+		args       []any
+	)
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		conditions = append(conditions, fmt.Sprintf("$%d = ANY(tags)", len(args)))
+	}
+	if filter.Search != "" {
+		args = append(args, "%"+escapeLike(filter.Search)+"%")
+		conditions = append(conditions, fmt.Sprintf(`(title ILIKE $%[1]d ESCAPE '\' OR content ILIKE $%[1]d ESCAPE '\')`, len(args)))
+	}
+	if filter.Since != nil {
+		args = append(args, *filter.Since)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM posts ` + where
+	if err := s.replica.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, ctxErr(ctx, err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, user_id, title, content, tags, version,
+			(SELECT COUNT(*) FROM likes l WHERE l.post_id = posts.id) AS likes_count,
+			created_at, updated_at
+		FROM posts
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+
+	rows, err := s.replica.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	for rows.Next() {
+		var p Post
+		err := rows.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.Title,
+			&p.Content,
+			pq.Array(&p.Tags),
+			&p.Version,
+			&p.LikesCount,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, ctxErr(ctx, err)
+		}
+
+		posts = append(posts, p)
+	}
+
+	return posts, total, ctxErr(ctx, rows.Err())
+}
+
+// Search returns posts matching every term in query, best matches first.
+// Results are ranked rather than time-ordered, so only fq.Limit is applied.
+func (s *PostsStorage) Search(ctx context.Context, query string, fq FeedQuery) ([]Post, error) {
+	ctx, span := startSpan(ctx, "Posts.Search")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	q := `
+		SELECT id, user_id, title, content, tags, version,
+			(SELECT COUNT(*) FROM likes l WHERE l.post_id = posts.id) AS likes_count,
+			created_at, updated_at
+		FROM posts, plainto_tsquery('english', $1) AS query
+		WHERE search_vector @@ query
+		ORDER BY ts_rank(search_vector, query) DESC, created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := s.replica.QueryContext(ctx, q, query, fq.Limit)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	for rows.Next() {
+		var p Post
+		err := rows.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.Title,
+			&p.Content,
+			pq.Array(&p.Tags),
+			&p.Version,
+			&p.LikesCount,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+
+		posts = append(posts, p)
+	}
+
+	return posts, ctxErr(ctx, rows.Err())
+}
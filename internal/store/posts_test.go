@@ -0,0 +1,425 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPostsCreate(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(`INSERT INTO posts \(content, title, user_id, tags\)`).
+		WithArgs("hello world", "Hello", int64(3), `{"go","sql"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).
+			AddRow(11, 1, "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z"))
+
+	post := &Post{UserID: 3, Title: "Hello", Content: "hello world", Tags: []string{"go", "sql"}}
+	if err := s.Posts.Create(context.Background(), post); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if post.ID != 11 || post.Version != 1 || post.CreatedAt == "" || post.UpdatedAt == "" {
+		t.Errorf("Create() did not fill in the generated fields: %+v", post)
+	}
+}
+
+func TestPostsGetByID(t *testing.T) {
+	columns := []string{"id", "user_id", "title", "content", "tags", "version", "likes_count", "created_at", "updated_at"}
+
+	t.Run("found", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`FROM posts\s+WHERE id = \$1`).
+			WithArgs(int64(11)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(11, 3, "Hello", "hello world", "{go,sql}", 2, 5, "2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z"))
+
+		post, err := s.Posts.GetByID(context.Background(), 11)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if want := []string{"go", "sql"}; !reflect.DeepEqual(post.Tags, want) {
+			t.Errorf("Tags = %v, want %v", post.Tags, want)
+		}
+		if post.Version != 2 || post.LikesCount != 5 {
+			t.Errorf("GetByID() = %+v", post)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`FROM posts`).
+			WithArgs(int64(11)).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		if _, err := s.Posts.GetByID(context.Background(), 11); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByID() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestPostsDelete(t *testing.T) {
+	t.Run("deleted", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectExec(`DELETE FROM posts WHERE id = \$1`).
+			WithArgs(int64(11)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := s.Posts.Delete(context.Background(), 11); err != nil {
+			t.Errorf("Delete() error = %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectExec(`DELETE FROM posts WHERE id = \$1`).
+			WithArgs(int64(11)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := s.Posts.Delete(context.Background(), 11); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestPostsDeleteCascadesIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	post := createTestPost(t, s, alice, "short-lived")
+	if err := s.Comments.Create(ctx, &Comment{PostID: post.ID, UserID: alice.ID, Content: "bye"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Posts.Delete(ctx, post.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Posts.GetByID(ctx, post.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID() after Delete error = %v, want ErrNotFound", err)
+	}
+	if n := countRows(t, s, `SELECT COUNT(*) FROM comments WHERE post_id = $1`, post.ID); n != 0 {
+		t.Errorf("%d comments survived their post", n)
+	}
+
+	if err := s.Posts.Delete(ctx, post.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPostsUpdate(t *testing.T) {
+	post := func() *Post {
+		return &Post{ID: 11, Title: "New title", Content: "new content", Tags: []string{"go"}, Version: 2}
+	}
+
+	t.Run("updated", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`UPDATE posts\s+SET .* version = version \+ 1\s+WHERE id = \$4 AND version = \$5`).
+			WithArgs("New title", "new content", `{"go"}`, int64(11), 2).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "updated_at"}).AddRow(3, "2024-01-02T00:00:00Z"))
+
+		p := post()
+		if err := s.Posts.Update(context.Background(), p); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if p.Version != 3 {
+			t.Errorf("Version = %d, want 3", p.Version)
+		}
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`UPDATE posts`).
+			WithArgs("New title", "new content", `{"go"}`, int64(11), 2).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "updated_at"}))
+
+		if err := s.Posts.Update(context.Background(), post()); !errors.Is(err, ErrConflict) {
+			t.Errorf("Update() error = %v, want ErrConflict", err)
+		}
+	})
+}
+
+func TestPostsUpdateConflictIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	created := createTestPost(t, s, alice, "original")
+
+	first, second := *created, *created
+	first.Title = "first writer"
+	second.Title = "second writer"
+
+	if err := s.Posts.Update(ctx, &first); err != nil {
+		t.Fatalf("first Update() error = %v", err)
+	}
+	if first.Version != created.Version+1 {
+		t.Errorf("Version = %d, want %d", first.Version, created.Version+1)
+	}
+
+	if err := s.Posts.Update(ctx, &second); !errors.Is(err, ErrConflict) {
+		t.Fatalf("stale Update() error = %v, want ErrConflict", err)
+	}
+
+	stored, err := s.Posts.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title != "first writer" {
+		t.Errorf("Title = %q, the stale write must not land", stored.Title)
+	}
+}
+
+func TestPostsPatch(t *testing.T) {
+	title := "Only the title"
+	columns := []string{"id", "user_id", "title", "content", "tags", "version", "likes_count", "created_at", "updated_at"}
+
+	t.Run("title only", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		// Content and tags are not in the SET clause at all.
+		mock.ExpectQuery(`UPDATE posts\s+SET title = \$1, updated_at = NOW\(\), version = version \+ 1\s+WHERE id = \$2 AND version = \$3`).
+			WithArgs(title, int64(11), 2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(11, 1, title, "untouched content", `{go}`, 3, 0, "2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z"))
+
+		post, err := s.Posts.Patch(context.Background(), 11, PostPatch{Title: &title, Version: 2})
+		if err != nil {
+			t.Fatalf("Patch() error = %v", err)
+		}
+		if post.Title != title || post.Content != "untouched content" || post.Version != 3 {
+			t.Errorf("Patch() = %+v", post)
+		}
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`UPDATE posts`).WithArgs(title, int64(11), 2).WillReturnRows(sqlmock.NewRows(columns))
+
+		if _, err := s.Posts.Patch(context.Background(), 11, PostPatch{Title: &title, Version: 2}); !errors.Is(err, ErrConflict) {
+			t.Errorf("Patch() error = %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("missing post", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`UPDATE posts`).WithArgs(title, int64(11)).WillReturnRows(sqlmock.NewRows(columns))
+
+		if _, err := s.Posts.Patch(context.Background(), 11, PostPatch{Title: &title}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Patch() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestPostsPatchIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	created := createTestPost(t, s, alice, "original", "go", "sql")
+
+	title := "renamed"
+	patched, err := s.Posts.Patch(ctx, created.ID, PostPatch{Title: &title, Version: created.Version})
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if patched.Version != created.Version+1 {
+		t.Errorf("Version = %d, want %d", patched.Version, created.Version+1)
+	}
+
+	stored, err := s.Posts.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title != title {
+		t.Errorf("Title = %q, want %q", stored.Title, title)
+	}
+	if stored.Content != created.Content || !reflect.DeepEqual(stored.Tags, created.Tags) {
+		t.Errorf("content and tags = %q, %v; want them unchanged (%q, %v)", stored.Content, stored.Tags, created.Content, created.Tags)
+	}
+
+	if _, err := s.Posts.Patch(ctx, created.ID, PostPatch{Title: &title, Version: created.Version}); !errors.Is(err, ErrConflict) {
+		t.Errorf("stale Patch() error = %v, want ErrConflict", err)
+	}
+}
+
+func TestPostsListTagFilter(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM posts WHERE \$1 = ANY\(tags\)`).
+		WithArgs("go").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`FROM posts WHERE \$1 = ANY\(tags\) ORDER BY created_at DESC, id DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs("go", 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "content", "tags", "version", "likes_count", "created_at", "updated_at"}).
+			AddRow(1, 3, "Go", "gophers", "{go}", 1, 0, "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z"))
+
+	posts, total, err := s.Posts.List(context.Background(), PostFilter{Tag: "go", Limit: 20})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if total != 1 || len(posts) != 1 || posts[0].ID != 1 {
+		t.Errorf("List() = %+v, %d", posts, total)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"go tips":  "go tips",
+		"100%":     `100\%`,
+		"snake_ca": `snake\_ca`,
+		`C:\dir`:   `C:\\dir`,
+		`%_\`:      `\%\_\\`,
+	}
+	for in, want := range tests {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPostsListSearchEscapesWildcards(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM posts WHERE \(title ILIKE \$1 ESCAPE '\\' OR content ILIKE \$1 ESCAPE '\\'\)`).
+		WithArgs(`%\%%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`FROM posts WHERE \(title ILIKE \$1 ESCAPE`).
+		WithArgs(`%\%%`, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "content", "tags", "version", "likes_count", "created_at", "updated_at"}))
+
+	if _, _, err := s.Posts.List(context.Background(), PostFilter{Search: "%", Limit: 20}); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+}
+
+func TestPostsListIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	tagged := createTestPost(t, s, alice, "Go tips", "go")
+	createTestPost(t, s, alice, "Rust tips", "rust")
+	createTestPost(t, s, alice, "More Go", "go", "tips")
+
+	posts, total, err := s.Posts.List(ctx, PostFilter{Tag: "go", Limit: 1})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if total != 2 {
+		t.Errorf("total = %d, want 2 posts tagged go", total)
+	}
+	if len(posts) != 1 {
+		t.Errorf("List() returned %d posts, want the limit of 1", len(posts))
+	}
+
+	posts, total, err = s.Posts.List(ctx, PostFilter{Search: "GO TIPS", Limit: 10})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if total != 1 || len(posts) != 1 || posts[0].ID != tagged.ID {
+		t.Errorf("case-insensitive search = %+v, %d; want only %q", posts, total, tagged.Title)
+	}
+
+	// Wildcards in the search are literal.
+	discount := createTestPost(t, s, alice, "50% off")
+	for search, want := range map[string]int{"%": 1, "_": 0, "50%": 1, "5_%": 0} {
+		posts, total, err = s.Posts.List(ctx, PostFilter{Search: search, Limit: 10})
+		if err != nil {
+			t.Fatalf("List(%q) error = %v", search, err)
+		}
+		if total != want || (want == 1 && posts[0].ID != discount.ID) {
+			t.Errorf("search %q matched %d posts, want %d", search, total, want)
+		}
+	}
+}
+
+func TestPostsGetWithDetails(t *testing.T) {
+	columns := []string{"id", "user_id", "title", "content", "tags", "version", "created_at", "updated_at", "username", "likes_count", "comments_count", "comments"}
+
+	t.Run("found", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		comments := `[{"id":2,"post_id":11,"user_id":4,"parent_id":null,"depth":0,"username":"bob","content":"second","created_at":"2024-01-03T00:00:00Z"},
+			{"id":1,"post_id":11,"user_id":4,"parent_id":null,"depth":0,"username":"bob","content":"first","created_at":"2024-01-02T00:00:00Z"}]`
+		mock.ExpectQuery(`FROM posts p\s+JOIN users u ON u.id = p.user_id\s+WHERE p.id = \$1`).
+			WithArgs(int64(11), postDetailsCommentLimit).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(11, 3, "Hello", "hello world", "{go}", 1, "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", "alice", 5, 12, comments))
+
+		post, err := s.Posts.GetWithDetails(context.Background(), 11)
+		if err != nil {
+			t.Fatalf("GetWithDetails() error = %v", err)
+		}
+		if post.Username != "alice" || post.LikesCount != 5 || post.CommentsCount != 12 {
+			t.Errorf("GetWithDetails() = %+v", post)
+		}
+		if len(post.Comments) != 2 || post.Comments[0].Content != "second" || post.Comments[0].Username != "bob" {
+			t.Errorf("Comments = %+v, want the two embedded comments newest first", post.Comments)
+		}
+		if !post.HasMoreComments {
+			t.Error("HasMoreComments = false with 12 comments and 2 embedded")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`FROM posts p`).
+			WithArgs(int64(11), postDetailsCommentLimit).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		if _, err := s.Posts.GetWithDetails(context.Background(), 11); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetWithDetails() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestPostsGetWithDetailsIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+	post := createTestPost(t, s, alice, "Hello", "go")
+
+	for i := range postDetailsCommentLimit + 1 {
+		c := &Comment{PostID: post.ID, UserID: bob.ID, Content: fmt.Sprintf("comment %d", i)}
+		if err := s.Comments.Create(ctx, c); err != nil {
+			t.Fatalf("Comments.Create() error = %v", err)
+		}
+	}
+	if err := s.Likes.Like(ctx, bob.ID, post.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	details, err := s.Posts.GetWithDetails(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("GetWithDetails() error = %v", err)
+	}
+	if details.Username != "alice" || details.LikesCount != 1 || details.CommentsCount != postDetailsCommentLimit+1 {
+		t.Errorf("GetWithDetails() = %+v", details)
+	}
+	if len(details.Comments) != postDetailsCommentLimit || !details.HasMoreComments {
+		t.Errorf("embedded %d comments (more: %v), want %d and more", len(details.Comments), details.HasMoreComments, postDetailsCommentLimit)
+	}
+	if details.Comments[0].Username != "bob" {
+		t.Errorf("comment author = %q, want bob", details.Comments[0].Username)
+	}
+
+	if _, err := s.Posts.GetWithDetails(ctx, post.ID+1000); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetWithDetails() of a missing post error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrRefreshTokenReused is returned when an already rotated or revoked
+// refresh token is presented again. Every token descended from the same
+// login has been revoked by the time it is returned.
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+// RefreshTokensStorage keeps the SHA-256 hashes of issued refresh tokens.
+// Tokens minted by rotation share the family ID of the login that started
+// the chain, so a whole chain can be revoked at once.
+type RefreshTokensStorage struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// Create stores token as the first refresh token of a new family for userID,
+// valid for ttl.
+func (s *RefreshTokensStorage) Create(ctx context.Context, userID int64, token string, ttl time.Duration) error {
+	ctx, span := startSpan(ctx, "RefreshTokens.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO refresh_tokens (token, user_id, expiry) VALUES ($1, $2, $3)`
+
+	hash := hashToken(token)
+	_, err := s.db.ExecContext(ctx, query, hash[:], userID, time.Now().Add(ttl))
+	return ctxErr(ctx, err)
+}
+
+// Rotate revokes token and stores newToken, valid for ttl, in its place,
+// returning the ID of the user it belongs to. Unknown and expired tokens, and
+// tokens of users that are no longer active, return ErrNotFound. Presenting a
+// token that was already revoked revokes the rest of its family and returns
+// ErrRefreshTokenReused.
+func (s *RefreshTokensStorage) Rotate(ctx context.Context, token, newToken string, ttl time.Duration) (int64, error) {
+	var (
+		userID int64
+		reused bool
+	)
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, span := startSpan(ctx, "RefreshTokens.Rotate")
+		defer span.End()
+
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+
+		query := `
+			SELECT rt.id, rt.user_id, rt.family_id, rt.expiry, rt.revoked_at
+			FROM refresh_tokens rt
+			JOIN users u ON u.id = rt.user_id
+			WHERE rt.token = $1 AND u.is_active AND u.deleted_at IS NULL
+			FOR UPDATE OF rt
+		`
+
+		var (
+			id, familyID int64
+			expiry       time.Time
+			revokedAt    sql.NullTime
+		)
+
+		hash := hashToken(token)
+		err := tx.QueryRowContext(ctx, query, hash[:]).Scan(&id, &userID, &familyID, &expiry, &revokedAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return ctxErr(ctx, err)
+			}
+		}
+
+		if revokedAt.Valid {
+			// The family revocation has to commit, so the reuse is reported
+			// once the transaction is done rather than by failing it.
+			reused = true
+			return revokeFamily(ctx, tx, familyID)
+		}
+
+		if !expiry.After(time.Now()) {
+			return ErrNotFound
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1`, id); err != nil {
+			return ctxErr(ctx, err)
+		}
+
+		query = `INSERT INTO refresh_tokens (token, user_id, family_id, expiry) VALUES ($1, $2, $3, $4)`
+
+		newHash := hashToken(newToken)
+		_, err = tx.ExecContext(ctx, query, newHash[:], userID, familyID, time.Now().Add(ttl))
+		return ctxErr(ctx, err)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if reused {
+		return 0, ErrRefreshTokenReused
+	}
+
+	return userID, nil
+}
+
+// Revoke revokes token so it can no longer be rotated. It returns
+// ErrNotFound when token is unknown or was already revoked.
+func (s *RefreshTokensStorage) Revoke(ctx context.Context, token string) error {
+	ctx, span := startSpan(ctx, "RefreshTokens.Revoke")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE token = $1 AND revoked_at IS NULL
+	`
+
+	hash := hashToken(token)
+	res, err := s.db.ExecContext(ctx, query, hash[:])
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func revokeFamily(ctx context.Context, q Querier, familyID int64) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := q.ExecContext(ctx, query, familyID)
+	return ctxErr(ctx, err)
+}
+
+// revokeUserTokens revokes every outstanding refresh token of userID.
+func revokeUserTokens(ctx context.Context, q Querier, userID int64) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := q.ExecContext(ctx, query, userID)
+	return ctxErr(ctx, err)
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRefreshTokensRotate(t *testing.T) {
+	columns := []string{"id", "user_id", "family_id", "expiry", "revoked_at"}
+	oldHash, newHash := hashToken("old-token"), hashToken("new-token")
+
+	t.Run("rotated", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM refresh_tokens rt(.|\n)+WHERE rt.token = \$1`).
+			WithArgs(oldHash[:]).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(4, 7, 1, time.Now().Add(time.Hour), nil))
+		mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = NOW\(\) WHERE id = \$1`).
+			WithArgs(int64(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO refresh_tokens \(token, user_id, family_id, expiry\)`).
+			WithArgs(newHash[:], int64(7), int64(1), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		userID, err := s.RefreshTokens.Rotate(context.Background(), "old-token", "new-token", time.Hour)
+		if err != nil || userID != 7 {
+			t.Errorf("Rotate() = %d, %v; want 7", userID, err)
+		}
+	})
+
+	t.Run("reused", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM refresh_tokens rt`).
+			WithArgs(oldHash[:]).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(4, 7, 1, time.Now().Add(time.Hour), time.Now().Add(-time.Minute)))
+		mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = NOW\(\)\s+WHERE family_id = \$1 AND revoked_at IS NULL`).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		// The family revocation is committed even though Rotate fails.
+		mock.ExpectCommit()
+
+		if _, err := s.RefreshTokens.Rotate(context.Background(), "old-token", "new-token", time.Hour); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Errorf("Rotate() error = %v, want ErrRefreshTokenReused", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM refresh_tokens rt`).
+			WithArgs(oldHash[:]).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(4, 7, 1, time.Now().Add(-time.Minute), nil))
+		mock.ExpectRollback()
+
+		if _, err := s.RefreshTokens.Rotate(context.Background(), "old-token", "new-token", time.Hour); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Rotate() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestRefreshTokensRevoke(t *testing.T) {
+	hash := hashToken("token")
+
+	for _, tt := range []struct {
+		name     string
+		affected int64
+		want     error
+	}{
+		{name: "revoked", affected: 1},
+		{name: "unknown or already revoked", affected: 0, want: ErrNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockStorage(t)
+
+			mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = NOW\(\)\s+WHERE token = \$1 AND revoked_at IS NULL`).
+				WithArgs(hash[:]).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			if err := s.RefreshTokens.Revoke(context.Background(), "token"); !errors.Is(err, tt.want) {
+				t.Errorf("Revoke() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshTokensIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+
+	t.Run("rotation and reuse", func(t *testing.T) {
+		if err := s.RefreshTokens.Create(ctx, alice.ID, "login", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RefreshTokens.Create(ctx, alice.ID, "other-device", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+
+		userID, err := s.RefreshTokens.Rotate(ctx, "login", "rotated", time.Hour)
+		if err != nil || userID != alice.ID {
+			t.Fatalf("Rotate() = %d, %v; want %d", userID, err, alice.ID)
+		}
+
+		if _, err := s.RefreshTokens.Rotate(ctx, "login", "stolen", time.Hour); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Fatalf("Rotate(reused) error = %v, want ErrRefreshTokenReused", err)
+		}
+		if _, err := s.RefreshTokens.Rotate(ctx, "rotated", "next", time.Hour); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Errorf("Rotate(newest of the chain) error = %v, want ErrRefreshTokenReused", err)
+		}
+		if _, err := s.RefreshTokens.Rotate(ctx, "other-device", "other-rotated", time.Hour); err != nil {
+			t.Errorf("Rotate() of another family error = %v", err)
+		}
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		if err := s.RefreshTokens.Create(ctx, alice.ID, "session", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RefreshTokens.Revoke(ctx, "session"); err != nil {
+			t.Fatalf("Revoke() error = %v", err)
+		}
+		if err := s.RefreshTokens.Revoke(ctx, "session"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second Revoke() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		if err := s.RefreshTokens.Create(ctx, alice.ID, "expired", -time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.RefreshTokens.Rotate(ctx, "expired", "fresh", time.Hour); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Rotate(expired) error = %v, want ErrNotFound", err)
+		}
+	})
+}
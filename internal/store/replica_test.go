@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var errReplica = errors.New("replica: connection refused")
+
+// reads runs a read method against s; each issues exactly one query.
+var reads = []struct {
+	name  string
+	query string
+	run   func(s Storage) error
+}{
+	{name: "Users.GetByID", query: `FROM users`, run: func(s Storage) error {
+		_, err := s.Users.GetByID(context.Background(), 1)
+		return err
+	}},
+	{name: "Users.GetByIDs", query: `FROM users`, run: func(s Storage) error {
+		_, err := s.Users.GetByIDs(context.Background(), []int64{1, 2})
+		return err
+	}},
+	{name: "Users.List", query: `SELECT COUNT\(\*\) FROM users`, run: func(s Storage) error {
+		_, _, err := s.Users.List(context.Background(), 10, 0, "username")
+		return err
+	}},
+	{name: "Posts.GetByID", query: `FROM posts`, run: func(s Storage) error {
+		_, err := s.Posts.GetByID(context.Background(), 1)
+		return err
+	}},
+	{name: "Posts.List", query: `FROM posts`, run: func(s Storage) error {
+		_, _, err := s.Posts.List(context.Background(), PostFilter{Limit: 10})
+		return err
+	}},
+	{name: "Posts.GetUserFeed", query: `FROM posts`, run: func(s Storage) error {
+		_, err := s.Posts.GetUserFeed(context.Background(), 1, FeedQuery{Limit: 10, Sort: "desc"})
+		return err
+	}},
+}
+
+func TestReadsUseReplica(t *testing.T) {
+	for _, read := range reads {
+		t.Run(read.name, func(t *testing.T) {
+			primary, _ := newMockDB(t)
+			replica, replicaMock := newMockDB(t)
+			s := NewStorage(primary, replica, testQueryTimeout, testPasswordCost)
+
+			replicaMock.ExpectQuery(read.query).WillReturnError(errReplica)
+
+			// The primary mock expects nothing, so a read there fails with a
+			// different error.
+			if err := read.run(s); !errors.Is(err, errReplica) {
+				t.Errorf("%s error = %v, want the replica's error", read.name, err)
+			}
+		})
+	}
+}
+
+func TestReadsFallBackToPrimary(t *testing.T) {
+	for _, read := range reads {
+		t.Run(read.name, func(t *testing.T) {
+			primary, mock := newMockDB(t)
+			s := NewStorage(primary, nil, testQueryTimeout, testPasswordCost)
+
+			mock.ExpectQuery(read.query).WillReturnError(errReplica)
+
+			if err := read.run(s); !errors.Is(err, errReplica) {
+				t.Errorf("%s error = %v, want it to reach the primary", read.name, err)
+			}
+		})
+	}
+}
+
+func TestWritesUsePrimary(t *testing.T) {
+	primary, primaryMock := newMockDB(t)
+	replica, _ := newMockDB(t)
+	s := NewStorage(primary, replica, testQueryTimeout, testPasswordCost)
+
+	primaryMock.ExpectExec(`UPDATE users SET deleted_at`).WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := s.Users.SoftDelete(context.Background(), 1); err != nil {
+		t.Errorf("SoftDelete() error = %v", err)
+	}
+
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectCommit()
+	if err := s.WithTx(context.Background(), func(*sql.Tx) error { return nil }); err != nil {
+		t.Errorf("WithTx() error = %v", err)
+	}
+}
+
+func TestPrimaryReadsSkipReplica(t *testing.T) {
+	errPrimary := errors.New("primary: connection refused")
+
+	for _, read := range []struct {
+		name  string
+		query string
+		run   func(s Storage) error
+	}{
+		{name: "Users.GetByIDPrimary", query: `FROM users`, run: func(s Storage) error {
+			_, err := s.Users.GetByIDPrimary(context.Background(), 1)
+			return err
+		}},
+		{name: "Posts.GetByIDPrimary", query: `FROM posts`, run: func(s Storage) error {
+			_, err := s.Posts.GetByIDPrimary(context.Background(), 1)
+			return err
+		}},
+	} {
+		t.Run(read.name, func(t *testing.T) {
+			primary, primaryMock := newMockDB(t)
+			replica, _ := newMockDB(t)
+			s := NewStorage(primary, replica, testQueryTimeout, testPasswordCost)
+
+			primaryMock.ExpectQuery(read.query).WillReturnError(errPrimary)
+
+			if err := read.run(s); !errors.Is(err, errPrimary) {
+				t.Errorf("%s error = %v, want the primary's error", read.name, err)
+			}
+		})
+	}
+}
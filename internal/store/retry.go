@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	txRetryAttempts = 3
+
+	retryBaseDelay = 20 * time.Millisecond
+	retryMaxDelay  = 500 * time.Millisecond
+)
+
+// withRetry calls fn up to attempts times, backing off exponentially with
+// full jitter between attempts. Only errors accepted by isRetryable are
+// retried; the last error is returned once attempts are exhausted or ctx is
+// done.
+func withRetry(ctx context.Context, attempts int, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err = fn(); err == nil || !isRetryable(err) {
+			return err
+		}
+
+		if attempt == attempts-1 {
+			break
+		}
+
+		backoff := min(retryBaseDelay<<attempt, retryMaxDelay)
+		timer := time.NewTimer(rand.N(backoff) + 1)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
+
+// isRetryable reports whether err is a transient failure that is worth
+// retrying: serialization failures, deadlocks and dropped connections.
+func isRetryable(err error) bool {
+	// context.DeadlineExceeded satisfies net.Error, but a query that ran out
+	// of time will not do better on a second try.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "40001", pqErr.Code == "40P01":
+			return true
+		case pqErr.Code.Class() == "08":
+			return true
+		}
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var errSerialization = &pq.Error{Code: "40001", Message: "could not serialize access"}
+
+func TestWithRetrySucceedsAfterTwoFailures(t *testing.T) {
+	calls := 0
+	err := withRetry(context.Background(), 3, func() error {
+		calls++
+		if calls <= 2 {
+			return errSerialization
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("withRetry() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("fn called %d times, want 3", calls)
+	}
+}
+
+func TestWithRetryGivesUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{name: "attempts exhausted", err: errSerialization, wantCalls: 3},
+		{name: "not retryable", err: ErrNotFound, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withRetry(context.Background(), 3, func() error {
+				calls++
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("withRetry() error = %v, want %v", err, tt.err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := withRetry(ctx, 3, func() error {
+		calls++
+		cancel()
+		return errSerialization
+	})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errSerialization) {
+		t.Errorf("withRetry() error = %v, want the last error joined with context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "serialization failure", err: errSerialization, want: true},
+		{name: "deadlock", err: &pq.Error{Code: "40P01"}, want: true},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, want: true},
+		{name: "unique violation", err: &pq.Error{Code: pgUniqueViolation}, want: false},
+		{name: "bad connection", err: driver.ErrBadConn, want: true},
+		{name: "wrapped", err: fmt.Errorf("activating: %w", errSerialization), want: true},
+		{name: "deadline", err: context.DeadlineExceeded, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "not found", err: ErrNotFound, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("isRetryable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWithSerializableTxRetriesTransaction(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	for range 2 {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users`).WillReturnError(errSerialization)
+		mock.ExpectRollback()
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	err := withSerializableTx(ctx, db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE users SET is_active = TRUE WHERE id = 1`)
+		return err
+	})
+	if err != nil {
+		t.Errorf("withSerializableTx() error = %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPostsSearchIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+
+	inContent := &Post{UserID: alice.ID, Title: "Gardening", Content: "notes on running postgres in the shed"}
+	inTitle := &Post{UserID: alice.ID, Title: "Postgres tuning", Content: "indexes and vacuum"}
+	for _, p := range []*Post{inContent, inTitle} {
+		if err := s.Posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fq := FeedQuery{Limit: 10, Sort: "desc"}
+
+	t.Run("title matches rank first", func(t *testing.T) {
+		posts, err := s.Posts.Search(ctx, "postgres", fq)
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		if len(posts) != 2 || posts[0].ID != inTitle.ID || posts[1].ID != inContent.ID {
+			t.Errorf("Search() = %+v, want the title match before the content match", posts)
+		}
+	})
+
+	t.Run("every term must match", func(t *testing.T) {
+		posts, err := s.Posts.Search(ctx, "postgres vacuum", fq)
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		if len(posts) != 1 || posts[0].ID != inTitle.ID {
+			t.Errorf("Search() = %+v, want only the post with both terms", posts)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		posts, err := s.Posts.Search(ctx, "kubernetes", fq)
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		if posts == nil || len(posts) != 0 {
+			t.Errorf("Search() = %#v, want an empty, non-nil slice", posts)
+		}
+	})
+}
@@ -3,20 +3,205 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound          = errors.New("resource not found")
+	ErrConflict          = errors.New("resource was modified by another request")
+	ErrDuplicateEmail    = errors.New("a user with that email already exists")
+	ErrDuplicateUsername = errors.New("a user with that username already exists")
+	ErrSelfFollow        = errors.New("users cannot follow themselves")
+	ErrSelfBlock         = errors.New("users cannot block themselves")
+	ErrBlocked           = errors.New("cannot follow a user who has blocked you or whom you have blocked")
+
+	ErrInvitationThrottled = errors.New("an activation email was sent too recently")
+)
+
+type BlocksStore interface {
+	Block(ctx context.Context, blockerID, blockedID int64) error
+	BlockTx(ctx context.Context, q Querier, blockerID, blockedID int64) error
+	Unblock(ctx context.Context, blockerID, blockedID int64) error
+}
+
+type CommentsStore interface {
+	Create(context.Context, *Comment) error
+	CreateTx(context.Context, Querier, *Comment) error
+	GetByID(context.Context, int64) (*Comment, error)
+	Delete(ctx context.Context, id int64) error
+	GetByPost(ctx context.Context, postID int64) ([]Comment, error)
+	GetThread(ctx context.Context, postID int64) ([]*CommentThread, error)
+}
+
+type ExportStore interface {
+	Export(ctx context.Context, userID int64, sink ExportSink) error
+}
+
+type FollowersStore interface {
+	Follow(ctx context.Context, followerID, followedID int64) error
+	FollowTx(ctx context.Context, q Querier, followerID, followedID int64) error
+	FollowMany(ctx context.Context, followerID int64, followedIDs []int64) ([]int64, error)
+	FollowManyTx(ctx context.Context, q Querier, followerID int64, followedIDs []int64) ([]int64, error)
+	Unfollow(ctx context.Context, followerID, followedID int64) error
+	UnfollowTx(ctx context.Context, q Querier, followerID, followedID int64) error
+	FollowerIDs(ctx context.Context, userID int64) ([]int64, error)
+}
+
+type IdempotencyStore interface {
+	Get(ctx context.Context, userID int64, key string) (*IdempotentResponse, error)
+	Save(ctx context.Context, resp *IdempotentResponse, ttl time.Duration) error
+}
+
+type LikesStore interface {
+	Like(ctx context.Context, userID, postID int64) error
+	LikeTx(ctx context.Context, q Querier, userID, postID int64) error
+	Unlike(ctx context.Context, userID, postID int64) error
+	UnlikeTx(ctx context.Context, q Querier, userID, postID int64) error
+	Count(ctx context.Context, postID int64) (int64, error)
+}
+
+type NotificationsStore interface {
+	Create(context.Context, *Notification) error
+	CreateTx(context.Context, Querier, *Notification) error
+	ListForUser(ctx context.Context, userID int64, onlyUnread bool, fq FeedQuery) ([]Notification, error)
+	MarkRead(ctx context.Context, userID, notificationID int64) error
+}
+
+type PostsStore interface {
+	Create(context.Context, *Post) error
+	CreateTx(context.Context, Querier, *Post) error
+	GetByID(context.Context, int64) (*Post, error)
+	GetByIDPrimary(context.Context, int64) (*Post, error)
+	GetWithDetails(ctx context.Context, id int64) (*PostDetails, error)
+	GetUserFeed(ctx context.Context, userID int64, fq FeedQuery) ([]PostWithMetadata, error)
+	GetByUser(ctx context.Context, userID int64, fq FeedQuery) ([]PostWithMetadata, error)
+	List(context.Context, PostFilter) ([]Post, int, error)
+	Search(ctx context.Context, query string, fq FeedQuery) ([]Post, error)
+	Update(context.Context, *Post) error
+	Patch(ctx context.Context, id int64, fields PostPatch) (*Post, error)
+	Delete(ctx context.Context, postID int64) error
+	DeleteTx(ctx context.Context, q Querier, postID int64) error
+}
+
+type RefreshTokensStore interface {
+	Create(ctx context.Context, userID int64, token string, ttl time.Duration) error
+	Rotate(ctx context.Context, token, newToken string, ttl time.Duration) (int64, error)
+	Revoke(ctx context.Context, token string) error
+}
+
+type TagsStore interface {
+	Trending(ctx context.Context, since time.Time, limit int) ([]TagCount, error)
+}
+
+type WebhooksStore interface {
+	Create(ctx context.Context, hook *Webhook) error
+	ListForEvent(ctx context.Context, event string) ([]Webhook, error)
+	RecordFailure(ctx context.Context, f *WebhookFailure) error
+}
+
+type UsersStore interface {
+	Create(context.Context, *User) error
+	CreateTx(context.Context, Querier, *User) error
+	CreateAndInvite(ctx context.Context, user *User, token string, exp time.Duration) error
+	Activate(ctx context.Context, token string) error
+	ReissueInvitation(ctx context.Context, email, token string, exp, minInterval time.Duration) (*User, error)
+	CreatePasswordReset(ctx context.Context, email, token string, exp time.Duration) (*User, error)
+	ResetPassword(ctx context.Context, token, password string) error
+	GetByID(context.Context, int64) (*User, error)
+	GetByIDPrimary(context.Context, int64) (*User, error)
+	GetByIDs(ctx context.Context, ids []int64) (map[int64]*User, error)
+	GetByEmail(context.Context, string) (*User, error)
+	GetByUsername(context.Context, string) (*User, error)
+	GetProfile(ctx context.Context, username string) (*UserProfile, error)
+	List(ctx context.Context, limit, offset int, sort string) ([]User, int, error)
+	SetAvatarURL(ctx context.Context, id int64, url string) error
+	SoftDelete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id int64, mode DeleteMode) error
+}
+
+var (
+	_ BlocksStore        = (*BlocksStorage)(nil)
+	_ CommentsStore      = (*CommentsStorage)(nil)
+	_ ExportStore        = (*ExportStorage)(nil)
+	_ FollowersStore     = (*FollowersStorage)(nil)
+	_ IdempotencyStore   = (*IdempotencyStorage)(nil)
+	_ LikesStore         = (*LikesStorage)(nil)
+	_ NotificationsStore = (*NotificationsStorage)(nil)
+	_ PostsStore         = (*PostsStorage)(nil)
+	_ RefreshTokensStore = (*RefreshTokensStorage)(nil)
+	_ TagsStore          = (*TagsStorage)(nil)
+	_ UsersStore         = (*UsersStorage)(nil)
+	_ WebhooksStore      = (*WebhooksStorage)(nil)
 )
 
 type Storage struct {
-	Posts interface {
-		Create(context.Context, *Post) error
+	db           *sql.DB
+	replica      *sql.DB
+	queryTimeout time.Duration
+
+	Blocks        BlocksStore
+	Comments      CommentsStore
+	Export        ExportStore
+	Followers     FollowersStore
+	Idempotency   IdempotencyStore
+	Likes         LikesStore
+	Notifications NotificationsStore
+	Posts         PostsStore
+	RefreshTokens RefreshTokensStore
+	Tags          TagsStore
+	Users         UsersStore
+	Webhooks      WebhooksStore
+}
+
+// NewStorage builds the sub-stores on top of db. Read-heavy queries are sent
+// to replica when one is given; a nil replica sends everything to db.
+// Passwords are hashed at passwordCost, which ValidatePasswordCost should
+// have accepted.
+func NewStorage(db, replica *sql.DB, queryTimeout time.Duration, passwordCost int) Storage {
+	if replica == nil {
+		replica = db
 	}
-	Users interface {
-		Create(context.Context, *User) error
+
+	return Storage{
+		db:           db,
+		replica:      replica,
+		queryTimeout: queryTimeout,
+
+		Blocks:        &BlocksStorage{db: db, timeout: queryTimeout},
+		Comments:      &CommentsStorage{db: db, timeout: queryTimeout},
+		Export:        &ExportStorage{db: db, timeout: queryTimeout},
+		Followers:     &FollowersStorage{db: db, timeout: queryTimeout},
+		Idempotency:   &IdempotencyStorage{db: db, timeout: queryTimeout},
+		Likes:         &LikesStorage{db: db, timeout: queryTimeout},
+		Notifications: &NotificationsStorage{db: db, timeout: queryTimeout},
+		Posts:         &PostsStorage{db: db, replica: replica, timeout: queryTimeout},
+		RefreshTokens: &RefreshTokensStorage{db: db, timeout: queryTimeout},
+		Tags:          &TagsStorage{replica: replica, timeout: queryTimeout},
+		Users:         &UsersStorage{db: db, replica: replica, timeout: queryTimeout, passwordCost: passwordCost},
+		Webhooks:      &WebhooksStorage{db: db, timeout: queryTimeout},
 	}
 }
 
-func NewStorage(db *sql.DB) Storage {
-	return Storage{
-		Posts: &PostsStorage{db: db},
-		Users: &UsersStorage{db: db},
+// WithTx runs fn inside a single transaction, committing when fn returns nil
+// and rolling back otherwise.
+func (s Storage) WithTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	return withTx(ctx, s.db, fn)
+}
+
+func (s Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the primary pool and, when it is a separate pool, the replica.
+func (s Storage) Close() error {
+	err := s.db.Close()
+	if s.replica != s.db {
+		err = errors.Join(err, s.replica.Close())
 	}
+	return err
+}
+
+func (s Storage) Stats() sql.DBStats {
+	return s.db.Stats()
 }
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testQueryTimeout = time.Second
+
+// testPasswordCost keeps hashing cheap; the production cost only slows the
+// tests down.
+const testPasswordCost = bcrypt.MinCost
+
+// newMockDB returns a database backed by sqlmock. Expectations are matched
+// as regular expressions against the SQL text, and any left unmet fail the
+// test when it finishes.
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet sqlmock expectations: %v", err)
+		}
+		db.Close()
+	})
+
+	return db, mock
+}
+
+// newMockStorage returns a Storage over newMockDB.
+func newMockStorage(t *testing.T) (Storage, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock := newMockDB(t)
+	return NewStorage(db, nil, testQueryTimeout, testPasswordCost), mock
+}
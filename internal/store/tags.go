@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+type TagsStorage struct {
+	replica *sql.DB
+	timeout time.Duration
+}
+
+// Trending returns the limit most used tags on posts created since since,
+// most used first. Ties are broken alphabetically so the order is stable.
+func (s *TagsStorage) Trending(ctx context.Context, since time.Time, limit int) ([]TagCount, error) {
+	ctx, span := startSpan(ctx, "Tags.Trending")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT t.tag, COUNT(*) AS posts
+		FROM posts p
+		CROSS JOIN LATERAL unnest(p.tags) AS t(tag)
+		WHERE p.created_at >= $1
+		GROUP BY t.tag
+		ORDER BY posts DESC, t.tag ASC
+		LIMIT $2
+	`
+
+	rows, err := s.replica.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		tags = append(tags, tc)
+	}
+
+	return tags, ctxErr(ctx, rows.Err())
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTagsTrending(t *testing.T) {
+	s, mock := newMockStorage(t)
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`unnest\(p\.tags\)`).
+		WithArgs(since, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"tag", "posts"}).AddRow("go", 3).AddRow("sql", 1))
+
+	got, err := s.Tags.Trending(context.Background(), since, 2)
+	if err != nil {
+		t.Fatalf("Trending() error = %v", err)
+	}
+	want := []TagCount{{Tag: "go", Count: 3}, {Tag: "sql", Count: 1}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Trending() = %v, want %v", got, want)
+	}
+}
+
+func TestTagsTrendingIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	alice := createTestUser(t, s, "alice")
+
+	createTestPost(t, s, alice, "one", "go", "sql")
+	createTestPost(t, s, alice, "two", "go", "web")
+	createTestPost(t, s, alice, "three", "go", "sql")
+	createTestPost(t, s, alice, "four", "web")
+	// Old posts fall outside the window however popular their tags are.
+	for _, title := range []string{"old one", "old two", "old three"} {
+		old := createTestPost(t, s, alice, title, "legacy", "web")
+		if _, err := s.db.Exec(`UPDATE posts SET created_at = NOW() - INTERVAL '30 days' WHERE id = $1`, old.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.Tags.Trending(ctx, time.Now().Add(-7*24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Trending() error = %v", err)
+	}
+	// Ties are ordered by tag name.
+	want := []TagCount{{Tag: "go", Count: 3}, {Tag: "sql", Count: 2}, {Tag: "web", Count: 2}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Trending() = %v, want %v", got, want)
+	}
+
+	got, err = s.Tags.Trending(ctx, time.Now().Add(-7*24*time.Hour), 1)
+	if err != nil || !slices.Equal(got, want[:1]) {
+		t.Errorf("Trending(limit 1) = %v, %v; want %v", got, err, want[:1])
+	}
+
+	got, err = s.Tags.Trending(ctx, time.Now().Add(-60*24*time.Hour), 1)
+	if err != nil || !slices.Equal(got, []TagCount{{Tag: "web", Count: 5}}) {
+		t.Errorf("Trending() over 60 days = %v, %v; want web with 5 posts", got, err)
+	}
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestQueryTimeoutCancelsSlowQuery(t *testing.T) {
+	db, mock := newMockDB(t)
+	s := NewStorage(db, nil, 50*time.Millisecond, testPasswordCost)
+
+	mock.ExpectQuery(`FROM users`).
+		WithArgs(int64(1)).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "alice", "alice@example.com", RoleUser, true, "", "2024-01-01T00:00:00Z"))
+
+	start := time.Now()
+	_, err := s.Users.GetByID(context.Background(), 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetByID() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("GetByID() returned after %v, want it cut off near the 50ms timeout", elapsed)
+	}
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+
+	"github.com/rissabekov-wes/social/internal/db"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/rissabekov-wes/social/internal/store")
+
+// startSpan starts a client span named "store.<name>" as a child of any span
+// already in ctx. Without a configured tracer provider it is a no-op. The
+// name also labels the method's queries in the slow query log.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	ctx = db.WithQueryName(ctx, name)
+	return tracer.Start(ctx, "store."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", name),
+		),
+	)
+}
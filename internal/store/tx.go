@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is the subset of *sql.DB and *sql.Tx used by the sub-stores, so the
+// same method can run standalone or as part of a larger transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+var (
+	_ Querier = (*sql.DB)(nil)
+	_ Querier = (*sql.Tx)(nil)
+)
+
+func withTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	return withTxOptions(ctx, db, nil, fn)
+}
+
+// withSerializableTx runs fn in a SERIALIZABLE transaction, retrying the whole
+// transaction when Postgres reports a serialization failure or another
+// transient error. fn must therefore be safe to run more than once.
+func withSerializableTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	return withRetry(ctx, txRetryAttempts, func() error {
+		return withTxOptions(ctx, db, opts, fn)
+	})
+}
+
+func withTxOptions(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return ctxErr(ctx, err)
+	}
+
+	return ctxErr(ctx, tx.Commit())
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestWithTxCommits(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO followers`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectCommit()
+
+	err := s.WithTx(context.Background(), func(tx *sql.Tx) error {
+		return s.Followers.FollowTx(context.Background(), tx, 1, 2)
+	})
+	if err != nil {
+		t.Errorf("WithTx() error = %v", err)
+	}
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO followers`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
+
+	errNotify := errors.New("notification failed")
+	err := s.WithTx(context.Background(), func(tx *sql.Tx) error {
+		if err := s.Followers.FollowTx(context.Background(), tx, 1, 2); err != nil {
+			return err
+		}
+		return errNotify
+	})
+	if !errors.Is(err, errNotify) {
+		t.Errorf("WithTx() error = %v, want %v", err, errNotify)
+	}
+}
+
+func TestWithTxRollsBackOnStoreError(t *testing.T) {
+	s, mock := newMockStorage(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO followers`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`INSERT INTO notifications`).WillReturnError(errors.New("insert failed"))
+	mock.ExpectRollback()
+
+	err := s.WithTx(context.Background(), func(tx *sql.Tx) error {
+		if err := s.Followers.FollowTx(context.Background(), tx, 1, 2); err != nil {
+			return err
+		}
+		return s.Notifications.CreateTx(context.Background(), tx, &Notification{UserID: 2, ActorID: 1, Type: NotificationFollow})
+	})
+	if err == nil {
+		t.Error("WithTx() error = nil, want the insert error")
+	}
+}
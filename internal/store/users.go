@@ -3,39 +3,484 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
 )
 
+const pgUniqueViolation = "23505"
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// rolePrecedence orders roles so that a higher role satisfies any check for a
+// lower one.
+var rolePrecedence = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
 type User struct {
 	ID        int64  `json:"id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
+	Username  string `json:"username" validate:"required,min=3,max=30"`
+	Email     string `json:"email" validate:"required,email"`
 	Password  string `json:"-"`
+	Role      string `json:"role"`
+	IsActive  bool   `json:"is_active"`
+	AvatarURL string `json:"avatar_url,omitempty"`
 	CreatedAt string `json:"created_at"`
 }
 
+// HasRole reports whether u holds role or one that outranks it.
+func (u *User) HasRole(role string) bool {
+	required, ok := rolePrecedence[role]
+	if !ok {
+		return false
+	}
+	return rolePrecedence[u.Role] >= required
+}
+
+// UserProfile is the public view of a user. It never carries the email or
+// password.
+type UserProfile struct {
+	ID             int64   `json:"id"`
+	Username       string  `json:"username"`
+	AvatarURL      string  `json:"avatar_url,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	FollowersCount int     `json:"followers_count"`
+	FollowingCount int     `json:"following_count"`
+	PostsCount     int     `json:"posts_count"`
+	LastPostAt     *string `json:"last_post_at,omitempty"`
+}
+
+func (u *User) ComparePassword(plain string) error {
+	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(plain))
+}
+
 type UsersStorage struct {
-	// Define fields for user storage, e.g., database connection
-	db *sql.DB
+	db           *sql.DB
+	replica      *sql.DB
+	timeout      time.Duration
+	passwordCost int
 }
 
 func (s *UsersStorage) Create(ctx context.Context, user *User) error {
-	// Implement user creation logic
+	return s.CreateTx(ctx, s.db, user)
+}
+
+func (s *UsersStorage) CreateTx(ctx context.Context, q Querier, user *User) error {
+	user.Email = normalizeEmail(user.Email)
+
+	hash, err := HashPassword(user.Password, s.passwordCost)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+	user.Password = hash
+
+	ctx, span := startSpan(ctx, "Users.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO users (username, password, email) VALUES ($1, $2, $3)
-		RETURNING id, created_at
+		INSERT INTO users (username, password, email, is_active) VALUES ($1, $2, $3, $4)
+		RETURNING id, role, is_active, created_at
 	`
-	err := s.db.QueryRowContext(
+	err = q.QueryRowContext(
 		ctx,
 		query,
 		user.Username,
 		user.Password,
 		user.Email,
+		user.IsActive,
 	).Scan(
 		&user.ID,
+		&user.Role,
+		&user.IsActive,
 		&user.CreatedAt,
 	)
 	if err != nil {
-		return err
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation {
+			switch pqErr.Constraint {
+			case "users_email_key":
+				return ErrDuplicateEmail
+			case "users_username_key":
+				return ErrDuplicateUsername
+			}
+		}
+		return ctxErr(ctx, err)
+	}
+
+	return nil
+}
+
+func (s *UsersStorage) GetByID(ctx context.Context, id int64) (*User, error) {
+	return s.getByID(ctx, s.replica, "Users.GetByID", id)
+}
+
+// GetByIDPrimary is GetByID read from the primary, for lookups that must see
+// a user created or changed moments ago, such as authenticating a request.
+func (s *UsersStorage) GetByIDPrimary(ctx context.Context, id int64) (*User, error) {
+	return s.getByID(ctx, s.db, "Users.GetByIDPrimary", id)
+}
+
+func (s *UsersStorage) getByID(ctx context.Context, q Querier, spanName string, id int64) (*User, error) {
+	ctx, span := startSpan(ctx, spanName)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, username, email, role, is_active, avatar_url, created_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	user := &User{}
+	err := q.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Role,
+		&user.IsActive,
+		&user.AvatarURL,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxErr(ctx, err)
+		}
+	}
+
+	return user, nil
+}
+
+// GetByIDs fetches every active user in ids with a single query, keyed by ID.
+// Duplicate IDs are collapsed and missing users are simply absent from the
+// result.
+func (s *UsersStorage) GetByIDs(ctx context.Context, ids []int64) (map[int64]*User, error) {
+	users := make(map[int64]*User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	seen := make(map[int64]struct{}, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+
+	ctx, span := startSpan(ctx, "Users.GetByIDs")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, username, email, role, is_active, avatar_url, created_at
+		FROM users
+		WHERE id = ANY($1) AND deleted_at IS NULL
+	`
+
+	rows, err := s.replica.QueryContext(ctx, query, pq.Array(unique))
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u := &User{}
+		err := rows.Scan(
+			&u.ID,
+			&u.Username,
+			&u.Email,
+			&u.Role,
+			&u.IsActive,
+			&u.AvatarURL,
+			&u.CreatedAt,
+		)
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+
+		users[u.ID] = u
+	}
+
+	return users, ctxErr(ctx, rows.Err())
+}
+
+func (s *UsersStorage) GetByEmail(ctx context.Context, email string) (*User, error) {
+	ctx, span := startSpan(ctx, "Users.GetByEmail")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, username, email, password, role, is_active, avatar_url, created_at
+		FROM users
+		WHERE email = $1 AND deleted_at IS NULL
+	`
+
+	user := &User{}
+	err := s.db.QueryRowContext(ctx, query, normalizeEmail(email)).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.IsActive,
+		&user.AvatarURL,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxErr(ctx, err)
+		}
+	}
+
+	return user, nil
+}
+
+func (s *UsersStorage) GetByUsername(ctx context.Context, username string) (*User, error) {
+	ctx, span := startSpan(ctx, "Users.GetByUsername")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, username, email, role, is_active, avatar_url, created_at
+		FROM users
+		WHERE username = $1 AND deleted_at IS NULL
+	`
+
+	user := &User{}
+	err := s.db.QueryRowContext(ctx, query, username).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Role,
+		&user.IsActive,
+		&user.AvatarURL,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxErr(ctx, err)
+		}
+	}
+
+	return user, nil
+}
+
+func (s *UsersStorage) GetProfile(ctx context.Context, username string) (*UserProfile, error) {
+	ctx, span := startSpan(ctx, "Users.GetProfile")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			u.id, u.username, u.avatar_url, u.created_at,
+			(SELECT COUNT(*) FROM followers f WHERE f.user_id = u.id) AS followers_count,
+			(SELECT COUNT(*) FROM followers f WHERE f.follower_id = u.id) AS following_count,
+			ps.posts_count,
+			ps.last_post_at
+		FROM users u
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS posts_count, MAX(p.created_at) AS last_post_at
+			FROM posts p
+			WHERE p.user_id = u.id
+		) ps ON TRUE
+		WHERE u.username = $1 AND u.deleted_at IS NULL
+	`
+
+	profile := &UserProfile{}
+	err := s.db.QueryRowContext(ctx, query, username).Scan(
+		&profile.ID,
+		&profile.Username,
+		&profile.AvatarURL,
+		&profile.CreatedAt,
+		&profile.FollowersCount,
+		&profile.FollowingCount,
+		&profile.PostsCount,
+		&profile.LastPostAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, ctxErr(ctx, err)
+		}
+	}
+
+	return profile, nil
+}
+
+func (s *UsersStorage) SoftDelete(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "Users.SoftDelete")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE users SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidatePasswordCost checks a bcrypt cost read from configuration. bcrypt
+// itself rejects a cost above the maximum only when hashing, and silently
+// replaces one below the minimum.
+func ValidatePasswordCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+	return nil
+}
+
+func HashPassword(plain string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// userListOrder maps the sort keys accepted by List to their ORDER BY
+// clauses. A leading "-" sorts descending.
+var userListOrder = map[string]string{
+	"created_at":  "created_at ASC, id ASC",
+	"-created_at": "created_at DESC, id DESC",
+	"username":    "username ASC",
+	"-username":   "username DESC",
+}
+
+// List returns a page of active users ordered by sort, together with the
+// total number of active users. Unknown sort keys fall back to newest first.
+func (s *UsersStorage) List(ctx context.Context, limit, offset int, sort string) ([]User, int, error) {
+	ctx, span := startSpan(ctx, "Users.List")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM users WHERE deleted_at IS NULL`
+	if err := s.replica.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
+		return nil, 0, ctxErr(ctx, err)
+	}
+
+	order, ok := userListOrder[sort]
+	if !ok {
+		order = userListOrder["-created_at"]
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, username, email, role, is_active, avatar_url, created_at
+		FROM users
+		WHERE deleted_at IS NULL
+		ORDER BY %s
+		LIMIT $1 OFFSET $2
+	`, order)
+
+	rows, err := s.replica.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		var u User
+		err := rows.Scan(
+			&u.ID,
+			&u.Username,
+			&u.Email,
+			&u.Role,
+			&u.IsActive,
+			&u.AvatarURL,
+			&u.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, ctxErr(ctx, err)
+		}
+
+		users = append(users, u)
+	}
+
+	return users, total, ctxErr(ctx, rows.Err())
+}
+
+func (s *UsersStorage) SetAvatarURL(ctx context.Context, id int64, url string) error {
+	ctx, span := startSpan(ctx, "Users.SetAvatarURL")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `UPDATE users SET avatar_url = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, url, id)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	if rows == 0 {
+		return ErrNotFound
 	}
 
 	return nil
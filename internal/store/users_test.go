@@ -0,0 +1,468 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var userColumns = []string{"id", "username", "email", "role", "is_active", "avatar_url", "created_at"}
+
+func TestUserHasRole(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		want     bool
+	}{
+		{role: RoleUser, required: RoleUser, want: true},
+		{role: RoleUser, required: RoleModerator, want: false},
+		{role: RoleUser, required: RoleAdmin, want: false},
+		{role: RoleModerator, required: RoleUser, want: true},
+		{role: RoleModerator, required: RoleModerator, want: true},
+		{role: RoleModerator, required: RoleAdmin, want: false},
+		{role: RoleAdmin, required: RoleModerator, want: true},
+		{role: RoleAdmin, required: RoleAdmin, want: true},
+		{role: "", required: RoleUser, want: false},
+		{role: RoleAdmin, required: "superuser", want: false},
+	}
+
+	for _, tt := range tests {
+		user := &User{Role: tt.role}
+		if got := user.HasRole(tt.required); got != tt.want {
+			t.Errorf("User{Role: %q}.HasRole(%q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestUsersGetByID(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`SELECT id, username, email, role, is_active, avatar_url, created_at\s+FROM users\s+WHERE id = \$1`).
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows(userColumns).
+				AddRow(7, "alice", "alice@example.com", RoleUser, true, "", "2024-01-01T00:00:00Z"))
+
+		user, err := s.Users.GetByID(context.Background(), 7)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if user.ID != 7 || user.Username != "alice" || user.Email != "alice@example.com" {
+			t.Errorf("GetByID() = %+v", user)
+		}
+		if user.Password != "" {
+			t.Errorf("GetByID() returned a password")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`FROM users`).
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows(userColumns))
+
+		if _, err := s.Users.GetByID(context.Background(), 7); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByID() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		dbErr := errors.New("connection reset")
+		mock.ExpectQuery(`FROM users`).
+			WithArgs(int64(7)).
+			WillReturnError(dbErr)
+
+		_, err := s.Users.GetByID(context.Background(), 7)
+		if !errors.Is(err, dbErr) || errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByID() error = %v, want %v", err, dbErr)
+		}
+	})
+}
+
+func TestUsersGetByEmail(t *testing.T) {
+	columns := []string{"id", "username", "email", "password", "role", "is_active", "avatar_url", "created_at"}
+
+	for _, email := range []string{"alice@example.com", "Alice@Example.com", "  ALICE@EXAMPLE.COM "} {
+		t.Run(email, func(t *testing.T) {
+			s, mock := newMockStorage(t)
+
+			mock.ExpectQuery(`SELECT id, username, email, password, .* FROM users\s+WHERE email = \$1`).
+				WithArgs("alice@example.com").
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(7, "alice", "alice@example.com", "$2a$hash", RoleUser, true, "", "2024-01-01T00:00:00Z"))
+
+			user, err := s.Users.GetByEmail(context.Background(), email)
+			if err != nil {
+				t.Fatalf("GetByEmail() error = %v", err)
+			}
+			if user.ID != 7 || user.Password != "$2a$hash" {
+				t.Errorf("GetByEmail() = %+v, want user 7 with its password hash", user)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`FROM users`).
+			WithArgs("nobody@example.com").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		if _, err := s.Users.GetByEmail(context.Background(), "nobody@example.com"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetByEmail() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUsersSoftDelete(t *testing.T) {
+	t.Run("deleted", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectExec(`UPDATE users SET deleted_at = NOW\(\)\s+WHERE id = \$1 AND deleted_at IS NULL`).
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := s.Users.SoftDelete(context.Background(), 7); err != nil {
+			t.Errorf("SoftDelete() error = %v", err)
+		}
+	})
+
+	t.Run("missing or already deleted", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectExec(`UPDATE users SET deleted_at`).
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := s.Users.SoftDelete(context.Background(), 7); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SoftDelete() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUsersSoftDeleteIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	user := createTestUser(t, s, "alice")
+
+	if err := s.Users.SoftDelete(ctx, user.ID); err != nil {
+		t.Fatalf("SoftDelete() error = %v", err)
+	}
+
+	if _, err := s.Users.GetByID(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID() error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Users.GetByEmail(ctx, user.Email); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByEmail() error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Users.GetByUsername(ctx, user.Username); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByUsername() error = %v, want ErrNotFound", err)
+	}
+	if err := s.Users.SoftDelete(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second SoftDelete() error = %v, want ErrNotFound", err)
+	}
+
+	if got := countRows(t, s, `SELECT COUNT(*) FROM users WHERE id = $1 AND deleted_at IS NOT NULL`, user.ID); got != 1 {
+		t.Errorf("soft-deleted rows = %d, want 1", got)
+	}
+}
+
+func TestUsersCreateUniqueViolation(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       error
+	}{
+		{constraint: "users_email_key", want: ErrDuplicateEmail},
+		{constraint: "users_username_key", want: ErrDuplicateUsername},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			s, mock := newMockStorage(t)
+
+			mock.ExpectQuery(`INSERT INTO users`).
+				WillReturnError(&pq.Error{Code: pgUniqueViolation, Constraint: tt.constraint})
+
+			err := s.Users.Create(context.Background(), &User{Username: "alice", Email: "alice@example.com", Password: "correct horse"})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Create() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("other constraint", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		pqErr := &pq.Error{Code: pgUniqueViolation, Constraint: "users_pkey"}
+		mock.ExpectQuery(`INSERT INTO users`).WillReturnError(pqErr)
+
+		err := s.Users.Create(context.Background(), &User{Username: "alice", Email: "alice@example.com", Password: "correct horse"})
+		if !errors.Is(err, pqErr) || errors.Is(err, ErrDuplicateEmail) || errors.Is(err, ErrDuplicateUsername) {
+			t.Errorf("Create() error = %v, want the driver error", err)
+		}
+	})
+}
+
+func TestUsersCreateUniqueViolationIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	createTestUser(t, s, "alice")
+
+	tests := []struct {
+		name string
+		user User
+		want error
+	}{
+		{
+			name: "email",
+			user: User{Username: "alice2", Email: "Alice@Example.com", Password: "correct horse"},
+			want: ErrDuplicateEmail,
+		},
+		{
+			name: "username",
+			user: User{Username: "alice", Email: "alice2@example.com", Password: "correct horse"},
+			want: ErrDuplicateUsername,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Users.Create(ctx, &tt.user); !errors.Is(err, tt.want) {
+				t.Errorf("Create() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsersGetProfileIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+	carol := createTestUser(t, s, "carol")
+
+	t.Run("no activity", func(t *testing.T) {
+		profile, err := s.Users.GetProfile(ctx, "alice")
+		if err != nil {
+			t.Fatalf("GetProfile() error = %v", err)
+		}
+		if profile.ID != alice.ID || profile.FollowersCount != 0 || profile.FollowingCount != 0 || profile.PostsCount != 0 || profile.LastPostAt != nil {
+			t.Errorf("GetProfile() = %+v, want alice with zero counts", profile)
+		}
+	})
+
+	for _, follower := range []*User{bob, carol} {
+		if err := s.Followers.Follow(ctx, follower.ID, alice.ID); err != nil {
+			t.Fatalf("Follow() error = %v", err)
+		}
+	}
+	if err := s.Followers.Follow(ctx, alice.ID, bob.ID); err != nil {
+		t.Fatalf("Follow() error = %v", err)
+	}
+	createTestPost(t, s, alice, "First")
+	createTestPost(t, s, alice, "Second")
+
+	t.Run("counts", func(t *testing.T) {
+		profile, err := s.Users.GetProfile(ctx, "alice")
+		if err != nil {
+			t.Fatalf("GetProfile() error = %v", err)
+		}
+		if profile.FollowersCount != 2 || profile.FollowingCount != 1 || profile.PostsCount != 2 || profile.LastPostAt == nil {
+			t.Errorf("GetProfile() = %+v, want 2 followers, 1 following and 2 posts", profile)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := s.Users.GetProfile(ctx, "nobody"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetProfile() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("soft deleted", func(t *testing.T) {
+		if err := s.Users.SoftDelete(ctx, carol.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Users.GetProfile(ctx, "carol"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetProfile() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestUsersListIntegration(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"carol", "alice", "bob"} {
+		createTestUser(t, s, name)
+	}
+	deleted := createTestUser(t, s, "dave")
+	if err := s.Users.SoftDelete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		limit, offset int
+		sort          string
+		want          []string
+	}{
+		{name: "first page", limit: 2, sort: "username", want: []string{"alice", "bob"}},
+		{name: "last page", limit: 2, offset: 2, sort: "username", want: []string{"carol"}},
+		{name: "past the end", limit: 2, offset: 10, sort: "username", want: []string{}},
+		{name: "descending", limit: 10, sort: "-username", want: []string{"carol", "bob", "alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := s.Users.List(ctx, tt.limit, tt.offset, tt.sort)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if total != 3 {
+				t.Errorf("total = %d, want 3", total)
+			}
+
+			got := make([]string, 0, len(users))
+			for _, u := range users {
+				got = append(got, u.Username)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsersGetByIDs(t *testing.T) {
+	t.Run("duplicates collapsed", func(t *testing.T) {
+		s, mock := newMockStorage(t)
+
+		mock.ExpectQuery(`FROM users\s+WHERE id = ANY\(\$1\) AND deleted_at IS NULL`).
+			WithArgs("{3,1}").
+			WillReturnRows(sqlmock.NewRows(userColumns).
+				AddRow(1, "alice", "alice@example.com", RoleUser, true, "", "2024-01-01T00:00:00Z").
+				AddRow(3, "carol", "carol@example.com", RoleUser, true, "", "2024-01-01T00:00:00Z"))
+
+		users, err := s.Users.GetByIDs(context.Background(), []int64{3, 1, 3, 1, 1})
+		if err != nil {
+			t.Fatalf("GetByIDs() error = %v", err)
+		}
+		if len(users) != 2 || users[1].Username != "alice" || users[3].Username != "carol" {
+			t.Errorf("GetByIDs() = %v, want alice and carol keyed by ID", users)
+		}
+	})
+
+	for _, ids := range [][]int64{nil, {}} {
+		t.Run("empty", func(t *testing.T) {
+			// No query is expected; the mock fails the test if one is made.
+			s, _ := newMockStorage(t)
+
+			users, err := s.Users.GetByIDs(context.Background(), ids)
+			if err != nil {
+				t.Fatalf("GetByIDs() error = %v", err)
+			}
+			if users == nil || len(users) != 0 {
+				t.Errorf("GetByIDs(%v) = %#v, want an empty map", ids, users)
+			}
+		})
+	}
+}
+
+func TestUsersGetProfilePostStats(t *testing.T) {
+	columns := []string{"id", "username", "avatar_url", "created_at", "followers_count", "following_count", "posts_count", "last_post_at"}
+
+	tests := []struct {
+		name         string
+		postsCount   int
+		lastPostAt   any
+		wantLastPost bool
+	}{
+		{name: "without posts", postsCount: 0, lastPostAt: nil},
+		{name: "with posts", postsCount: 3, lastPostAt: "2024-02-01T00:00:00Z", wantLastPost: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newMockStorage(t)
+
+			mock.ExpectQuery(`FROM users u\s+LEFT JOIN LATERAL`).
+				WithArgs("alice").
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(7, "alice", "", "2024-01-01T00:00:00Z", 0, 0, tt.postsCount, tt.lastPostAt))
+
+			profile, err := s.Users.GetProfile(context.Background(), "alice")
+			if err != nil {
+				t.Fatalf("GetProfile() error = %v", err)
+			}
+			if profile.PostsCount != tt.postsCount || (profile.LastPostAt != nil) != tt.wantLastPost {
+				t.Errorf("GetProfile() = %+v, want %d posts", profile, tt.postsCount)
+			}
+
+			js, err := json.Marshal(profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(string(js), `"last_post_at"`); got != tt.wantLastPost {
+				t.Errorf("JSON %s has last_post_at: %v, want %v", js, got, tt.wantLastPost)
+			}
+			if !strings.Contains(string(js), fmt.Sprintf(`"posts_count":%d`, tt.postsCount)) {
+				t.Errorf("JSON %s is missing posts_count", js)
+			}
+		})
+	}
+}
+
+func TestValidatePasswordCost(t *testing.T) {
+	for _, cost := range []int{bcrypt.MinCost, bcrypt.DefaultCost, bcrypt.MaxCost} {
+		if err := ValidatePasswordCost(cost); err != nil {
+			t.Errorf("ValidatePasswordCost(%d) error = %v", cost, err)
+		}
+	}
+	for _, cost := range []int{0, bcrypt.MinCost - 1, bcrypt.MaxCost + 1, 40} {
+		if err := ValidatePasswordCost(cost); err == nil {
+			t.Errorf("ValidatePasswordCost(%d) succeeded, want an error", cost)
+		}
+	}
+}
+
+// hashCost matches a bcrypt hash argument made at cost.
+type hashCost int
+
+func (c hashCost) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	cost, err := bcrypt.Cost([]byte(s))
+	return err == nil && cost == int(c)
+}
+
+func TestUsersCreateHashesAtConfiguredCost(t *testing.T) {
+	const cost = bcrypt.MinCost + 1
+
+	db, mock := newMockDB(t)
+	s := NewStorage(db, nil, testQueryTimeout, cost)
+
+	mock.ExpectQuery(`INSERT INTO users`).
+		WithArgs("alice", hashCost(cost), "alice@example.com", false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "is_active", "created_at"}).AddRow(1, RoleUser, false, "2024-01-01T00:00:00Z"))
+
+	if err := s.Users.Create(context.Background(), &User{Username: "alice", Email: "alice@example.com", Password: "correct horse"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+}
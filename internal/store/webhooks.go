@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Webhook is a subscriber URL that is sent the listed events. Secret keys the
+// HMAC signature on every delivery, so it has to be kept in the clear.
+type Webhook struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"-"`
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// WebhookFailure records a delivery that was given up on after its last
+// attempt. StatusCode is zero when no response was received.
+type WebhookFailure struct {
+	WebhookID  int64
+	Event      string
+	Payload    json.RawMessage
+	Attempts   int
+	StatusCode int
+	Error      string
+}
+
+type WebhooksStorage struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func (s *WebhooksStorage) Create(ctx context.Context, hook *Webhook) error {
+	ctx, span := startSpan(ctx, "Webhooks.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO webhooks (url, secret, events)
+		VALUES ($1, $2, $3) RETURNING id, active, created_at
+	`
+
+	err := s.db.QueryRowContext(ctx, query, hook.URL, hook.Secret, pq.Array(hook.Events)).Scan(
+		&hook.ID,
+		&hook.Active,
+		&hook.CreatedAt,
+	)
+	return ctxErr(ctx, err)
+}
+
+// ListForEvent returns the active webhooks subscribed to event.
+func (s *WebhooksStorage) ListForEvent(ctx context.Context, event string) ([]Webhook, error) {
+	ctx, span := startSpan(ctx, "Webhooks.ListForEvent")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		SELECT id, url, secret, events, active, created_at
+		FROM webhooks
+		WHERE active AND events @> ARRAY[$1]::text[]
+		ORDER BY id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, event)
+	if err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		var h Webhook
+		if err := rows.Scan(&h.ID, &h.URL, &h.Secret, pq.Array(&h.Events), &h.Active, &h.CreatedAt); err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		hooks = append(hooks, h)
+	}
+
+	return hooks, ctxErr(ctx, rows.Err())
+}
+
+func (s *WebhooksStorage) RecordFailure(ctx context.Context, f *WebhookFailure) error {
+	ctx, span := startSpan(ctx, "Webhooks.RecordFailure")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO webhook_failures (webhook_id, event, payload, attempts, status_code, error)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6)
+	`
+
+	_, err := s.db.ExecContext(ctx, query, f.WebhookID, f.Event, string(f.Payload), f.Attempts, f.StatusCode, f.Error)
+	return ctxErr(ctx, err)
+}
@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// EventPostCreated is sent with the new post after it is stored.
+const EventPostCreated = "post.created"
+
+// Headers set on every delivery. SignatureHeader carries "sha256=" followed
+// by the hex HMAC-SHA256 of the raw body keyed with the webhook's secret.
+const (
+	EventHeader     = "X-Social-Event"
+	DeliveryHeader  = "X-Social-Delivery"
+	SignatureHeader = "X-Social-Signature"
+)
+
+// Sign returns the SignatureHeader value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the SignatureHeader value for body,
+// comparing in constant time.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Config tunes delivery. Attempts counts the first try; the wait before retry
+// n is Backoff doubled n-1 times, capped at MaxBackoff, with jitter.
+type Config struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Timeout    time.Duration
+}
+
+// Dispatcher POSTs signed payloads to subscribers and works out when a
+// failed delivery should be retried. It is safe for concurrent use.
+type Dispatcher struct {
+	client *http.Client
+	cfg    Config
+}
+
+func NewDispatcher(cfg Config) *Dispatcher {
+	return &Dispatcher{
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// A redirect would turn the POST into a GET; report it instead.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg: cfg,
+	}
+}
+
+// Delivery is one event on its way to one subscriber. Attempts and
+// StatusCode, that of the last response and zero when none was received,
+// are kept up to date by Attempt.
+type Delivery struct {
+	URL    string
+	Secret string
+	Event  string
+	ID     string
+	Body   []byte
+
+	Attempts   int
+	StatusCode int
+}
+
+// Attempt POSTs del once. Any 2xx counts as delivered. On failure, retry
+// reports whether another attempt is worthwhile: only after a network error,
+// 408, 429 or 5xx, while attempts remain and ctx is not done. wait is the
+// backoff to observe before that attempt.
+//
+// Attempt never sleeps, so the caller decides how to wait; a worker pool
+// need not sit idle through the backoff.
+func (d *Dispatcher) Attempt(ctx context.Context, del *Delivery) (retry bool, wait time.Duration, err error) {
+	del.Attempts++
+
+	del.StatusCode, retry, err = d.post(ctx, del.URL, del.Secret, del.Event, del.ID, del.Body)
+	if err == nil || !retry || del.Attempts >= max(d.cfg.Attempts, 1) {
+		return false, 0, err
+	}
+
+	return true, d.backoff(del.Attempts), err
+}
+
+func (d *Dispatcher) post(ctx context.Context, url, secret, event, deliveryID string, body []byte) (status int, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "social-webhooks")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, deliveryID)
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	// Drain a little so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+
+	retry = resp.StatusCode >= 500 ||
+		resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, retry, fmt.Errorf("webhook responded %d", resp.StatusCode)
+}
+
+// backoff is the wait before the retry following attempt n, with the upper
+// half jittered so retries from many deliveries spread out.
+func (d *Dispatcher) backoff(n int) time.Duration {
+	wait := d.cfg.Backoff << (n - 1)
+	if d.cfg.MaxBackoff > 0 && (wait > d.cfg.MaxBackoff || wait <= 0) {
+		wait = d.cfg.MaxBackoff
+	}
+	if wait <= 1 {
+		return wait
+	}
+	return wait/2 + rand.N(wait/2)
+}
@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"post.created"}`)
+	sig := Sign("secret", body)
+
+	if !Verify("secret", body, sig) {
+		t.Error("Verify() rejected its own signature")
+	}
+	if Verify("other", body, sig) {
+		t.Error("Verify() accepted a signature made with another secret")
+	}
+	if Verify("secret", []byte(`{"event":"post.deleted"}`), sig) {
+		t.Error("Verify() accepted a signature of another body")
+	}
+}
+
+func TestAttemptDelivers(t *testing.T) {
+	body := []byte(`{"id":"d-1","event":"post.created"}`)
+
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+
+	d := NewDispatcher(Config{Attempts: 3, Backoff: time.Second, Timeout: time.Second})
+	del := &Delivery{URL: srv.URL, Secret: "secret", Event: EventPostCreated, ID: "d-1", Body: body}
+
+	retry, _, err := d.Attempt(context.Background(), del)
+	if err != nil || retry {
+		t.Fatalf("Attempt() = %v, %v; want delivered", retry, err)
+	}
+	if del.Attempts != 1 || del.StatusCode != http.StatusAccepted {
+		t.Errorf("delivery = %d attempts, status %d; want 1 and 202", del.Attempts, del.StatusCode)
+	}
+
+	if got.Method != http.MethodPost || got.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s with Content-Type %q, want a JSON POST", got.Method, got.Header.Get("Content-Type"))
+	}
+	if string(gotBody) != string(body) {
+		t.Errorf("body = %s, want %s", gotBody, body)
+	}
+	if got.Header.Get(EventHeader) != EventPostCreated || got.Header.Get(DeliveryHeader) != "d-1" {
+		t.Errorf("event and delivery headers = %q, %q", got.Header.Get(EventHeader), got.Header.Get(DeliveryHeader))
+	}
+	if !Verify("secret", gotBody, got.Header.Get(SignatureHeader)) {
+		t.Errorf("signature %q does not verify", got.Header.Get(SignatureHeader))
+	}
+}
+
+func TestAttemptRetryDecision(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantRetry bool
+	}{
+		{status: http.StatusInternalServerError, wantRetry: true},
+		{status: http.StatusBadGateway, wantRetry: true},
+		{status: http.StatusServiceUnavailable, wantRetry: true},
+		{status: http.StatusRequestTimeout, wantRetry: true},
+		{status: http.StatusTooManyRequests, wantRetry: true},
+		{status: http.StatusBadRequest},
+		{status: http.StatusUnauthorized},
+		{status: http.StatusNotFound},
+		{status: http.StatusGone},
+		// A redirect is reported, not followed.
+		{status: http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "/elsewhere")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(srv.Close)
+
+			d := NewDispatcher(Config{Attempts: 3, Backoff: time.Second, Timeout: time.Second})
+			del := &Delivery{URL: srv.URL, Secret: "secret", Event: EventPostCreated, Body: []byte(`{}`)}
+
+			retry, wait, err := d.Attempt(context.Background(), del)
+			if err == nil {
+				t.Fatal("Attempt() succeeded")
+			}
+			if retry != tt.wantRetry {
+				t.Errorf("retry = %v, want %v", retry, tt.wantRetry)
+			}
+			if retry && (wait < 500*time.Millisecond || wait > time.Second) {
+				t.Errorf("wait = %v, want the first backoff of 1s, jittered by up to half", wait)
+			}
+			if del.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", del.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestAttemptRetriesOnServerErrorUntilAttemptsRunOut(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	d := NewDispatcher(Config{Attempts: 3, Backoff: time.Millisecond, Timeout: time.Second})
+	del := &Delivery{URL: srv.URL, Secret: "secret", Event: EventPostCreated, Body: []byte(`{}`)}
+
+	var err error
+	for retry := true; retry; {
+		retry, _, err = d.Attempt(context.Background(), del)
+	}
+
+	if err == nil {
+		t.Fatal("delivery succeeded against a failing server")
+	}
+	if n := hits.Load(); n != 3 || del.Attempts != 3 {
+		t.Errorf("server hit %d times over %d attempts, want 3", n, del.Attempts)
+	}
+	if del.StatusCode != http.StatusInternalServerError {
+		t.Errorf("StatusCode = %d, want 500", del.StatusCode)
+	}
+}
+
+func TestAttemptRecoversAfterServerError(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	d := NewDispatcher(Config{Attempts: 3, Backoff: time.Millisecond, Timeout: time.Second})
+	del := &Delivery{URL: srv.URL, Secret: "secret", Event: EventPostCreated, Body: []byte(`{}`)}
+
+	if retry, _, err := d.Attempt(context.Background(), del); !retry || err == nil {
+		t.Fatalf("first Attempt() = %v, %v; want a retryable failure", retry, err)
+	}
+	if retry, _, err := d.Attempt(context.Background(), del); retry || err != nil {
+		t.Fatalf("second Attempt() = %v, %v; want delivered", retry, err)
+	}
+	if del.Attempts != 2 || del.StatusCode != http.StatusOK {
+		t.Errorf("delivery = %d attempts, status %d; want 2 and 200", del.Attempts, del.StatusCode)
+	}
+}
+
+func TestAttemptNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	d := NewDispatcher(Config{Attempts: 2, Backoff: time.Millisecond, Timeout: time.Second})
+	del := &Delivery{URL: url, Secret: "secret", Event: EventPostCreated, Body: []byte(`{}`)}
+
+	retry, _, err := d.Attempt(context.Background(), del)
+	if err == nil || !retry {
+		t.Errorf("Attempt() = %v, %v; want a retryable error", retry, err)
+	}
+	if del.StatusCode != 0 {
+		t.Errorf("StatusCode = %d, want 0 without a response", del.StatusCode)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if retry, _, err := d.Attempt(ctx, &Delivery{URL: url, Body: []byte(`{}`)}); err == nil || retry {
+		t.Errorf("Attempt() with a cancelled context = %v, %v; want a final error", retry, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(Config{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+
+	for n, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		// Far past the shift width; still capped, not wrapped around.
+		80: time.Second,
+	} {
+		for range 20 {
+			if got := d.backoff(n); got < want/2 || got > want {
+				t.Errorf("backoff(%d) = %v, want within [%v, %v]", n, got, want/2, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+var (
+	ErrClosed    = errors.New("worker pool is shut down")
+	ErrQueueFull = errors.New("worker pool queue is full")
+)
+
+// Pool runs submitted tasks on a fixed number of goroutines. Tasks receive a
+// context that is cancelled only if Shutdown gives up waiting for them.
+type Pool struct {
+	tasks  chan func(context.Context)
+	logger *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+
+	// inFlight counts tasks that have been queued but not yet finished.
+	inFlight sync.WaitGroup
+}
+
+func NewPool(workers, queueSize int, logger *slog.Logger) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Pool{
+		tasks:  make(chan func(context.Context), queueSize),
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for range max(workers, 1) {
+		go p.work()
+	}
+
+	return p
+}
+
+// Submit queues task without blocking. It fails with ErrQueueFull when every
+// worker is busy and the queue is at capacity, and with ErrClosed once
+// Shutdown has been called.
+func (p *Pool) Submit(task func(context.Context)) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	p.inFlight.Add(1)
+	select {
+	case p.tasks <- task:
+		return nil
+	default:
+		p.inFlight.Done()
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting tasks and waits for queued and running ones to
+// finish. If ctx expires first, running tasks have their context cancelled
+// and ctx's error is returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	for task := range p.tasks {
+		p.run(task)
+	}
+}
+
+func (p *Pool) run(task func(context.Context)) {
+	defer p.inFlight.Done()
+	defer func() {
+		if rec := recover(); rec != nil {
+			p.logger.Error("worker task panicked", "error", fmt.Sprint(rec))
+		}
+	}()
+
+	task(p.ctx)
+}
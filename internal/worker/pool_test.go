@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestPool(t *testing.T, workers, queueSize int) *Pool {
+	t.Helper()
+
+	p := NewPool(workers, queueSize, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { p.Shutdown(context.Background()) })
+	return p
+}
+
+func TestPoolRunsTasks(t *testing.T) {
+	p := newTestPool(t, 3, 10)
+
+	var ran atomic.Int32
+	for range 10 {
+		if err := p.Submit(func(context.Context) { ran.Add(1) }); err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+	}
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := ran.Load(); got != 10 {
+		t.Errorf("%d tasks ran, want 10", got)
+	}
+}
+
+func TestPoolShutdownWaitsForTasks(t *testing.T) {
+	p := newTestPool(t, 1, 1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var finished atomic.Bool
+	p.Submit(func(context.Context) {
+		close(started)
+		<-release
+		finished.Store(true)
+	})
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- p.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown() returned %v while a task was running", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Shutdown() returned before the task finished")
+	}
+
+	if err := p.Submit(func(context.Context) {}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit() after Shutdown error = %v, want ErrClosed", err)
+	}
+}
+
+func TestPoolShutdownTimeoutCancelsTasks(t *testing.T) {
+	p := newTestPool(t, 1, 1)
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	p.Submit(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want DeadlineExceeded", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("task context was not cancelled when Shutdown gave up")
+	}
+}
+
+func TestPoolQueueFull(t *testing.T) {
+	p := newTestPool(t, 1, 1)
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	p.Submit(func(context.Context) {
+		close(started)
+		<-release
+	})
+	<-started
+
+	if err := p.Submit(func(context.Context) {}); err != nil {
+		t.Fatalf("Submit() into the queue error = %v", err)
+	}
+	if err := p.Submit(func(context.Context) {}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit() error = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestPoolRecoversPanics(t *testing.T) {
+	p := newTestPool(t, 1, 2)
+
+	var ran atomic.Bool
+	p.Submit(func(context.Context) { panic("boom") })
+	p.Submit(func(context.Context) { ran.Store(true) })
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if !ran.Load() {
+		t.Error("task after a panicking one did not run")
+	}
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    username varchar(255) NOT NULL,
+    email varchar(255) NOT NULL,
+    password text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
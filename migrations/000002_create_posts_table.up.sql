@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS posts (
+    id bigserial PRIMARY KEY,
+    title text NOT NULL,
+    user_id bigint NOT NULL REFERENCES users (id),
+    content text NOT NULL,
+    tags varchar(100) [],
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN version int NOT NULL DEFAULT 0;
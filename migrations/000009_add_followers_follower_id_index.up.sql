@@ -0,0 +1,3 @@
+-- The primary key (user_id, follower_id) already serves followers-of lookups;
+-- this index serves the reverse "who does this user follow" direction.
+CREATE INDEX IF NOT EXISTS idx_followers_follower_id ON followers (follower_id, user_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id bigserial PRIMARY KEY,
+    type text NOT NULL CHECK (type IN ('follow', 'comment', 'like')),
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    actor_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    entity_id bigint NOT NULL,
+    read boolean NOT NULL DEFAULT FALSE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id_created_at ON notifications (user_id, created_at, id);
+
+-- A user is notified at most once per follower, even across unfollow/refollow.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_follow_unique
+    ON notifications (user_id, actor_id)
+    WHERE type = 'follow';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_invitations;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_active;
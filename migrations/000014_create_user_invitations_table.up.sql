@@ -0,0 +1,11 @@
+-- Existing accounts stay active; only new signups start inactive.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_active boolean NOT NULL DEFAULT TRUE;
+ALTER TABLE users ALTER COLUMN is_active SET DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS user_invitations (
+    token bytea PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expiry timestamp(0) with time zone NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_invitations_user_id ON user_invitations (user_id);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts (created_at);
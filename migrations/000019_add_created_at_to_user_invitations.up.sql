@@ -0,0 +1 @@
+ALTER TABLE user_invitations ADD COLUMN IF NOT EXISTS created_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
@@ -0,0 +1,5 @@
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS parent_id bigint REFERENCES comments (id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS depth int NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS webhook_failures;
+DROP TABLE IF EXISTS webhooks;